@@ -0,0 +1,214 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"founders-toolkit-api/internal/audit"
+	"founders-toolkit-api/internal/bucket"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/models"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	ActionDeletionRequested = "account.deletion_requested"
+	ActionDeletionCancelled = "account.deletion_cancelled"
+	ActionDeleted           = "account.deleted"
+
+	defaultGraceDays = 30
+)
+
+const (
+	ErrInvalidPassword   = "Invalid password"
+	ErrNoPendingDeletion = "No pending deletion request"
+)
+
+// gracePeriod is read from ACCOUNT_DELETION_GRACE_DAYS, defaulting to 30 days.
+func gracePeriod() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("ACCOUNT_DELETION_GRACE_DAYS"))
+	if err != nil || days < 0 {
+		days = defaultGraceDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// POST /me/delete  schedule the account for erasure after the grace period
+func RequestDeletion(db *database.Service, auditor *audit.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uRaw, _ := c.Get("user")
+		user, _ := uRaw.(models.User)
+		if user.ID == 0 {
+			response.Respond(c, http.StatusUnauthorized, "unauthorized", nil)
+			return
+		}
+
+		var body struct {
+			Password string `json:"password" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(body.Password)); err != nil {
+			response.Respond(c, http.StatusUnauthorized, ErrInvalidPassword, nil)
+			return
+		}
+
+		var existing models.AccountDeletion
+		err := db.DB.
+			Where("user_id = ? AND status = ?", user.ID, models.DeletionStatusPending).
+			First(&existing).Error
+		if err == nil {
+			response.Respond(c, http.StatusOK, "Deletion already scheduled", existing)
+			return
+		}
+
+		deletion := models.AccountDeletion{
+			UserID:       user.ID,
+			Status:       models.DeletionStatusPending,
+			ScheduledFor: time.Now().Add(gracePeriod()),
+		}
+		if err := db.DB.Create(&deletion).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "deletion could not be scheduled", nil)
+			return
+		}
+
+		auditor.Record(c, audit.Entry{
+			Action:     ActionDeletionRequested,
+			TargetType: audit.TargetUser,
+			TargetID:   audit.ID(user.ID),
+			Metadata:   map[string]any{"scheduled_for": deletion.ScheduledFor},
+		})
+
+		response.Respond(c, http.StatusAccepted, "Deletion scheduled", deletion)
+	}
+}
+
+// DELETE /me/delete  cancel a pending deletion during the grace period
+func CancelDeletion(db *database.Service, auditor *audit.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uRaw, _ := c.Get("user")
+		user, _ := uRaw.(models.User)
+		if user.ID == 0 {
+			response.Respond(c, http.StatusUnauthorized, "unauthorized", nil)
+			return
+		}
+
+		res := db.DB.Model(&models.AccountDeletion{}).
+			Where("user_id = ? AND status = ?", user.ID, models.DeletionStatusPending).
+			Update("status", models.DeletionStatusCancelled)
+		if res.Error != nil {
+			response.Respond(c, http.StatusInternalServerError, "deletion could not be cancelled", nil)
+			return
+		}
+		if res.RowsAffected == 0 {
+			response.Respond(c, http.StatusNotFound, ErrNoPendingDeletion, nil)
+			return
+		}
+
+		auditor.Record(c, audit.Entry{
+			Action:     ActionDeletionCancelled,
+			TargetType: audit.TargetUser,
+			TargetID:   audit.ID(user.ID),
+		})
+
+		response.Respond(c, http.StatusOK, "Deletion cancelled", nil)
+	}
+}
+
+// StartPurger periodically erases accounts whose grace period has elapsed.
+// It returns when ctx is cancelled.
+func StartPurger(ctx context.Context, db *database.Service, store *bucket.Service, auditor *audit.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purgeDue(ctx, db, store, auditor)
+		}
+	}
+}
+
+func purgeDue(ctx context.Context, db *database.Service, store *bucket.Service, auditor *audit.Service) {
+	var due []models.AccountDeletion
+	if err := db.DB.
+		Where("status = ? AND scheduled_for <= ?", models.DeletionStatusPending, time.Now()).
+		Find(&due).Error; err != nil {
+		log.Printf("[account] load due deletions failed: %v", err)
+		return
+	}
+
+	for _, d := range due {
+		if err := purgeUser(ctx, db, store, d.UserID); err != nil {
+			log.Printf("[account] purge user=%d failed: %v", d.UserID, err)
+			continue
+		}
+
+		now := time.Now()
+		db.DB.Model(&d).Updates(map[string]any{
+			"status":       models.DeletionStatusCompleted,
+			"completed_at": now,
+		})
+		auditor.Log(audit.Entry{
+			Action:     ActionDeleted,
+			TargetType: audit.TargetUser,
+			TargetID:   audit.ID(d.UserID),
+			Metadata:   map[string]any{"deletion_id": d.ID},
+		})
+	}
+}
+
+// purgeUser removes every row of exportTables owned by the user, children
+// first, and the export archives stored in the bucket. The deletion record
+// stays as proof of the erasure, and the audit trail stays without the IP
+// addresses and user agents of the user.
+func purgeUser(ctx context.Context, db *database.Service, store *bucket.Service, userID int64) error {
+	var exports []models.AccountExport
+	if err := db.DB.Where("user_id = ? AND object_key <> ''", userID).Find(&exports).Error; err != nil {
+		return err
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		uid := sql.Named("uid", userID)
+		for i := len(exportTables) - 1; i >= 0; i-- {
+			t := exportTables[i]
+			if t.keep {
+				continue
+			}
+			if err := tx.Exec("DELETE FROM "+t.name+" WHERE "+t.where, uid).Error; err != nil {
+				return fmt.Errorf("purge %s: %w", t.name, err)
+			}
+		}
+		if err := tx.Exec(`UPDATE audit_events SET ip = NULL, user_agent = NULL
+			WHERE actor_id = @uid OR (target_type = @user AND target_id = @id)`,
+			uid, sql.Named("user", audit.TargetUser), sql.Named("id", audit.ID(userID))).Error; err != nil {
+			return fmt.Errorf("anonymize audit_events: %w", err)
+		}
+		return tx.Exec("DELETE FROM users WHERE id = ?", userID).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if store != nil {
+		for _, e := range exports {
+			if err := store.RemoveObject(ctx, e.ObjectKey); err != nil {
+				log.Printf("[account] remove export object %s failed: %v", e.ObjectKey, err)
+			}
+		}
+	}
+	return nil
+}
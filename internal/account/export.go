@@ -0,0 +1,220 @@
+package account
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"founders-toolkit-api/internal/audit"
+	"founders-toolkit-api/internal/bucket"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/models"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ActionExportRequested = "account.export_requested"
+
+	exportTimeout     = 5 * time.Minute
+	downloadURLExpiry = time.Hour
+)
+
+const (
+	ErrBucketUnavailable = "File storage is not configured"
+	ErrExportNotFound    = "Export not found"
+)
+
+// POST /me/export  queue a ZIP archive of everything we store about the user
+func RequestExport(db *database.Service, store *bucket.Service, auditor *audit.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uRaw, _ := c.Get("user")
+		user, _ := uRaw.(models.User)
+		if user.ID == 0 {
+			response.Respond(c, http.StatusUnauthorized, "unauthorized", nil)
+			return
+		}
+		if store == nil {
+			response.Respond(c, http.StatusServiceUnavailable, ErrBucketUnavailable, nil)
+			return
+		}
+
+		export := models.AccountExport{
+			UserID: user.ID,
+			Status: models.ExportStatusPending,
+		}
+		if err := db.DB.Create(&export).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "export could not be queued", nil)
+			return
+		}
+
+		auditor.Record(c, audit.Entry{
+			Action:     ActionExportRequested,
+			TargetType: audit.TargetUser,
+			TargetID:   audit.ID(user.ID),
+			Metadata:   map[string]any{"export_id": export.ID},
+		})
+
+		go runExport(db, store, export)
+
+		response.Respond(c, http.StatusAccepted, "Export queued", export)
+	}
+}
+
+// GET /me/export/:id  export status, with a fresh download link once completed
+func GetExport(db *database.Service, store *bucket.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uRaw, _ := c.Get("user")
+		user, _ := uRaw.(models.User)
+		if user.ID == 0 {
+			response.Respond(c, http.StatusUnauthorized, "unauthorized", nil)
+			return
+		}
+
+		var export models.AccountExport
+		if err := db.DB.
+			Where("id = ? AND user_id = ?", c.Param("id"), user.ID).
+			First(&export).Error; err != nil || export.ID == 0 {
+			response.Respond(c, http.StatusNotFound, ErrExportNotFound, nil)
+			return
+		}
+
+		data := gin.H{"export": export}
+		if export.Status == models.ExportStatusCompleted && store != nil {
+			link, err := store.PresignedGetURL(c.Request.Context(), export.ObjectKey, downloadURLExpiry)
+			if err != nil {
+				response.Respond(c, http.StatusInternalServerError, "download link could not be created", nil)
+				return
+			}
+			data["download_url"] = link
+			data["download_expires_in"] = int(downloadURLExpiry.Seconds())
+		}
+
+		response.Respond(c, http.StatusOK, "Export loaded", data)
+	}
+}
+
+func runExport(db *database.Service, store *bucket.Service, export models.AccountExport) {
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	db.DB.Model(&export).Update("status", models.ExportStatusRunning)
+
+	key := fmt.Sprintf("exports/%d/%d.zip", export.UserID, export.ID)
+	archive, err := buildArchive(db, export.UserID)
+	if err == nil {
+		err = store.PutObject(ctx, key, bytes.NewReader(archive), int64(len(archive)), "application/zip")
+	}
+
+	now := time.Now()
+	updates := map[string]any{"completed_at": now}
+	if err != nil {
+		log.Printf("[account] export id=%d user=%d failed: %v", export.ID, export.UserID, err)
+		updates["status"] = models.ExportStatusFailed
+		updates["error"] = err.Error()
+	} else {
+		updates["status"] = models.ExportStatusCompleted
+		updates["object_key"] = key
+	}
+
+	if err := db.DB.Model(&export).Updates(updates).Error; err != nil {
+		log.Printf("[account] export id=%d status update failed: %v", export.ID, err)
+	}
+}
+
+// exportTables are the tables holding the user's data, each with the
+// condition selecting the user's rows (@uid is the user id), parents before
+// children. Rows are exported as stored, so columns added later are
+// included; tables added later must be listed here. purgeUser deletes the
+// same rows in reverse order, except for the tables marked keep.
+var exportTables = []struct {
+	name  string
+	where string
+	omit  []string
+	keep  bool
+}{
+	{name: "sites", where: "user_id = @uid"},
+	{name: "site_markets", where: "site_id IN (SELECT id FROM sites WHERE user_id = @uid)"},
+	{name: "scans", where: "user_id = @uid"},
+	{name: "brand_analyses", where: "user_id = @uid"},
+	{name: "workflow_runs", where: "user_id = @uid"},
+	{name: "workflow_run_steps", where: "run_id IN (SELECT id FROM workflow_runs WHERE user_id = @uid)"},
+	{name: "llm_calls", where: "user_id = @uid"},
+	{name: "competitors", where: "user_id = @uid"},
+	{name: "competitor_appearances", where: "competitor_id IN (SELECT id FROM competitors WHERE user_id = @uid)"},
+	{name: "scoring_profiles", where: "user_id = @uid"},
+	{name: "query_sets", where: "user_id = @uid"},
+	{name: "query_set_items", where: "query_set_id IN (SELECT id FROM query_sets WHERE user_id = @uid)"},
+	{name: "alert_rules", where: "user_id = @uid"},
+	{name: "alert_events", where: "rule_id IN (SELECT id FROM alert_rules WHERE user_id = @uid)"},
+	{name: "webhook_endpoints", where: "user_id = @uid", omit: []string{"secret"}},
+	{name: "webhook_deliveries", where: "endpoint_id IN (SELECT id FROM webhook_endpoints WHERE user_id = @uid)"},
+	{name: "account_exports", where: "user_id = @uid"},
+	{name: "account_deletions", where: "user_id = @uid", keep: true},
+	{name: "audit_events", where: "actor_id = @uid", keep: true},
+}
+
+type archiveFile struct {
+	name string
+	data any
+}
+
+// buildArchive collects the user's profile and the rows of every table in
+// exportTables into an in-memory ZIP with one JSON document per table.
+func buildArchive(db *database.Service, userID int64) ([]byte, error) {
+	user, err := db.FindUserById(fmt.Sprintf("%d", userID))
+	if err != nil {
+		return nil, fmt.Errorf("load user: %w", err)
+	}
+	user.Password = ""
+
+	files := []archiveFile{{"profile.json", user}}
+	for _, t := range exportTables {
+		rows := []map[string]any{}
+		if err := db.DB.Table(t.name).Where(t.where, sql.Named("uid", userID)).
+			Order("id").Find(&rows).Error; err != nil {
+			return nil, fmt.Errorf("load %s: %w", t.name, err)
+		}
+		for _, row := range rows {
+			for _, col := range t.omit {
+				delete(row, col)
+			}
+			for col, v := range row {
+				// jsonb and bytea come back as bytes; keep JSON as JSON
+				if b, ok := v.([]byte); ok {
+					if json.Valid(b) {
+						row[col] = json.RawMessage(b)
+					} else {
+						row[col] = string(b)
+					}
+				}
+			}
+		}
+		files = append(files, archiveFile{t.name + ".json", rows})
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return nil, fmt.Errorf("write %s: %w", f.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,152 @@
+// Package alerttest provides local stand-ins for alert channels: an HTTP
+// receiver for webhook and Slack rules and an SMTP sink for email rules.
+package alerttest
+
+import (
+	"bufio"
+	"encoding/json"
+	"founders-toolkit-api/internal/alerts"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Receiver records every JSON body POSTed to it. Point webhook or Slack
+// rules at its URL; it listens on loopback, which the default notifiers
+// only reach with APP_ENV=development, so tests pass its Client instead.
+type Receiver struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	bodies []map[string]any
+}
+
+func NewReceiver() *Receiver {
+	r := &Receiver{}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.handle))
+	return r
+}
+
+// Bodies returns the decoded payloads received so far, in order.
+func (r *Receiver) Bodies() []map[string]any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]map[string]any(nil), r.bodies...)
+}
+
+func (r *Receiver) handle(w http.ResponseWriter, req *http.Request) {
+	var body map[string]any
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	r.mu.Lock()
+	r.bodies = append(r.bodies, body)
+	r.mu.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+type Mail struct {
+	From string
+	To   []string
+	Data string
+}
+
+// SMTPSink accepts mail on a local port and keeps it in memory. It speaks
+// just enough SMTP for net/smtp.SendMail without auth or TLS.
+type SMTPSink struct {
+	ln net.Listener
+
+	mu   sync.Mutex
+	mail []Mail
+}
+
+func NewSMTPSink() (*SMTPSink, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &SMTPSink{ln: ln}
+	go s.serve()
+	return s, nil
+}
+
+func (s *SMTPSink) Addr() string { return s.ln.Addr().String() }
+
+func (s *SMTPSink) Close() error { return s.ln.Close() }
+
+// Notifier returns an email notifier delivering to the sink.
+func (s *SMTPSink) Notifier() alerts.EmailNotifier {
+	return alerts.EmailNotifier{Addr: s.Addr(), From: "alerts@localhost"}
+}
+
+// Mail returns the messages received so far, in order.
+func (s *SMTPSink) Mail() []Mail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Mail(nil), s.mail...)
+}
+
+func (s *SMTPSink) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.session(conn)
+	}
+}
+
+func (s *SMTPSink) session(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 localhost ESMTP alerttest")
+	var cur Mail
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			cur = Mail{From: strings.Trim(line[len("MAIL FROM:"):], " <>")}
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			cur.To = append(cur.To, strings.Trim(line[len("RCPT TO:"):], " <>"))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if strings.TrimRight(l, "\r\n") == "." {
+					break
+				}
+				data.WriteString(l)
+			}
+			cur.Data = data.String()
+			s.mu.Lock()
+			s.mail = append(s.mail, cur)
+			s.mu.Unlock()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/models"
+	"log"
+	"time"
+)
+
+const deliveryTimeout = 15 * time.Second
+
+// Observation is a freshly saved scan or brand analysis.
+type Observation struct {
+	Source     string // models.AlertSourceScan or models.AlertSourceBrand
+	SourceID   int64
+	SiteID     int64
+	UserID     int64
+	SiteName   string
+	Visibility float64
+	// DirectPresent counts the direct queries the site appeared in. Only
+	// brand analyses carry it.
+	DirectPresent int
+	CreatedAt     time.Time
+}
+
+// previous is the run of the same source before the observed one.
+type previous struct {
+	ID            int64
+	Visibility    float64
+	DirectPresent int
+}
+
+// Evaluate checks the site's enabled rules against obs and delivers a
+// notification for every rule that fires. Errors are logged.
+func Evaluate(ctx context.Context, db *database.Service, obs Observation) {
+	var rules []models.AlertRule
+	if err := db.DB.Where("site_id = ? AND enabled AND source IN ?", obs.SiteID,
+		[]string{models.AlertSourceAny, obs.Source}).Find(&rules).Error; err != nil {
+		log.Printf("[alerts.Evaluate] load rules site_id=%d: %v", obs.SiteID, err)
+		return
+	}
+	if len(rules) == 0 {
+		return
+	}
+
+	prev, err := loadPrevious(db, obs)
+	if err != nil {
+		log.Printf("[alerts.Evaluate] load previous %s site_id=%d: %v", obs.Source, obs.SiteID, err)
+		return
+	}
+
+	for _, rule := range rules {
+		n, fired, err := check(db, rule, obs, prev)
+		if err != nil {
+			log.Printf("[alerts.Evaluate] rule_id=%d: %v", rule.ID, err)
+			continue
+		}
+		if !fired {
+			continue
+		}
+		n.Rule = rule
+		n.Site = obs.SiteName
+		n.Payload["source"] = obs.Source
+		n.Payload["source_id"] = obs.SourceID
+		deliver(ctx, db, n, obs)
+	}
+}
+
+func loadPrevious(db *database.Service, obs Observation) (*previous, error) {
+	var rows []previous
+	var err error
+	switch obs.Source {
+	case models.AlertSourceBrand:
+		err = db.DB.Raw(`
+			SELECT id,
+			       visibility_score AS visibility,
+			       (SELECT COUNT(*) FROM jsonb_array_elements(
+			            CASE WHEN jsonb_typeof(analysis->'direct'->'queries') = 'array'
+			                 THEN analysis->'direct'->'queries' ELSE '[]'::jsonb END) q
+			        WHERE (q->'target'->>'present')::boolean) AS direct_present
+			FROM brand_analyses
+			WHERE site_id = ? AND id <> ? AND created_at <= ? AND status = ?
+			ORDER BY created_at DESC, id DESC
+			LIMIT 1`, obs.SiteID, obs.SourceID, obs.CreatedAt, models.AnalysisStatusCompleted).Scan(&rows).Error
+	default:
+		err = db.DB.Raw(`
+			SELECT id, visibility_score AS visibility
+			FROM scans
+			WHERE site_id = ? AND id <> ? AND created_at <= ? AND completed AND NOT failed
+			ORDER BY created_at DESC, id DESC
+			LIMIT 1`, obs.SiteID, obs.SourceID, obs.CreatedAt).Scan(&rows).Error
+	}
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return &rows[0], nil
+}
+
+// check reports whether rule fires for obs. Threshold rules fire when the
+// score crosses below the floor, not on every run spent below it.
+func check(db *database.Service, rule models.AlertRule, obs Observation, prev *previous) (Notification, bool, error) {
+	n := Notification{Payload: map[string]any{"visibility": obs.Visibility}}
+	if prev != nil {
+		n.Payload["previous_visibility"] = prev.Visibility
+	}
+
+	switch rule.Kind {
+	case models.AlertKindThreshold:
+		if obs.Visibility >= rule.Threshold || (prev != nil && prev.Visibility < rule.Threshold) {
+			return n, false, nil
+		}
+		n.Subject = fmt.Sprintf("%s visibility fell below %.0f", obs.SiteName, rule.Threshold)
+		n.Message = fmt.Sprintf("Visibility is %.1f (threshold %.1f).", obs.Visibility, rule.Threshold)
+		return n, true, nil
+
+	case models.AlertKindPercentDrop:
+		if prev == nil || prev.Visibility <= 0 {
+			return n, false, nil
+		}
+		drop := (prev.Visibility - obs.Visibility) / prev.Visibility * 100
+		if drop < rule.Threshold {
+			return n, false, nil
+		}
+		n.Payload["drop_pct"] = drop
+		n.Subject = fmt.Sprintf("%s visibility dropped %.0f%%", obs.SiteName, drop)
+		n.Message = fmt.Sprintf("Visibility went from %.1f to %.1f.", prev.Visibility, obs.Visibility)
+		return n, true, nil
+
+	case models.AlertKindDirectDisappear:
+		if obs.Source != models.AlertSourceBrand || prev == nil ||
+			prev.DirectPresent == 0 || obs.DirectPresent > 0 {
+			return n, false, nil
+		}
+		n.Payload["previous_direct_present"] = prev.DirectPresent
+		n.Subject = fmt.Sprintf("%s disappeared from direct queries", obs.SiteName)
+		n.Message = fmt.Sprintf("The site was named in %d direct queries last time and in none now.", prev.DirectPresent)
+		return n, true, nil
+
+	case models.AlertKindCompetitorOvertake:
+		if obs.Source != models.AlertSourceBrand || prev == nil {
+			return n, false, nil
+		}
+		names, err := overtakers(db, rule, obs.SourceID, prev.ID)
+		if err != nil || len(names) == 0 {
+			return n, false, err
+		}
+		n.Payload["competitors"] = names
+		n.Subject = fmt.Sprintf("A competitor overtook %s", obs.SiteName)
+		n.Message = fmt.Sprintf("Now ranked ahead of the site: %v.", names)
+		return n, true, nil
+	}
+
+	return n, false, fmt.Errorf("unknown alert kind %q", rule.Kind)
+}
+
+// overtakers lists the competitors that ranked ahead of the site in the
+// current analysis but not in the previous one. Without a competitor on the
+// rule every confirmed competitor is watched.
+func overtakers(db *database.Service, rule models.AlertRule, currentID, previousID int64) ([]string, error) {
+	q := db.DB.Table("competitor_appearances a").
+		Select("DISTINCT c.name").
+		Joins("JOIN competitors c ON c.id = a.competitor_id").
+		Where("a.brand_analysis_id = ? AND a.won", currentID).
+		Where(`NOT EXISTS (SELECT 1 FROM competitor_appearances p
+			WHERE p.competitor_id = a.competitor_id AND p.brand_analysis_id = ? AND p.won)`, previousID)
+	if rule.CompetitorID != nil {
+		q = q.Where("c.id = ?", *rule.CompetitorID)
+	} else {
+		q = q.Where("c.status = ?", models.CompetitorStatusConfirmed)
+	}
+
+	var names []string
+	err := q.Pluck("c.name", &names).Error
+	return names, err
+}
+
+func deliver(ctx context.Context, db *database.Service, n Notification, obs Observation) {
+	payload, _ := json.Marshal(n.Payload)
+	event := models.AlertEvent{
+		RuleID:   n.Rule.ID,
+		SiteID:   obs.SiteID,
+		Source:   obs.Source,
+		SourceID: obs.SourceID,
+		Message:  n.Subject + ": " + n.Message,
+		Payload:  models.JSONB(payload),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+	if err := Send(ctx, n.Rule, n); err != nil {
+		event.Error = err.Error()
+		log.Printf("[alerts.deliver] rule_id=%d channel=%s: %v", n.Rule.ID, n.Rule.Channel, err)
+	} else {
+		event.Delivered = true
+	}
+
+	if err := db.DB.Create(&event).Error; err != nil {
+		log.Printf("[alerts.deliver] save event rule_id=%d: %v", n.Rule.ID, err)
+	}
+	db.DB.Model(&models.AlertRule{}).Where("id = ?", n.Rule.ID).Update("last_triggered_at", event.CreatedAt)
+}
+
+// Send delivers n through the rule's channel.
+func Send(ctx context.Context, rule models.AlertRule, n Notification) error {
+	notifier, err := notifierFor(rule.Channel)
+	if err != nil {
+		return err
+	}
+	return notifier.Notify(ctx, rule.Target, n)
+}
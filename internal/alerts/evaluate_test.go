@@ -0,0 +1,63 @@
+package alerts
+
+import (
+	"founders-toolkit-api/models"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	threshold := models.AlertRule{Kind: models.AlertKindThreshold, Threshold: 40}
+	drop := models.AlertRule{Kind: models.AlertKindPercentDrop, Threshold: 25}
+	disappear := models.AlertRule{Kind: models.AlertKindDirectDisappear}
+	brand := func(vis float64, direct int) Observation {
+		return Observation{Source: models.AlertSourceBrand, SiteName: "Acme", Visibility: vis, DirectPresent: direct}
+	}
+	scan := func(vis float64) Observation {
+		return Observation{Source: models.AlertSourceScan, SiteName: "Acme", Visibility: vis}
+	}
+
+	tests := []struct {
+		name    string
+		rule    models.AlertRule
+		obs     Observation
+		prev    *previous
+		fired   bool
+		subject string
+	}{
+		{name: "threshold crossed", rule: threshold, obs: scan(35), prev: &previous{Visibility: 50}, fired: true, subject: "Acme visibility fell below 40"},
+		{name: "threshold first run below", rule: threshold, obs: scan(35), fired: true, subject: "Acme visibility fell below 40"},
+		{name: "threshold still below", rule: threshold, obs: scan(30), prev: &previous{Visibility: 35}},
+		{name: "threshold above", rule: threshold, obs: scan(40), prev: &previous{Visibility: 50}},
+		{name: "drop large enough", rule: drop, obs: scan(60), prev: &previous{Visibility: 80}, fired: true, subject: "Acme visibility dropped 25%"},
+		{name: "drop too small", rule: drop, obs: scan(61), prev: &previous{Visibility: 80}},
+		{name: "drop without previous", rule: drop, obs: scan(10)},
+		{name: "drop from zero", rule: drop, obs: scan(0), prev: &previous{Visibility: 0}},
+		{name: "direct disappeared", rule: disappear, obs: brand(20, 0), prev: &previous{DirectPresent: 3}, fired: true, subject: "Acme disappeared from direct queries"},
+		{name: "direct still present", rule: disappear, obs: brand(20, 1), prev: &previous{DirectPresent: 3}},
+		{name: "direct never present", rule: disappear, obs: brand(20, 0), prev: &previous{DirectPresent: 0}},
+		{name: "direct on a scan", rule: disappear, obs: scan(20), prev: &previous{DirectPresent: 3}},
+		{name: "overtake without previous", rule: models.AlertRule{Kind: models.AlertKindCompetitorOvertake}, obs: brand(20, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, fired, err := check(nil, tt.rule, tt.obs, tt.prev)
+			if err != nil {
+				t.Fatalf("check: %v", err)
+			}
+			if fired != tt.fired {
+				t.Fatalf("fired = %v, want %v", fired, tt.fired)
+			}
+			if n.Subject != tt.subject {
+				t.Errorf("subject = %q, want %q", n.Subject, tt.subject)
+			}
+			if n.Payload["visibility"] != tt.obs.Visibility {
+				t.Errorf("payload visibility = %v, want %v", n.Payload["visibility"], tt.obs.Visibility)
+			}
+		})
+	}
+
+	if _, _, err := check(nil, models.AlertRule{Kind: "nope"}, scan(10), nil); err == nil {
+		t.Error("check of an unknown kind should fail")
+	}
+}
@@ -0,0 +1,268 @@
+package alerts
+
+import (
+	"context"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/egress"
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/internal/sites"
+	"founders-toolkit-api/models"
+	"net/http"
+	"net/mail"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	validKinds = map[string]bool{
+		models.AlertKindThreshold:          true,
+		models.AlertKindPercentDrop:        true,
+		models.AlertKindCompetitorOvertake: true,
+		models.AlertKindDirectDisappear:    true,
+	}
+	validSources = map[string]bool{
+		models.AlertSourceAny:   true,
+		models.AlertSourceScan:  true,
+		models.AlertSourceBrand: true,
+	}
+)
+
+type ruleRequest struct {
+	Kind         string  `json:"kind" binding:"required"`
+	Source       string  `json:"source"`
+	Threshold    float64 `json:"threshold"`
+	CompetitorID *int64  `json:"competitor_id"`
+	Channel      string  `json:"channel" binding:"required"`
+	Target       string  `json:"target" binding:"required"`
+	Enabled      *bool   `json:"enabled"`
+}
+
+// GET /sites/:id/alerts
+func ListRules(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+
+		var rules []models.AlertRule
+		if err := db.DB.Where("site_id = ? AND user_id = ?", site.ID, user.ID).
+			Order("id").Find(&rules).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load alert rules", nil)
+			return
+		}
+		response.Respond(c, http.StatusOK, "Alert rules loaded", rules)
+	}
+}
+
+// POST /sites/:id/alerts
+func CreateRule(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+
+		var body ruleRequest
+		if err := c.ShouldBindJSON(&body); err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+
+		rule := models.AlertRule{UserID: user.ID, SiteID: site.ID, Enabled: true}
+		if !applyRule(c, db, &rule, body) {
+			return
+		}
+		if err := db.DB.Create(&rule).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "alert rule save failed", nil)
+			return
+		}
+		response.Respond(c, http.StatusCreated, "Alert rule created", rule)
+	}
+}
+
+// PUT /sites/:id/alerts/:rid
+func UpdateRule(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+		rule, ok := ownedRule(c, db, site.ID)
+		if !ok {
+			return
+		}
+
+		var body ruleRequest
+		if err := c.ShouldBindJSON(&body); err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		if !applyRule(c, db, &rule, body) {
+			return
+		}
+		if err := db.DB.Save(&rule).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "alert rule save failed", nil)
+			return
+		}
+		response.Respond(c, http.StatusOK, "Alert rule updated", rule)
+	}
+}
+
+// DELETE /sites/:id/alerts/:rid
+func DeleteRule(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+		rule, ok := ownedRule(c, db, site.ID)
+		if !ok {
+			return
+		}
+
+		if err := db.DB.Delete(&rule).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "alert rule delete failed", nil)
+			return
+		}
+		response.Respond(c, http.StatusOK, "Alert rule deleted", nil)
+	}
+}
+
+// POST /sites/:id/alerts/:rid/test  sends a sample notification through the
+// rule's channel without recording an event
+func TestRule(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+		rule, ok := ownedRule(c, db, site.ID)
+		if !ok {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), deliveryTimeout)
+		defer cancel()
+		err := Send(ctx, rule, Notification{
+			Rule:    rule,
+			Site:    site.Name,
+			Subject: "Test alert for " + site.Name,
+			Message: "This is a test notification for a " + rule.Kind + " alert rule.",
+			Payload: map[string]any{"test": true},
+		})
+		if err != nil {
+			response.Respond(c, http.StatusBadGateway, "test notification failed: "+err.Error(), nil)
+			return
+		}
+		response.Respond(c, http.StatusOK, "Test notification sent", nil)
+	}
+}
+
+// GET /sites/:id/alert-events?limit=
+func ListEvents(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if limit <= 0 || limit > 200 {
+			limit = 50
+		}
+
+		var events []models.AlertEvent
+		if err := db.DB.Where("site_id = ?", site.ID).
+			Order("created_at DESC").Limit(limit).Find(&events).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load alert events", nil)
+			return
+		}
+		response.Respond(c, http.StatusOK, "Alert events loaded", events)
+	}
+}
+
+// applyRule validates body onto rule, responding 400 on failure.
+func applyRule(c *gin.Context, db *database.Service, rule *models.AlertRule, body ruleRequest) bool {
+	if !validKinds[body.Kind] {
+		response.Respond(c, http.StatusBadRequest, "kind must be one of threshold, pct_drop, competitor_overtake, direct_disappear", nil)
+		return false
+	}
+	if body.Source == "" {
+		body.Source = models.AlertSourceAny
+	}
+	if !validSources[body.Source] {
+		response.Respond(c, http.StatusBadRequest, "source must be one of any, scan, brand", nil)
+		return false
+	}
+
+	switch body.Kind {
+	case models.AlertKindThreshold:
+		if body.Threshold <= 0 || body.Threshold > 100 {
+			response.Respond(c, http.StatusBadRequest, "threshold must be within (0, 100]", nil)
+			return false
+		}
+	case models.AlertKindPercentDrop:
+		if body.Threshold <= 0 || body.Threshold > 100 {
+			response.Respond(c, http.StatusBadRequest, "threshold must be a percentage within (0, 100]", nil)
+			return false
+		}
+	case models.AlertKindCompetitorOvertake, models.AlertKindDirectDisappear:
+		// these compare brand analyses only
+		body.Source = models.AlertSourceBrand
+	}
+
+	if body.CompetitorID != nil {
+		if body.Kind != models.AlertKindCompetitorOvertake {
+			response.Respond(c, http.StatusBadRequest, "competitor_id only applies to competitor_overtake rules", nil)
+			return false
+		}
+		var count int64
+		db.DB.Model(&models.Competitor{}).
+			Where("id = ? AND site_id = ?", *body.CompetitorID, rule.SiteID).Count(&count)
+		if count == 0 {
+			response.Respond(c, http.StatusBadRequest, "competitor not found", nil)
+			return false
+		}
+	}
+
+	switch body.Channel {
+	case models.AlertChannelEmail:
+		addr, err := mail.ParseAddress(body.Target)
+		if err != nil {
+			response.Respond(c, http.StatusBadRequest, "target must be an email address", nil)
+			return false
+		}
+		body.Target = addr.Address
+	case models.AlertChannelWebhook, models.AlertChannelSlack:
+		if err := egress.CheckURL(c.Request.Context(), body.Target); err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return false
+		}
+	default:
+		response.Respond(c, http.StatusBadRequest, "channel must be one of email, webhook, slack", nil)
+		return false
+	}
+
+	rule.Kind = body.Kind
+	rule.Source = body.Source
+	rule.Threshold = body.Threshold
+	rule.CompetitorID = body.CompetitorID
+	rule.Channel = body.Channel
+	rule.Target = body.Target
+	if body.Enabled != nil {
+		rule.Enabled = *body.Enabled
+	}
+	return true
+}
+
+func ownedRule(c *gin.Context, db *database.Service, siteID int64) (models.AlertRule, bool) {
+	var rule models.AlertRule
+	if err := db.DB.Where("id = ? AND site_id = ?", c.Param("rid"), siteID).
+		First(&rule).Error; err != nil || rule.ID == 0 {
+		response.Respond(c, http.StatusNotFound, "alert rule not found", nil)
+		return rule, false
+	}
+	return rule, true
+}
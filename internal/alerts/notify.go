@@ -0,0 +1,176 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"founders-toolkit-api/internal/egress"
+	"founders-toolkit-api/models"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Notification is one triggered alert ready to be delivered.
+type Notification struct {
+	Rule    models.AlertRule `json:"rule"`
+	Site    string           `json:"site"`
+	Subject string           `json:"subject"`
+	Message string           `json:"message"`
+	Payload map[string]any   `json:"payload"`
+}
+
+// Notifier delivers notifications for one channel. target is the rule's
+// Target (an email address or a URL).
+type Notifier interface {
+	Notify(ctx context.Context, target string, n Notification) error
+}
+
+var (
+	notifiersMu sync.RWMutex
+	notifiers   = map[string]Notifier{
+		models.AlertChannelWebhook: WebhookNotifier{Client: egress.NewClient(10 * time.Second)},
+		models.AlertChannelSlack:   SlackNotifier{Client: egress.NewClient(10 * time.Second)},
+		models.AlertChannelEmail:   EmailFromEnv(),
+	}
+)
+
+// Register replaces the notifier of a channel, e.g. with a local stand-in.
+func Register(channel string, n Notifier) {
+	notifiersMu.Lock()
+	defer notifiersMu.Unlock()
+	notifiers[channel] = n
+}
+
+func notifierFor(channel string) (Notifier, error) {
+	notifiersMu.RLock()
+	defer notifiersMu.RUnlock()
+	n, ok := notifiers[channel]
+	if !ok || n == nil {
+		return nil, fmt.Errorf("no notifier for channel %q", channel)
+	}
+	return n, nil
+}
+
+// WebhookNotifier POSTs the notification as JSON.
+type WebhookNotifier struct {
+	Client *http.Client
+}
+
+func (w WebhookNotifier) Notify(ctx context.Context, target string, n Notification) error {
+	return postJSON(ctx, w.Client, target, map[string]any{
+		"event":   "alert.triggered",
+		"kind":    n.Rule.Kind,
+		"rule_id": n.Rule.ID,
+		"site_id": n.Rule.SiteID,
+		"site":    n.Site,
+		"subject": n.Subject,
+		"message": n.Message,
+		"payload": n.Payload,
+	})
+}
+
+// SlackNotifier posts in the Slack incoming-webhook format.
+type SlackNotifier struct {
+	Client *http.Client
+}
+
+func (s SlackNotifier) Notify(ctx context.Context, target string, n Notification) error {
+	return postJSON(ctx, s.Client, target, map[string]any{
+		"text": fmt.Sprintf("*%s*\n%s", n.Subject, n.Message),
+	})
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, body any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+// EmailNotifier sends plain-text mail through an SMTP relay. Auth is skipped
+// when Username is empty, which suits local catchers like MailHog.
+type EmailNotifier struct {
+	Addr     string // host:port
+	Username string
+	Password string
+	From     string
+}
+
+// EmailFromEnv reads SMTP_ADDR, SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM.
+func EmailFromEnv() EmailNotifier {
+	return EmailNotifier{
+		Addr:     os.Getenv("SMTP_ADDR"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+}
+
+func (e EmailNotifier) Notify(ctx context.Context, target string, n Notification) error {
+	if e.Addr == "" || e.From == "" {
+		return errors.New("email notifier is not configured (SMTP_ADDR, SMTP_FROM)")
+	}
+
+	var auth smtp.Auth
+	if e.Username != "" {
+		host, _, err := net.SplitHostPort(e.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", e.Username, e.Password, host)
+	}
+
+	to, err := mail.ParseAddress(target)
+	if err != nil {
+		return fmt.Errorf("invalid email target: %w", err)
+	}
+
+	msg := strings.Join([]string{
+		"From: " + e.From,
+		"To: " + to.Address,
+		"Subject: " + encodeHeader(n.Subject),
+		"Content-Type: text/plain; charset=utf-8",
+		"",
+		n.Message,
+	}, "\r\n")
+
+	done := make(chan error, 1)
+	go func() { done <- smtp.SendMail(e.Addr, auth, e.From, []string{to.Address}, []byte(msg)) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// encodeHeader folds line breaks, which would start new headers, and
+// encodes non-ASCII text as an RFC 2047 word.
+func encodeHeader(v string) string {
+	v = strings.Join(strings.FieldsFunc(v, func(r rune) bool { return r == '\r' || r == '\n' }), " ")
+	return mime.QEncoding.Encode("utf-8", v)
+}
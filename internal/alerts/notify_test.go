@@ -0,0 +1,110 @@
+package alerts_test
+
+import (
+	"context"
+	"errors"
+	"founders-toolkit-api/internal/alerts"
+	"founders-toolkit-api/internal/alerts/alerttest"
+	"founders-toolkit-api/internal/egress"
+	"founders-toolkit-api/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func notification() alerts.Notification {
+	return alerts.Notification{
+		Rule:    models.AlertRule{ID: 7, SiteID: 3, Kind: models.AlertKindThreshold},
+		Site:    "Acme",
+		Subject: "Acme visibility fell below 40",
+		Message: "Visibility is 35.0 (threshold 40.0).",
+		Payload: map[string]any{"visibility": 35.0},
+	}
+}
+
+func TestWebhookAndSlackNotifiers(t *testing.T) {
+	recv := alerttest.NewReceiver()
+	defer recv.Close()
+	ctx := context.Background()
+
+	if err := (alerts.WebhookNotifier{Client: recv.Client()}).Notify(ctx, recv.URL, notification()); err != nil {
+		t.Fatalf("webhook Notify: %v", err)
+	}
+	if err := (alerts.SlackNotifier{Client: recv.Client()}).Notify(ctx, recv.URL, notification()); err != nil {
+		t.Fatalf("slack Notify: %v", err)
+	}
+
+	bodies := recv.Bodies()
+	if len(bodies) != 2 {
+		t.Fatalf("received %d bodies, want 2", len(bodies))
+	}
+	hook := bodies[0]
+	if hook["event"] != "alert.triggered" || hook["rule_id"] != 7.0 || hook["site"] != "Acme" ||
+		hook["subject"] != "Acme visibility fell below 40" {
+		t.Errorf("webhook body = %v", hook)
+	}
+	if want := "*Acme visibility fell below 40*\nVisibility is 35.0 (threshold 40.0)."; bodies[1]["text"] != want {
+		t.Errorf("slack text = %q, want %q", bodies[1]["text"], want)
+	}
+}
+
+func TestWebhookNotifierRefusesPrivateTargets(t *testing.T) {
+	recv := alerttest.NewReceiver()
+	defer recv.Close()
+
+	n := alerts.WebhookNotifier{Client: egress.NewClient(time.Second)}
+	err := n.Notify(context.Background(), recv.URL, notification())
+	if !errors.Is(err, egress.ErrForbiddenHost) {
+		t.Fatalf("Notify to %s = %v, want ErrForbiddenHost", recv.URL, err)
+	}
+	if len(recv.Bodies()) != 0 {
+		t.Error("the receiver should not have been reached")
+	}
+}
+
+func TestEmailNotifier(t *testing.T) {
+	sink, err := alerttest.NewSMTPSink()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	alerts.Register(models.AlertChannelEmail, sink.Notifier())
+
+	n := notification()
+	n.Subject = "Acme fell\r\nBcc: victim@example.com\r\n\r\nspoofed body"
+	rule := models.AlertRule{Channel: models.AlertChannelEmail, Target: "Ops Team <ops@example.com>"}
+	if err := alerts.Send(context.Background(), rule, n); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	mail := sink.Mail()
+	if len(mail) != 1 {
+		t.Fatalf("received %d mails, want 1", len(mail))
+	}
+	if got := mail[0].To; len(got) != 1 || got[0] != "ops@example.com" {
+		t.Errorf("recipients = %q, want the bare address", got)
+	}
+	headers, body, _ := strings.Cut(mail[0].Data, "\r\n\r\n")
+	for _, line := range strings.Split(headers, "\r\n") {
+		if strings.HasPrefix(line, "Bcc:") {
+			t.Errorf("subject injected a header: %q", headers)
+		}
+	}
+	if !strings.Contains(headers, "Subject: Acme fell Bcc: victim@example.com spoofed body") {
+		t.Errorf("headers = %q, want the subject on one line", headers)
+	}
+	if !strings.Contains(headers, "To: ops@example.com") {
+		t.Errorf("headers = %q, want the bare address in To", headers)
+	}
+	if strings.TrimSpace(body) != n.Message {
+		t.Errorf("body = %q, want %q", body, n.Message)
+	}
+
+	n.Subject = "Grüße: visibility fell"
+	if err := alerts.Send(context.Background(), rule, n); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if mail := sink.Mail(); len(mail) != 2 || !strings.Contains(mail[1].Data, "Subject: =?utf-8?q?") {
+		t.Errorf("non-ASCII subject should be encoded: %q", mail[len(mail)-1].Data)
+	}
+}
@@ -0,0 +1,225 @@
+package analytics
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/models"
+	"time"
+)
+
+var validBuckets = map[string]bool{"day": true, "week": true, "month": true}
+
+// Range is the time window and bucket size of a time series query.
+type Range struct {
+	Bucket string
+	From   time.Time
+	To     time.Time
+}
+
+func ParseRange(bucket, from, to string) (Range, error) {
+	r := Range{Bucket: bucket, To: time.Now()}
+	if r.Bucket == "" {
+		r.Bucket = "day"
+	}
+	if !validBuckets[r.Bucket] {
+		return r, errors.New("bucket must be one of day, week, month")
+	}
+
+	if to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return r, errors.New("to must be an RFC3339 timestamp")
+		}
+		r.To = t
+	}
+	r.From = r.To.AddDate(0, 0, -90)
+	if from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return r, errors.New("from must be an RFC3339 timestamp")
+		}
+		r.From = t
+	}
+	if !r.From.Before(r.To) {
+		return r, errors.New("from must be before to")
+	}
+	return r, nil
+}
+
+type BrandPoint struct {
+	BucketStart   time.Time `json:"bucket_start"`
+	Runs          int       `json:"runs"`
+	Visibility    float64   `json:"visibility"`
+	Direct        float64   `json:"direct"`
+	Intermediate  float64   `json:"intermediate"`
+	Indirect      float64   `json:"indirect"`
+	ShareOfVoice  float64   `json:"share_of_voice"`
+	CitingDomains int       `json:"citing_domains"`
+}
+
+type ScanPoint struct {
+	BucketStart   time.Time `json:"bucket_start"`
+	Runs          int       `json:"runs"`
+	Visibility    float64   `json:"visibility"`
+	Direct        float64   `json:"direct"`
+	Intermediate  float64   `json:"intermediate"`
+	Indirect      float64   `json:"indirect"`
+	CitingDomains int       `json:"citing_domains"`
+}
+
+// jsonArray guards jsonb_array_elements against missing keys and JSON null.
+const jsonArray = `CASE WHEN jsonb_typeof(%[1]s) = 'array' THEN %[1]s ELSE '[]'::jsonb END`
+
+// hostOf strips scheme, "www." and path from a citation (URL or bare domain).
+// It avoids "?" since gorm treats every one as a placeholder.
+const hostOf = `lower(substring(regexp_replace(%s, '^[a-zA-Z]+://(www\.){0,1}|^www\.', '') from '^[^/:#]+'))`
+
+// BrandSeries buckets the brand analyses of the given sites.
+func BrandSeries(db *database.Service, userID int64, siteIDs []int64, r Range) ([]BrandPoint, error) {
+	var points []BrandPoint
+	err := db.DB.Raw(`
+		SELECT date_trunc(?, created_at)  AS bucket_start,
+		       COUNT(*)                   AS runs,
+		       AVG(visibility_score)      AS visibility,
+		       AVG(direct_score)          AS direct,
+		       AVG(intermediate_score)    AS intermediate,
+		       AVG(indirect_score)        AS indirect,
+		       AVG(share_of_voice)        AS share_of_voice
+		FROM brand_analyses
+		WHERE user_id = ? AND site_id IN ? AND status = ? AND created_at >= ? AND created_at < ?
+		GROUP BY 1
+		ORDER BY 1`, r.Bucket, userID, siteIDs, models.AnalysisStatusCompleted, r.From, r.To).Scan(&points).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var domains []struct {
+		BucketStart   time.Time
+		CitingDomains int
+	}
+	err = db.DB.Raw(`
+		SELECT date_trunc(?, b.created_at) AS bucket_start,
+		       COUNT(DISTINCT `+fmt.Sprintf(hostOf, "cite")+`) AS citing_domains
+		FROM brand_analyses b
+		CROSS JOIN LATERAL jsonb_array_elements(
+		    `+fmt.Sprintf(jsonArray, "b.analysis->'direct'->'queries'")+` ||
+		    `+fmt.Sprintf(jsonArray, "b.analysis->'intermediate'->'queries'")+` ||
+		    `+fmt.Sprintf(jsonArray, "b.analysis->'indirect'->'queries'")+`) AS q
+		CROSS JOIN LATERAL jsonb_array_elements(`+fmt.Sprintf(jsonArray, "q->'brands'")+`) AS br
+		CROSS JOIN LATERAL jsonb_array_elements_text(`+fmt.Sprintf(jsonArray, "br->'citations'")+`) AS cite
+		WHERE b.user_id = ? AND b.site_id IN ? AND b.status = ? AND b.created_at >= ? AND b.created_at < ?
+		GROUP BY 1`, r.Bucket, userID, siteIDs, models.AnalysisStatusCompleted, r.From, r.To).Scan(&domains).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byBucket := make(map[int64]int, len(domains))
+	for _, d := range domains {
+		byBucket[d.BucketStart.Unix()] = d.CitingDomains
+	}
+	for i := range points {
+		points[i].CitingDomains = byBucket[points[i].BucketStart.Unix()]
+	}
+	return points, nil
+}
+
+// ScanSeries buckets the SEO scans of the given sites.
+func ScanSeries(db *database.Service, userID int64, siteIDs []int64, r Range) ([]ScanPoint, error) {
+	var points []ScanPoint
+	err := db.DB.Raw(`
+		SELECT date_trunc(?, created_at) AS bucket_start,
+		       COUNT(*)                  AS runs,
+		       AVG(visibility_score)     AS visibility,
+		       AVG(score1)               AS direct,
+		       AVG(score2)               AS intermediate,
+		       AVG(score3)               AS indirect
+		FROM scans
+		WHERE user_id = ? AND site_id IN ? AND completed AND NOT failed
+		  AND created_at >= ? AND created_at < ?
+		GROUP BY 1
+		ORDER BY 1`, r.Bucket, userID, siteIDs, r.From, r.To).Scan(&points).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var domains []struct {
+		BucketStart   time.Time
+		CitingDomains int
+	}
+	err = db.DB.Raw(`
+		SELECT date_trunc(?, s.created_at) AS bucket_start,
+		       COUNT(DISTINCT `+fmt.Sprintf(hostOf, "cite")+`) AS citing_domains
+		FROM scans s
+		CROSS JOIN LATERAL jsonb_array_elements_text(`+fmt.Sprintf(jsonArray, "s.citations::jsonb")+`) AS cite
+		WHERE s.user_id = ? AND s.site_id IN ? AND s.completed AND NOT s.failed
+		  AND s.created_at >= ? AND s.created_at < ?
+		GROUP BY 1`, r.Bucket, userID, siteIDs, r.From, r.To).Scan(&domains).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byBucket := make(map[int64]int, len(domains))
+	for _, d := range domains {
+		byBucket[d.BucketStart.Unix()] = d.CitingDomains
+	}
+	for i := range points {
+		points[i].CitingDomains = byBucket[points[i].BucketStart.Unix()]
+	}
+	return points, nil
+}
+
+type SiteSummary struct {
+	SiteID               int64      `json:"site_id"`
+	Name                 string     `json:"name"`
+	URL                  string     `json:"url"`
+	LatestVisibility     *float64   `json:"latest_visibility"`
+	PreviousVisibility   *float64   `json:"previous_visibility"`
+	VisibilityChange     *float64   `json:"visibility_change"`
+	LatestShareOfVoice   *float64   `json:"latest_share_of_voice"`
+	LatestAnalysisAt     *time.Time `json:"latest_analysis_at"`
+	LatestScanVisibility *float64   `json:"latest_scan_visibility"`
+	LatestScanAt         *time.Time `json:"latest_scan_at"`
+}
+
+// Portfolio returns the latest standing of every site of the user.
+func Portfolio(db *database.Service, userID int64) ([]SiteSummary, error) {
+	var rows []SiteSummary
+	err := db.DB.Raw(`
+		SELECT s.id                 AS site_id,
+		       s.name,
+		       s.url,
+		       la.visibility_score  AS latest_visibility,
+		       pa.visibility_score  AS previous_visibility,
+		       la.share_of_voice    AS latest_share_of_voice,
+		       la.created_at        AS latest_analysis_at,
+		       ls.visibility_score  AS latest_scan_visibility,
+		       ls.created_at        AS latest_scan_at
+		FROM sites s
+		LEFT JOIN LATERAL (
+		    SELECT visibility_score, share_of_voice, created_at FROM brand_analyses
+		    WHERE site_id = s.id AND status = @completed ORDER BY created_at DESC LIMIT 1
+		) la ON TRUE
+		LEFT JOIN LATERAL (
+		    SELECT visibility_score FROM brand_analyses
+		    WHERE site_id = s.id AND status = @completed ORDER BY created_at DESC OFFSET 1 LIMIT 1
+		) pa ON TRUE
+		LEFT JOIN LATERAL (
+		    SELECT visibility_score, created_at FROM scans
+		    WHERE site_id = s.id AND completed AND NOT failed ORDER BY created_at DESC LIMIT 1
+		) ls ON TRUE
+		WHERE s.user_id = @user
+		ORDER BY s.id`, sql.Named("user", userID), sql.Named("completed", models.AnalysisStatusCompleted)).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rows {
+		if rows[i].LatestVisibility != nil && rows[i].PreviousVisibility != nil {
+			change := *rows[i].LatestVisibility - *rows[i].PreviousVisibility
+			rows[i].VisibilityChange = &change
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,121 @@
+package analytics
+
+import (
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/internal/sites"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /sites/:id/analytics?bucket=day|week|month&from=&to=
+// from/to are RFC3339; the window defaults to the last 90 days.
+func SiteAnalytics(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+
+		r, err := ParseRange(c.Query("bucket"), c.Query("from"), c.Query("to"))
+		if err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+
+		brand, err := BrandSeries(db, user.ID, []int64{site.ID}, r)
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to aggregate brand analyses", nil)
+			return
+		}
+		scans, err := ScanSeries(db, user.ID, []int64{site.ID}, r)
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to aggregate scans", nil)
+			return
+		}
+
+		response.Respond(c, http.StatusOK, "Analytics loaded", gin.H{
+			"site_id":        site.ID,
+			"bucket":         r.Bucket,
+			"from":           r.From,
+			"to":             r.To,
+			"brand_analyses": nonNil(brand),
+			"scans":          nonNil(scans),
+		})
+	}
+}
+
+// GET /analytics/portfolio?bucket=&from=&to=  latest standing of every site
+// plus brand and scan series aggregated over all of them
+func PortfolioAnalytics(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := sites.CurrentUser(c)
+		if !ok {
+			return
+		}
+
+		r, err := ParseRange(c.Query("bucket"), c.Query("from"), c.Query("to"))
+		if err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+
+		sites, err := Portfolio(db, user.ID)
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load portfolio", nil)
+			return
+		}
+
+		siteIDs := make([]int64, 0, len(sites))
+		var visSum, sovSum float64
+		var measured int
+		for _, s := range sites {
+			siteIDs = append(siteIDs, s.SiteID)
+			if s.LatestVisibility != nil {
+				visSum += *s.LatestVisibility
+				measured++
+			}
+			if s.LatestShareOfVoice != nil {
+				sovSum += *s.LatestShareOfVoice
+			}
+		}
+
+		var brand []BrandPoint
+		var scans []ScanPoint
+		if len(siteIDs) > 0 {
+			if brand, err = BrandSeries(db, user.ID, siteIDs, r); err != nil {
+				response.Respond(c, http.StatusInternalServerError, "failed to aggregate brand analyses", nil)
+				return
+			}
+			if scans, err = ScanSeries(db, user.ID, siteIDs, r); err != nil {
+				response.Respond(c, http.StatusInternalServerError, "failed to aggregate scans", nil)
+				return
+			}
+		}
+
+		summary := gin.H{"sites": len(sites), "sites_measured": measured}
+		if measured > 0 {
+			summary["avg_visibility"] = visSum / float64(measured)
+			summary["avg_share_of_voice"] = sovSum / float64(measured)
+		}
+
+		response.Respond(c, http.StatusOK, "Portfolio loaded", gin.H{
+			"summary":        summary,
+			"sites":          nonNil(sites),
+			"bucket":         r.Bucket,
+			"from":           r.From,
+			"to":             r.To,
+			"brand_analyses": nonNil(brand),
+			"scans":          nonNil(scans),
+		})
+	}
+}
+
+// nonNil makes empty series serialise as [] rather than null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"encoding/json"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/models"
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ActionSignUp         = "user.signup"
+	ActionLogin          = "user.login"
+	ActionLoginFailed    = "user.login_failed"
+	ActionPasswordChange = "user.password_change"
+	ActionScanCreate     = "scan.create"
+	ActionScanFailed     = "scan.failed"
+	ActionScanCancelled  = "scan.cancelled"
+	ActionBrandAnalysis  = "brand_analysis.create"
+	ActionEngineCompare  = "engine_comparison.create"
+
+	ActionImpersonationStart  = "admin.impersonation_start"
+	ActionImpersonatedRequest = "admin.impersonated_request"
+	ActionPlanChange          = "admin.plan_change"
+)
+
+const (
+	TargetUser          = "user"
+	TargetSite          = "site"
+	TargetScan          = "scan"
+	TargetBrandAnalysis = "brand_analysis"
+)
+
+// Entry describes a single auditable action. ActorID is taken from the
+// authenticated user on the request when left empty.
+type Entry struct {
+	ActorID    int64
+	Action     string
+	TargetType string
+	TargetID   string
+	Metadata   map[string]any
+}
+
+type Service struct {
+	db *database.Service
+}
+
+func New(db *database.Service) *Service {
+	return &Service{db: db}
+}
+
+// Record stores an audit event for the current request. Failures are logged
+// and never surfaced to the caller; auditing must not break the action itself.
+// Events raised during an impersonated session carry the admin's id.
+func (s *Service) Record(c *gin.Context, e Entry) {
+	if e.ActorID == 0 {
+		if uRaw, ok := c.Get("user"); ok {
+			if user, ok := uRaw.(models.User); ok {
+				e.ActorID = user.ID
+			}
+		}
+	}
+	if iRaw, ok := c.Get("impersonator"); ok {
+		if admin, ok := iRaw.(models.User); ok {
+			if e.Metadata == nil {
+				e.Metadata = map[string]any{}
+			}
+			e.Metadata["impersonator_id"] = admin.ID
+		}
+	}
+
+	s.insert(e, c.ClientIP(), c.Request.UserAgent())
+}
+
+// Log stores an audit event raised outside of an HTTP request, such as by a
+// background job.
+func (s *Service) Log(e Entry) {
+	s.insert(e, "", "")
+}
+
+func (s *Service) insert(e Entry, ip, userAgent string) {
+	if e.Metadata == nil {
+		e.Metadata = map[string]any{}
+	}
+	meta, err := json.Marshal(e.Metadata)
+	if err != nil {
+		log.Printf("[audit] marshal metadata for %s failed: %v", e.Action, err)
+		meta = []byte("{}")
+	}
+
+	event := models.AuditEvent{
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		IP:         ip,
+		UserAgent:  userAgent,
+		Metadata:   models.JSONB(meta),
+	}
+	if e.ActorID != 0 {
+		actorID := e.ActorID
+		event.ActorID = &actorID
+	}
+
+	if err := s.db.DB.Create(&event).Error; err != nil {
+		log.Printf("[audit] insert %s failed: %v", e.Action, err)
+	}
+}
+
+// ID formats a numeric primary key as an audit target id.
+func ID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
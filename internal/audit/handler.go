@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+// applyFilters narrows the query by the supported query string filters:
+// action, target_type, target_id, from, to (RFC3339), limit and offset.
+func applyFilters(c *gin.Context, q *gorm.DB) (*gorm.DB, error) {
+	if v := c.Query("action"); v != "" {
+		q = q.Where("action = ?", v)
+	}
+	if v := c.Query("target_type"); v != "" {
+		q = q.Where("target_type = ?", v)
+	}
+	if v := c.Query("target_id"); v != "" {
+		q = q.Where("target_id = ?", v)
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where("created_at >= ?", from)
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where("created_at < ?", to)
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	if limit <= 0 || limit > maxLimit {
+		limit = defaultLimit
+	}
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	return q.Order("created_at DESC").Limit(limit).Offset(offset), nil
+}
+
+// GET /audit  events performed by the current user
+func ListMyEvents(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uRaw, _ := c.Get("user")
+		user, _ := uRaw.(models.User)
+		if user.ID == 0 {
+			response.Respond(c, http.StatusUnauthorized, "unauthorized", nil)
+			return
+		}
+
+		q, err := applyFilters(c, db.DB.Where("actor_id = ?", user.ID))
+		if err != nil {
+			response.Respond(c, http.StatusBadRequest, "invalid time filter: "+err.Error(), nil)
+			return
+		}
+
+		var events []models.AuditEvent
+		if err := q.Find(&events).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load audit events", nil)
+			return
+		}
+
+		response.Respond(c, http.StatusOK, "Audit events loaded", events)
+	}
+}
+
+// GET /admin/audit  events of every user, optionally filtered by actor_id
+func ListAllEvents(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := db.DB.Model(&models.AuditEvent{})
+		if v := c.Query("actor_id"); v != "" {
+			q = q.Where("actor_id = ?", v)
+		}
+
+		q, err := applyFilters(c, q)
+		if err != nil {
+			response.Respond(c, http.StatusBadRequest, "invalid time filter: "+err.Error(), nil)
+			return
+		}
+
+		var events []models.AuditEvent
+		if err := q.Find(&events).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load audit events", nil)
+			return
+		}
+
+		response.Respond(c, http.StatusOK, "Audit events loaded", events)
+	}
+}
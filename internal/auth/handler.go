@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"founders-toolkit-api/internal/audit"
 	"founders-toolkit-api/internal/database"
 	"founders-toolkit-api/internal/response"
 	"founders-toolkit-api/models"
@@ -17,11 +18,11 @@ const (
 	ErrTokenFailure    = "Failed to create token"
 )
 
-func SignUp(db *database.Service) gin.HandlerFunc {
+func SignUp(db *database.Service, auditor *audit.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var body struct {
 			Email    string `json:"email" binding:"required,email"`
-			Password string `json:"password" binding:"required,min=6"`
+			Password string `json:"password" binding:"required"`
 		}
 
 		if err := c.ShouldBindJSON(&body); err != nil {
@@ -29,6 +30,10 @@ func SignUp(db *database.Service) gin.HandlerFunc {
 			return
 		}
 
+		if rejectWeakPassword(c, "password", body.Password, body.Email) {
+			return
+		}
+
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(body.Password), bcrypt.DefaultCost)
 		if err != nil {
 			response.Respond(c, http.StatusInternalServerError, ErrTokenFailure, nil)
@@ -45,6 +50,13 @@ func SignUp(db *database.Service) gin.HandlerFunc {
 			return
 		}
 
+		auditor.Record(c, audit.Entry{
+			ActorID:    user.ID,
+			Action:     audit.ActionSignUp,
+			TargetType: audit.TargetUser,
+			TargetID:   audit.ID(user.ID),
+		})
+
 		accessToken, err := GenerateAccessTokenString(*user)
 		if err != nil {
 			response.Respond(c, http.StatusBadRequest, ErrTokenFailure, nil)
@@ -66,7 +78,7 @@ func SignUp(db *database.Service) gin.HandlerFunc {
 	}
 }
 
-func Login(db *database.Service) gin.HandlerFunc {
+func Login(db *database.Service, auditor *audit.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var body struct {
 			Email    string `json:"email"`
@@ -84,16 +96,34 @@ func Login(db *database.Service) gin.HandlerFunc {
 			return
 		}
 		if user.ID == 0 {
+			// no target, but the IP still shows attempts across many emails
+			auditor.Record(c, audit.Entry{
+				Action:   audit.ActionLoginFailed,
+				Metadata: map[string]any{"reason": "unknown_email"},
+			})
 			response.Respond(c, http.StatusNotFound, ErrUserNotFound, nil)
 			return
 		}
 
 		err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(body.Password))
 		if err != nil {
+			auditor.Record(c, audit.Entry{
+				ActorID:    user.ID,
+				Action:     audit.ActionLoginFailed,
+				TargetType: audit.TargetUser,
+				TargetID:   audit.ID(user.ID),
+			})
 			response.Respond(c, http.StatusUnauthorized, ErrInvalidPassword, nil)
 			return
 		}
 
+		auditor.Record(c, audit.Entry{
+			ActorID:    user.ID,
+			Action:     audit.ActionLogin,
+			TargetType: audit.TargetUser,
+			TargetID:   audit.ID(user.ID),
+		})
+
 		accessToken, err := GenerateAccessTokenString(user)
 		if err != nil {
 			response.Respond(c, http.StatusBadRequest, ErrTokenFailure, nil)
@@ -131,6 +161,10 @@ func RefreshAccessToken(db *database.Service) gin.HandlerFunc {
 			response.Respond(c, http.StatusUnauthorized, err.Error(), nil)
 			return
 		}
+		if claims.ImpersonatorID != "" {
+			response.Respond(c, http.StatusUnauthorized, ErrImpersonating, nil)
+			return
+		}
 
 		userId := claims.Subject
 		user, err := db.FindUserById(userId)
@@ -161,7 +195,7 @@ const (
 	MsgPasswordChanged = "Password updated successfully"
 )
 
-func ChangePassword(db *database.Service) gin.HandlerFunc {
+func ChangePassword(db *database.Service, auditor *audit.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		_user, ok := c.Get("user")
 		user := _user.(models.User)
@@ -172,8 +206,8 @@ func ChangePassword(db *database.Service) gin.HandlerFunc {
 		}
 
 		var body struct {
-			CurrentPassword string `json:"current_password" binding:"required,min=4"`
-			NewPassword     string `json:"new_password" binding:"required,min=4"`
+			CurrentPassword string `json:"current_password" binding:"required"`
+			NewPassword     string `json:"new_password" binding:"required"`
 		}
 
 		if err := c.ShouldBindJSON(&body); err != nil {
@@ -181,12 +215,23 @@ func ChangePassword(db *database.Service) gin.HandlerFunc {
 			return
 		}
 
+		// the policy is only revealed to someone who knows the password
 		err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(body.CurrentPassword))
 		if err != nil {
 			response.Respond(c, http.StatusBadRequest, ErrIncorrectCurrentPassword, nil)
 			return
 		}
 
+		if body.NewPassword == body.CurrentPassword {
+			response.Respond(c, http.StatusBadRequest, ErrPasswordPolicy, gin.H{
+				"errors": gin.H{"new_password": []string{"must differ from the current password"}},
+			})
+			return
+		}
+		if rejectWeakPassword(c, "new_password", body.NewPassword, user.Email) {
+			return
+		}
+
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(body.NewPassword), bcrypt.DefaultCost)
 		if err != nil {
 			response.Respond(c, http.StatusInternalServerError, ErrHashFailure, nil)
@@ -198,10 +243,58 @@ func ChangePassword(db *database.Service) gin.HandlerFunc {
 			return
 		}
 
+		auditor.Record(c, audit.Entry{
+			Action:     audit.ActionPasswordChange,
+			TargetType: audit.TargetUser,
+			TargetID:   audit.ID(user.ID),
+		})
+
 		response.Respond(c, http.StatusOK, MsgPasswordChanged, nil)
 	}
 }
 
+const (
+	ErrCannotImpersonate = "Admins cannot be impersonated"
+)
+
+// POST /admin/impersonate/:id  short-lived access token acting as another user
+func Impersonate(db *database.Service, auditor *audit.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		aRaw, _ := c.Get("user")
+		admin, _ := aRaw.(models.User)
+
+		target, err := db.FindUserById(c.Param("id"))
+		if err != nil || target.ID == 0 {
+			response.Respond(c, http.StatusNotFound, ErrUserNotFound, nil)
+			return
+		}
+		if target.IsAdmin() {
+			response.Respond(c, http.StatusForbidden, ErrCannotImpersonate, nil)
+			return
+		}
+
+		accessToken, err := GenerateImpersonationTokenString(admin, target)
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, ErrTokenFailure, nil)
+			return
+		}
+
+		auditor.Record(c, audit.Entry{
+			ActorID:    admin.ID,
+			Action:     audit.ActionImpersonationStart,
+			TargetType: audit.TargetUser,
+			TargetID:   audit.ID(target.ID),
+		})
+
+		response.Respond(c, http.StatusOK, "Impersonation token issued",
+			gin.H{
+				"access_token": accessToken,
+				"expires_in":   int(impersonationTTL.Seconds()),
+				"user_id":      target.ID,
+			})
+	}
+}
+
 func Logout(c *gin.Context) {
 	c.SetCookie("Authorization", "", -1, "/", "", false, true)
 	response.Respond(c, http.StatusOK, "Logged out successfully", nil)
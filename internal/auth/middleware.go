@@ -1,8 +1,11 @@
 package auth
 
 import (
+	"founders-toolkit-api/internal/audit"
 	"founders-toolkit-api/internal/database"
 	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/models"
+	"log"
 	"net/http"
 	"strings"
 
@@ -12,6 +15,8 @@ import (
 const (
 	ErrAuthHeaderMissing = "Authorization header missing"
 	ErrTokenMissing      = "Token missing or invalid"
+	ErrAdminOnly         = "Admin access required"
+	ErrImpersonating     = "Not allowed while impersonating a user"
 )
 
 func abort(c *gin.Context, msg string) {
@@ -49,7 +54,75 @@ func AuthenticateUser(db *database.Service) gin.HandlerFunc {
 			return
 		}
 
+		if claims.ImpersonatorID != "" {
+			admin, err := db.FindUserById(claims.ImpersonatorID)
+			if err != nil || !admin.IsAdmin() {
+				abort(c, ErrTokenMissing)
+				return
+			}
+			c.Set("impersonator", admin)
+		}
+
 		c.Set("user", user)
 		c.Next()
 	}
 }
+
+// RequireAdmin must run after AuthenticateUser.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uRaw, _ := c.Get("user")
+		user, _ := uRaw.(models.User)
+		if !user.IsAdmin() {
+			response.Respond(c, http.StatusForbidden, ErrAdminOnly, nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ForbidImpersonation blocks sensitive actions such as password changes for
+// impersonated sessions. It must run after AuthenticateUser.
+func ForbidImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("impersonator"); ok {
+			response.Respond(c, http.StatusForbidden, ErrImpersonating, nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// AuditImpersonation records every request made with an impersonation token.
+// It is registered globally and inspects the context after the handler chain
+// ran, once AuthenticateUser has resolved the impersonator.
+func AuditImpersonation(auditor *audit.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		iRaw, ok := c.Get("impersonator")
+		if !ok {
+			return
+		}
+		admin, _ := iRaw.(models.User)
+		uRaw, _ := c.Get("user")
+		user, _ := uRaw.(models.User)
+
+		log.Printf("[auth] impersonated request admin=%d user=%d %s %s -> %d",
+			admin.ID, user.ID, c.Request.Method, c.FullPath(), c.Writer.Status())
+
+		auditor.Record(c, audit.Entry{
+			ActorID:    admin.ID,
+			Action:     audit.ActionImpersonatedRequest,
+			TargetType: audit.TargetUser,
+			TargetID:   audit.ID(user.ID),
+			Metadata: map[string]any{
+				"method": c.Request.Method,
+				"path":   c.Request.URL.Path,
+				"status": c.Writer.Status(),
+			},
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"founders-toolkit-api/internal/response"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ErrPasswordPolicy = "Password does not meet the password policy"
+)
+
+type PasswordPolicy struct {
+	// MinLength is counted in characters, not bytes.
+	MinLength int
+	// MaxBytes is bcrypt's limit; it ignores everything after 72 bytes.
+	MaxBytes       int
+	MinEntropyBits float64
+}
+
+var passwordPolicy = PasswordPolicy{
+	MinLength:      10,
+	MaxBytes:       72,
+	MinEntropyBits: 45,
+}
+
+var (
+	breachedOnce sync.Once
+	breached     *BreachedList
+)
+
+// breachedList loads BREACHED_PASSWORDS_DIR on first use so the env file has
+// been read by then.
+func breachedList() *BreachedList {
+	breachedOnce.Do(func() {
+		breached = NewBreachedList(os.Getenv("BREACHED_PASSWORDS_DIR"))
+	})
+	return breached
+}
+
+// rejectWeakPassword responds with field level errors and returns true when
+// password violates the policy.
+func rejectWeakPassword(c *gin.Context, field, password, email string) bool {
+	problems := passwordPolicy.Check(password, email)
+	if len(problems) == 0 {
+		return false
+	}
+
+	response.Respond(c, http.StatusBadRequest, ErrPasswordPolicy, gin.H{
+		"errors": gin.H{field: problems},
+	})
+	return true
+}
+
+// Check returns every rule the password violates, or nil if it is accepted.
+func (p PasswordPolicy) Check(password, email string) []string {
+	var problems []string
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		problems = append(problems, fmt.Sprintf("must be at least %d characters long", p.MinLength))
+	}
+	if len(password) > p.MaxBytes {
+		problems = append(problems, fmt.Sprintf("must be at most %d bytes long", p.MaxBytes))
+	}
+	if estimateEntropy(password) < p.MinEntropyBits {
+		problems = append(problems, "is too easy to guess; use a longer password or mix character types")
+	}
+	if matchesEmail(password, email) {
+		problems = append(problems, "must not be the same as your email address")
+	}
+	if breachedList().Contains(password) {
+		problems = append(problems, "has appeared in a known data breach")
+	}
+
+	return problems
+}
+
+func matchesEmail(password, email string) bool {
+	if email == "" {
+		return false
+	}
+	pw := strings.ToLower(strings.TrimSpace(password))
+	email = strings.ToLower(strings.TrimSpace(email))
+	local, _, _ := strings.Cut(email, "@")
+	return pw == email || pw == local
+}
+
+// estimateEntropy approximates the password strength in bits as
+// log2(character pool) per character. Runs of repeated or sequential
+// characters only count half, so "aaaaaaaa" or "12345678" score low.
+func estimateEntropy(password string) float64 {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range password {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII && unicode.IsPrint(r):
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if symbol {
+		pool += 33
+	}
+	if other {
+		pool += 100
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	var effective float64
+	var prev rune = -1
+	for _, r := range password {
+		if prev >= 0 && (r == prev || r == prev+1 || r == prev-1) {
+			effective += 0.5
+		} else {
+			effective++
+		}
+		prev = r
+	}
+
+	return effective * math.Log2(float64(pool))
+}
+
+// BreachedList checks passwords against a local copy of a breached password
+// corpus stored in the k-anonymity range format: one file per 5 character
+// upper-case SHA-1 prefix (e.g. "5BAA6" or "5BAA6.txt"), each line holding
+// the remaining 35 hash characters and an optional count ("SUFFIX:COUNT").
+type BreachedList struct {
+	dir string
+}
+
+// NewBreachedList returns a list backed by dir. An empty dir disables the check.
+func NewBreachedList(dir string) *BreachedList {
+	if dir == "" {
+		return nil
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		log.Printf("[auth] breached password dir %q unavailable, check disabled", dir)
+		return nil
+	}
+	return &BreachedList{dir: dir}
+}
+
+func (b *BreachedList) Contains(password string) bool {
+	if b == nil {
+		return false
+	}
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	f, err := os.Open(filepath.Join(b.dir, prefix))
+	if err != nil {
+		f, err = os.Open(filepath.Join(b.dir, prefix+".txt"))
+	}
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		candidate, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if strings.EqualFold(candidate, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		email    string
+		want     []string // substrings of the expected problems, in order
+	}{
+		{name: "strong", password: "Tr0ub4dor&3x", email: "alice@example.com"},
+		{name: "too short", password: "Ab3$xY", want: []string{"at least 10 characters", "too easy to guess"}},
+		{name: "short in characters though long in bytes", password: "ééééé", want: []string{"at least 10 characters", "too easy to guess"}},
+		{name: "long non-ascii", password: "Grüße-aus-Köln-7", email: "bob@example.com"},
+		{name: "over bcrypt limit", password: strings.Repeat("Zq7!", 19), want: []string{"at most 72 bytes"}},
+		{name: "repeated characters", password: "aaaaaaaaaaaaaaaa", want: []string{"too easy to guess"}},
+		{name: "sequence", password: "abcdefghijklmnop", want: []string{"too easy to guess"}},
+		{name: "same as email", password: "Quentin.Blake-77@Example.com", email: "quentin.blake-77@example.com", want: []string{"same as your email"}},
+		{name: "same as email local part", password: "Quentin.Blake-77", email: "quentin.blake-77@example.com", want: []string{"same as your email"}},
+		{name: "contains but differs from email", password: "Quentin.Blake-77!x", email: "quentin.blake-77@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := passwordPolicy.Check(tt.password, tt.email)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Check(%q) = %q, want %d problems matching %q", tt.password, got, len(tt.want), tt.want)
+			}
+			for i, w := range tt.want {
+				if !strings.Contains(got[i], w) {
+					t.Errorf("problem %d = %q, want it to mention %q", i, got[i], w)
+				}
+			}
+		})
+	}
+}
+
+func TestEstimateEntropy(t *testing.T) {
+	tests := []struct {
+		password string
+		min, max float64
+	}{
+		{"", 0, 0},
+		{"aaaaaaaa", 20, 22},     // 1 + 7*0.5 chars of log2(26)
+		{"12345678", 14, 16},     // 1 + 7*0.5 chars of log2(10)
+		{"qwzmxkvp", 37, 38},     // 8 chars of log2(26)
+		{"Qw3!zM9#", 52, 53},     // 8 chars of log2(95)
+		{"çöğüşıİé", 49, 51},     // pool of 100 for non-ascii; ı and İ are adjacent
+		{"abcabcabcabc", 37, 38}, // 8 effective chars: each step of a run counts half
+	}
+
+	for _, tt := range tests {
+		got := estimateEntropy(tt.password)
+		if got < tt.min || got > tt.max {
+			t.Errorf("estimateEntropy(%q) = %.1f, want within [%v, %v]", tt.password, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestBreachedList(t *testing.T) {
+	hashOf := func(pw string) (string, string) {
+		sum := sha1.Sum([]byte(pw))
+		h := strings.ToUpper(hex.EncodeToString(sum[:]))
+		return h[:5], h[5:]
+	}
+
+	dir := t.TempDir()
+	prefix, suffix := hashOf("password123")
+	body := "0000000000000000000000000000000000A:3\n" + strings.ToLower(suffix) + ":24\n"
+	if err := os.WriteFile(filepath.Join(dir, prefix), []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	txtPrefix, txtSuffix := hashOf("letmein2024")
+	if err := os.WriteFile(filepath.Join(dir, txtPrefix+".txt"), []byte(txtSuffix+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	list := NewBreachedList(dir)
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"password123", true},       // suffix with count, lower case
+		{"letmein2024", true},       // prefix file with .txt extension
+		{"password1234", false},     // no file for its prefix
+		{"Tr0ub4dor&3x-new", false}, // prefix file missing
+	}
+	for _, tt := range tests {
+		if got := list.Contains(tt.password); got != tt.want {
+			t.Errorf("Contains(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+
+	if NewBreachedList("") != nil {
+		t.Error("NewBreachedList(\"\") should disable the check")
+	}
+	if NewBreachedList(filepath.Join(dir, "missing")) != nil {
+		t.Error("NewBreachedList of a missing dir should disable the check")
+	}
+	var disabled *BreachedList
+	if disabled.Contains("password123") {
+		t.Error("a disabled list must not match")
+	}
+}
@@ -12,8 +12,12 @@ import (
 
 type AuthClaims struct {
 	jwt.RegisteredClaims
+	// ImpersonatorID is the admin acting as Subject, empty for normal sessions.
+	ImpersonatorID string `json:"imp,omitempty"`
 }
 
+const impersonationTTL = 10 * time.Minute
+
 var hmacSecret = []byte(os.Getenv("HMAC_SECRET"))
 
 func generateToken(id int, expiresAt time.Time) *jwt.Token {
@@ -35,6 +39,20 @@ func GenerateRefreshTokenString(user models.User) (string, error) {
 	return token.SignedString(hmacSecret)
 }
 
+// GenerateImpersonationTokenString issues a short-lived access token for
+// target that also carries the admin's id. No refresh token is ever issued
+// for it.
+func GenerateImpersonationTokenString(admin, target models.User) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, AuthClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   fmt.Sprintf("%d", target.ID),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(impersonationTTL)),
+		},
+		ImpersonatorID: fmt.Sprintf("%d", admin.ID),
+	})
+	return token.SignedString(hmacSecret)
+}
+
 func ParseToken(tokenString string) (*AuthClaims, error) {
 	claims := &AuthClaims{}
 
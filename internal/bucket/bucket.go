@@ -3,8 +3,11 @@ package bucket
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
+	"net/url"
 	"os"
+	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
@@ -12,6 +15,7 @@ import (
 
 type Service struct {
 	client *minio.Client
+	name   string
 }
 
 func New() *Service {
@@ -19,7 +23,11 @@ func New() *Service {
 		endpoint        = os.Getenv("BUCKET_ENDPOINT")
 		accessKeyID     = os.Getenv("BUCKET_ACCESS_KEY")
 		secretAccessKey = os.Getenv("BUCKET_SECRET_KEY")
+		name            = os.Getenv("BUCKET_NAME")
 	)
+	if name == "" {
+		name = "kova-1"
+	}
 	minioClient, err := minio.New(endpoint, &minio.Options{
 		Creds:  credentials.NewStaticV4(accessKeyID, secretAccessKey, ""),
 		Secure: true,
@@ -29,7 +37,7 @@ func New() *Service {
 		log.Fatal(err)
 	}
 
-	return &Service{client: minioClient}
+	return &Service{client: minioClient, name: name}
 }
 
 func (s *Service) ListBuckets() ([]minio.BucketInfo, error) {
@@ -48,7 +56,7 @@ func (s *Service) ListObjects() {
 	}
 
 	// List all objects from a bucket-name with a matching prefix.
-	for object := range s.client.ListObjects(context.Background(), "kova-1", opts) {
+	for object := range s.client.ListObjects(context.Background(), s.name, opts) {
 		if object.Err != nil {
 			fmt.Println(object.Err)
 			break
@@ -56,3 +64,23 @@ func (s *Service) ListObjects() {
 		fmt.Println(object)
 	}
 }
+
+func (s *Service) PutObject(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.name, key, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return err
+}
+
+// PresignedGetURL returns a time limited download link for key.
+func (s *Service) PresignedGetURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	u, err := s.client.PresignedGetObject(ctx, s.name, key, expiry, url.Values{})
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func (s *Service) RemoveObject(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.name, key, minio.RemoveObjectOptions{})
+}
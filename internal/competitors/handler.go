@@ -0,0 +1,294 @@
+package competitors
+
+import (
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/internal/search"
+	"founders-toolkit-api/internal/sites"
+	"founders-toolkit-api/models"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const topCitationDomains = 5
+
+type DomainCount struct {
+	Domain string `json:"domain"`
+	Count  int    `json:"count"`
+}
+
+type TypeStats struct {
+	Appearances int `json:"appearances"`
+	Wins        int `json:"wins"`
+}
+
+type Metrics struct {
+	Appearances  int `json:"appearances"`
+	AnalysesSeen int `json:"analyses_seen"`
+	// AppearanceFrequency is the share of the site's brand analyses the
+	// competitor appeared in.
+	AppearanceFrequency float64              `json:"appearance_frequency"`
+	AvgPosition         float64              `json:"avg_position"`
+	Wins                int                  `json:"wins"`
+	ByQueryType         map[string]TypeStats `json:"by_query_type"`
+	CitationDomains     []DomainCount        `json:"citation_domains"`
+}
+
+type CompetitorWithMetrics struct {
+	models.Competitor
+	Metrics Metrics `json:"metrics"`
+}
+
+type TimelinePoint struct {
+	BrandAnalysisID int64     `json:"brand_analysis_id"`
+	CreatedAt       time.Time `json:"created_at"`
+	Appearances     int       `json:"appearances"`
+	BestPosition    int       `json:"best_position"`
+	Wins            int       `json:"wins"`
+}
+
+// GET /sites/:id/competitors  competitors of the site with their metrics.
+// ?status=suggested|confirmed|ignored filters; ignored ones are hidden by default.
+func ListCompetitors(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+
+		q := db.DB.Where("site_id = ? AND user_id = ?", site.ID, user.ID)
+		if status := c.Query("status"); status != "" {
+			q = q.Where("status = ?", status)
+		} else {
+			q = q.Where("status <> ?", models.CompetitorStatusIgnored)
+		}
+
+		var comps []models.Competitor
+		if err := q.Order("last_seen_at DESC").Find(&comps).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load competitors", nil)
+			return
+		}
+
+		ids := make([]int64, 0, len(comps))
+		for _, comp := range comps {
+			ids = append(ids, comp.ID)
+		}
+		metrics, err := loadMetrics(db, site.ID, ids)
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load competitor metrics", nil)
+			return
+		}
+
+		out := make([]CompetitorWithMetrics, 0, len(comps))
+		for _, comp := range comps {
+			out = append(out, CompetitorWithMetrics{Competitor: comp, Metrics: metrics[comp.ID]})
+		}
+		sort.SliceStable(out, func(i, j int) bool {
+			return out[i].Metrics.Appearances > out[j].Metrics.Appearances
+		})
+
+		response.Respond(c, http.StatusOK, "Competitors loaded", out)
+	}
+}
+
+// GET /sites/:id/competitors/:cid  one competitor with metrics and a
+// per-analysis timeline
+func GetCompetitor(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+		comp, ok := ownedCompetitor(c, db, site.ID)
+		if !ok {
+			return
+		}
+
+		metrics, err := loadMetrics(db, site.ID, []int64{comp.ID})
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load competitor metrics", nil)
+			return
+		}
+
+		var timeline []TimelinePoint
+		if err := db.DB.Raw(`
+			SELECT a.brand_analysis_id,
+			       b.created_at,
+			       COUNT(*)                      AS appearances,
+			       MIN(a.position)               AS best_position,
+			       COUNT(*) FILTER (WHERE a.won) AS wins
+			FROM competitor_appearances a
+			JOIN brand_analyses b ON b.id = a.brand_analysis_id
+			WHERE a.competitor_id = ?
+			GROUP BY a.brand_analysis_id, b.created_at
+			ORDER BY b.created_at`, comp.ID).Scan(&timeline).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load competitor timeline", nil)
+			return
+		}
+
+		response.Respond(c, http.StatusOK, "Competitor loaded", gin.H{
+			"competitor": CompetitorWithMetrics{Competitor: comp, Metrics: metrics[comp.ID]},
+			"timeline":   timeline,
+		})
+	}
+}
+
+// POST /sites/:id/competitors/:cid/confirm and /ignore
+func SetStatus(db *database.Service, status string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+		comp, ok := ownedCompetitor(c, db, site.ID)
+		if !ok {
+			return
+		}
+
+		if err := db.DB.Model(&comp).Update("status", status).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "competitor update failed", nil)
+			return
+		}
+
+		response.Respond(c, http.StatusOK, "Competitor updated", comp)
+	}
+}
+
+func loadMetrics(db *database.Service, siteID int64, ids []int64) (map[int64]Metrics, error) {
+	out := make(map[int64]Metrics, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+
+	var totalAnalyses int64
+	if err := db.DB.Table("brand_analyses").
+		Where("site_id = ? AND status = ?", siteID, models.AnalysisStatusCompleted).Count(&totalAnalyses).Error; err != nil {
+		return nil, err
+	}
+
+	var byType []struct {
+		CompetitorID int64
+		QueryType    string
+		Appearances  int
+		Wins         int
+		PositionSum  int
+	}
+	if err := db.DB.Raw(`
+		SELECT competitor_id,
+		       query_type,
+		       COUNT(*)                    AS appearances,
+		       COUNT(*) FILTER (WHERE won) AS wins,
+		       SUM(position)               AS position_sum
+		FROM competitor_appearances
+		WHERE competitor_id IN ?
+		GROUP BY competitor_id, query_type`, ids).Scan(&byType).Error; err != nil {
+		return nil, err
+	}
+
+	var seen []struct {
+		CompetitorID int64
+		Analyses     int
+	}
+	if err := db.DB.Raw(`
+		SELECT competitor_id, COUNT(DISTINCT brand_analysis_id) AS analyses
+		FROM competitor_appearances
+		WHERE competitor_id IN ?
+		GROUP BY competitor_id`, ids).Scan(&seen).Error; err != nil {
+		return nil, err
+	}
+
+	var citations []models.CompetitorAppearance
+	if err := db.DB.Select("competitor_id", "citations").
+		Where("competitor_id IN ?", ids).Find(&citations).Error; err != nil {
+		return nil, err
+	}
+
+	positionSums := map[int64]int{}
+	for _, row := range byType {
+		m := out[row.CompetitorID]
+		if m.ByQueryType == nil {
+			m.ByQueryType = map[string]TypeStats{}
+		}
+		m.ByQueryType[row.QueryType] = TypeStats{Appearances: row.Appearances, Wins: row.Wins}
+		m.Appearances += row.Appearances
+		m.Wins += row.Wins
+		positionSums[row.CompetitorID] += row.PositionSum
+		out[row.CompetitorID] = m
+	}
+
+	for _, row := range seen {
+		m := out[row.CompetitorID]
+		m.AnalysesSeen = row.Analyses
+		if totalAnalyses > 0 {
+			m.AppearanceFrequency = float64(row.Analyses) / float64(totalAnalyses)
+		}
+		if m.Appearances > 0 {
+			m.AvgPosition = float64(positionSums[row.CompetitorID]) / float64(m.Appearances)
+		}
+		out[row.CompetitorID] = m
+	}
+
+	domainCounts := map[int64]map[string]int{}
+	for _, a := range citations {
+		counts := domainCounts[a.CompetitorID]
+		if counts == nil {
+			counts = map[string]int{}
+			domainCounts[a.CompetitorID] = counts
+		}
+		for _, cite := range a.Citations {
+			if d := citationDomain(cite); d != "" {
+				counts[d]++
+			}
+		}
+	}
+	for id, counts := range domainCounts {
+		m := out[id]
+		m.CitationDomains = topDomains(counts, topCitationDomains)
+		out[id] = m
+	}
+
+	return out, nil
+}
+
+// citationDomain accepts either a full URL or a bare domain.
+func citationDomain(cite string) string {
+	cite = strings.TrimSpace(cite)
+	if cite == "" {
+		return ""
+	}
+	if !strings.Contains(cite, "://") {
+		cite = "https://" + cite
+	}
+	return search.DomainOf(cite)
+}
+
+func topDomains(counts map[string]int, n int) []DomainCount {
+	out := make([]DomainCount, 0, len(counts))
+	for d, cnt := range counts {
+		out = append(out, DomainCount{Domain: d, Count: cnt})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Domain < out[j].Domain
+	})
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+func ownedCompetitor(c *gin.Context, db *database.Service, siteID int64) (models.Competitor, bool) {
+	var comp models.Competitor
+	if err := db.DB.Where("id = ? AND site_id = ?", c.Param("cid"), siteID).
+		First(&comp).Error; err != nil || comp.ID == 0 {
+		response.Respond(c, http.StatusNotFound, "competitor not found", nil)
+		return comp, false
+	}
+	return comp, true
+}
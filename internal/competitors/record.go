@@ -0,0 +1,111 @@
+package competitors
+
+import (
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/models"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Appearance is a non-target brand found for one query of a brand analysis.
+type Appearance struct {
+	Name           string
+	URL            string
+	Domain         string
+	QueryType      string
+	Query          string
+	Position       int // 1-based among the query's brands
+	TargetPosition int // 0 when the target site was absent
+	Citations      []string
+}
+
+// NormalizeName is the key competitors are deduplicated on per site.
+func NormalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Record upserts the competitors seen in a brand analysis and stores their
+// appearances. Ignored competitors only get their last_seen_at bumped.
+func Record(db *database.Service, siteID, userID, analysisID int64, seenAt time.Time, apps []Appearance) error {
+	if len(apps) == 0 {
+		return nil
+	}
+
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		byName := map[string]*models.Competitor{}
+		var rows []models.CompetitorAppearance
+
+		for _, a := range apps {
+			key := NormalizeName(a.Name)
+			if key == "" {
+				continue
+			}
+
+			comp, ok := byName[key]
+			if !ok {
+				comp = &models.Competitor{
+					SiteID:         siteID,
+					UserID:         userID,
+					Name:           strings.TrimSpace(a.Name),
+					NormalizedName: key,
+					URL:            a.URL,
+					Domain:         a.Domain,
+					Status:         models.CompetitorStatusSuggested,
+					FirstSeenAt:    seenAt,
+					LastSeenAt:     seenAt,
+				}
+				err := tx.Clauses(clause.OnConflict{
+					Columns: []clause.Column{{Name: "site_id"}, {Name: "normalized_name"}},
+					DoUpdates: clause.Assignments(map[string]any{
+						"last_seen_at": seenAt,
+						"url":          gorm.Expr("COALESCE(NULLIF(competitors.url, ''), EXCLUDED.url)"),
+						"domain":       gorm.Expr("COALESCE(NULLIF(competitors.domain, ''), EXCLUDED.domain)"),
+						"updated_at":   seenAt,
+					}),
+				}).Create(comp).Error
+				if err != nil {
+					return err
+				}
+				// the upsert only returns the id; reload for the current status
+				if err := tx.First(comp, comp.ID).Error; err != nil {
+					return err
+				}
+				byName[key] = comp
+			}
+
+			if comp.Status == models.CompetitorStatusIgnored {
+				continue
+			}
+
+			citations := a.Citations
+			if citations == nil {
+				citations = []string{}
+			}
+			rows = append(rows, models.CompetitorAppearance{
+				CompetitorID:    comp.ID,
+				BrandAnalysisID: analysisID,
+				QueryType:       a.QueryType,
+				Query:           a.Query,
+				Position:        a.Position,
+				TargetPosition:  a.TargetPosition,
+				Won:             a.TargetPosition == 0 || a.Position < a.TargetPosition,
+				Citations:       models.StringArray(citations),
+			})
+		}
+
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+}
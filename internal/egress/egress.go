@@ -0,0 +1,126 @@
+// Package egress guards outbound requests to user supplied URLs (alert and
+// webhook targets) against reaching the server's own network: loopback,
+// private, link-local and cloud metadata addresses are refused, both when a
+// URL is saved and again when the connection is dialed, so a hostname that
+// later resolves elsewhere is still caught.
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var ErrForbiddenHost = errors.New("target host is not publicly reachable")
+
+// allowPrivate lets local receivers (alerttest, a local webhook catcher) be
+// used during development.
+var allowPrivate = os.Getenv("APP_ENV") == "development"
+
+const resolveTimeout = 5 * time.Second
+
+// blockedNets are special purpose ranges the net.IP predicates miss.
+var blockedNets = parseCIDRs(
+	"0.0.0.0/8",     // this network
+	"100.64.0.0/10", // carrier-grade NAT, also some metadata services
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"240.0.0.0/4",   // reserved
+	"64:ff9b::/96",  // NAT64, may embed a private IPv4
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	out := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+// Allowed reports whether ip is a public unicast address.
+func Allowed(ip net.IP) bool {
+	if allowPrivate {
+		return true
+	}
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, n := range blockedNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckURL validates a target before it is stored: an http(s) URL whose host
+// resolves to public addresses only.
+func CheckURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("target must be an http(s) URL")
+	}
+	if u.User != nil {
+		return errors.New("target must not contain credentials")
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if ip := net.ParseIP(host); ip != nil {
+		if !Allowed(ip) {
+			return ErrForbiddenHost
+		}
+		return nil
+	}
+	if !allowPrivate && (host == "localhost" || strings.HasSuffix(host, ".localhost") ||
+		strings.HasSuffix(host, ".internal") || strings.HasSuffix(host, ".local")) {
+		return ErrForbiddenHost
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("target host %q could not be resolved", host)
+	}
+	for _, a := range addrs {
+		if !Allowed(a.IP) {
+			return ErrForbiddenHost
+		}
+	}
+	return nil
+}
+
+// NewClient returns an HTTP client that refuses to connect to addresses
+// Allowed rejects, including after redirects. Proxies from the environment
+// are not used, as they would hide the real destination from the check.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !Allowed(net.ParseIP(host)) {
+				return fmt.Errorf("dial %s: %w", address, ErrForbiddenHost)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
@@ -0,0 +1,121 @@
+package engines
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"founders-toolkit-api/internal/llm"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAnthropicBaseURL = "https://api.anthropic.com"
+	defaultAnthropicModel   = "claude-sonnet-4-5"
+	anthropicVersion        = "2023-06-01"
+	anthropicMaxTokens      = 2048
+	anthropicMaxSearches    = 5
+)
+
+// AnthropicEngine asks the Messages API with the server-side web search tool.
+type AnthropicEngine struct {
+	APIKey  string
+	BaseURL string
+	model   string
+	Client  *http.Client
+}
+
+func NewAnthropic(apiKey, model string) *AnthropicEngine {
+	if model == "" {
+		model = defaultAnthropicModel
+	}
+	return &AnthropicEngine{
+		APIKey:  apiKey,
+		BaseURL: defaultAnthropicBaseURL,
+		model:   model,
+		Client:  &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+func (e *AnthropicEngine) Name() string  { return Anthropic }
+func (e *AnthropicEngine) Model() string { return e.model }
+
+type anthropicResponse struct {
+	Content []struct {
+		Type      string `json:"type"`
+		Text      string `json:"text"`
+		Citations []struct {
+			URL string `json:"url"`
+		} `json:"citations"`
+		// web_search_tool_result blocks list every result the search returned
+		Content json.RawMessage `json:"content"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens          int64 `json:"input_tokens"`
+		OutputTokens         int64 `json:"output_tokens"`
+		CacheReadInputTokens int64 `json:"cache_read_input_tokens"`
+		ServerToolUse        struct {
+			WebSearchRequests int `json:"web_search_requests"`
+		} `json:"server_tool_use"`
+	} `json:"usage"`
+}
+
+func (e *AnthropicEngine) Ask(ctx context.Context, query string) (Answer, error) {
+	header := http.Header{}
+	header.Set("x-api-key", e.APIKey)
+	header.Set("anthropic-version", anthropicVersion)
+
+	body, err := postJSON(ctx, Anthropic, e.Client, strings.TrimRight(e.BaseURL, "/")+"/v1/messages", header, map[string]any{
+		"model":      e.model,
+		"max_tokens": anthropicMaxTokens,
+		"messages":   []map[string]string{{"role": "user", "content": query}},
+		"tools": []map[string]any{{
+			"type":     "web_search_20250305",
+			"name":     "web_search",
+			"max_uses": anthropicMaxSearches,
+		}},
+	})
+	if err != nil {
+		return Answer{}, err
+	}
+
+	var parsed anthropicResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return Answer{}, fmt.Errorf("decode anthropic response: %w", err)
+	}
+	llm.Record(ctx, llm.CallEngine, e.model, llm.Usage{
+		// input_tokens excludes cache reads, llm.Usage counts them in
+		InputTokens:       parsed.Usage.InputTokens + parsed.Usage.CacheReadInputTokens,
+		CachedInputTokens: parsed.Usage.CacheReadInputTokens,
+		OutputTokens:      parsed.Usage.OutputTokens,
+		WebSearchCalls:    parsed.Usage.ServerToolUse.WebSearchRequests,
+	})
+
+	var text strings.Builder
+	var cited, searched []string
+	for _, block := range parsed.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+			for _, c := range block.Citations {
+				cited = append(cited, c.URL)
+			}
+		case "web_search_tool_result":
+			var results []struct {
+				URL string `json:"url"`
+			}
+			// an error result is an object rather than a list
+			if json.Unmarshal(block.Content, &results) == nil {
+				for _, r := range results {
+					searched = append(searched, r.URL)
+				}
+			}
+		}
+	}
+	// answers without inline citations still drew on the search results
+	if len(cited) == 0 {
+		cited = searched
+	}
+	return Answer{Engine: Anthropic, Model: e.model, Text: strings.TrimSpace(text.String()), Citations: uniqueURLs(cited)}, nil
+}
@@ -0,0 +1,184 @@
+// Package engines asks AI answer engines (OpenAI, Anthropic, Gemini,
+// Perplexity) the same question through one interface, so brand visibility
+// can be compared across them.
+package engines
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"founders-toolkit-api/internal/llm"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Engine names.
+const (
+	OpenAI     = "openai"
+	Anthropic  = "anthropic"
+	Gemini     = "gemini"
+	Perplexity = "perplexity"
+)
+
+// Names lists every supported engine.
+var Names = []string{OpenAI, Anthropic, Gemini, Perplexity}
+
+// Answer is what an engine replied to a query, with the sources it cited.
+type Answer struct {
+	Engine    string   `json:"engine"`
+	Model     string   `json:"model"`
+	Text      string   `json:"text"`
+	Citations []string `json:"citations"`
+}
+
+// Engine answers a query the way it would for an end user, searching the
+// web where the API supports it. Implementations record their usage on the
+// meter of ctx.
+type Engine interface {
+	Name() string
+	Model() string
+	Ask(ctx context.Context, query string) (Answer, error)
+}
+
+// FromEnv returns the engines whose API key is set. <ENGINE>_BASE_URL
+// overrides an endpoint, e.g. to point at a fake from enginetest, and
+// <ENGINE>_MODEL the model (OPENAI_MODEL_ENGINE for OpenAI).
+func FromEnv() map[string]Engine {
+	out := map[string]Engine{}
+	if key := os.Getenv("OPENAI_API_KEY"); key != "" {
+		e := NewOpenAI(key, llm.ConfiguredModel(llm.CallEngine))
+		if base := os.Getenv("OPENAI_BASE_URL"); base != "" {
+			e.BaseURL = base
+		}
+		out[OpenAI] = e
+	}
+	if key := os.Getenv("ANTHROPIC_API_KEY"); key != "" {
+		e := NewAnthropic(key, os.Getenv("ANTHROPIC_MODEL"))
+		if base := os.Getenv("ANTHROPIC_BASE_URL"); base != "" {
+			e.BaseURL = base
+		}
+		out[Anthropic] = e
+	}
+	if key := os.Getenv("GEMINI_API_KEY"); key != "" {
+		e := NewGemini(key, os.Getenv("GEMINI_MODEL"))
+		if base := os.Getenv("GEMINI_BASE_URL"); base != "" {
+			e.BaseURL = base
+		}
+		out[Gemini] = e
+	}
+	if key := os.Getenv("PERPLEXITY_API_KEY"); key != "" {
+		e := NewPerplexity(key, os.Getenv("PERPLEXITY_MODEL"))
+		if base := os.Getenv("PERPLEXITY_BASE_URL"); base != "" {
+			e.BaseURL = base
+		}
+		out[Perplexity] = e
+	}
+	return out
+}
+
+// Query asks e, serving repeated questions from the llm cache.
+func Query(ctx context.Context, e Engine, query string) (Answer, error) {
+	request := map[string]string{"engine": e.Name(), "model": e.Model(), "query": query}
+	raw, err := llm.Cached(ctx, llm.CallEngine, e.Model(), request, func(ctx context.Context) (string, error) {
+		a, err := e.Ask(ctx, query)
+		if err != nil {
+			return "", err
+		}
+		b, err := json.Marshal(a)
+		return string(b), err
+	})
+	if err != nil {
+		return Answer{}, err
+	}
+	var a Answer
+	if err := json.Unmarshal([]byte(raw), &a); err != nil {
+		return Answer{}, err
+	}
+	return a, nil
+}
+
+// callers keeps one breaker per engine so an outage of one does not pause
+// the others. OpenAI shares llm.OpenAI with the rest of the service.
+var callers = map[string]*llm.Caller{
+	OpenAI:     llm.OpenAI,
+	Anthropic:  newCaller(Anthropic),
+	Gemini:     newCaller(Gemini),
+	Perplexity: newCaller(Perplexity),
+}
+
+func newCaller(name string) *llm.Caller {
+	return &llm.Caller{
+		MaxAttempts: llm.OpenAI.MaxAttempts,
+		BaseDelay:   time.Second,
+		MaxDelay:    30 * time.Second,
+		Breaker:     llm.NewBreaker(name, 5, 30*time.Second),
+	}
+}
+
+// postJSON sends body to url with retries and returns the response body.
+// Non-2xx answers become *llm.StatusError so they are classified like
+// OpenAI failures.
+func postJSON(ctx context.Context, engine string, client *http.Client, url string, header http.Header, body any) ([]byte, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var respBody []byte
+	err = callers[engine].Do(ctx, engine, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header = header.Clone()
+		req.Header.Set("Content-Type", "application/json")
+
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+
+		respBody, err = io.ReadAll(res.Body)
+		if err != nil {
+			return err
+		}
+		if res.StatusCode >= 300 {
+			return &llm.StatusError{Status: res.StatusCode, Header: res.Header, Body: string(respBody)}
+		}
+		return nil
+	})
+	return respBody, err
+}
+
+// uniqueURLs drops empty and repeated URLs, keeping the first occurrence.
+func uniqueURLs(urls []string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, u := range urls {
+		u = strings.TrimSpace(u)
+		if u == "" || seen[u] {
+			continue
+		}
+		seen[u] = true
+		out = append(out, u)
+	}
+	return out
+}
+
+// Available returns the names of the configured engines, sorted.
+func Available(engines map[string]Engine) []string {
+	names := make([]string, 0, len(engines))
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,129 @@
+package engines_test
+
+import (
+	"context"
+	"founders-toolkit-api/internal/engines"
+	"founders-toolkit-api/internal/engines/enginetest"
+	"founders-toolkit-api/internal/llm"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestEnginesAgainstFake(t *testing.T) {
+	srv := enginetest.NewServer()
+	defer srv.Close()
+	clients := srv.Engines()
+
+	tests := []struct {
+		engine    string
+		reply     enginetest.Canned
+		citations []string
+	}{
+		{
+			engine: engines.OpenAI,
+			reply: enginetest.Canned{
+				Text:      "Acme and Rival are the usual picks.",
+				Citations: []string{"https://acme.io/pricing", "https://rival.com", "https://acme.io/pricing"},
+			},
+			citations: []string{"https://acme.io/pricing", "https://rival.com"},
+		},
+		{
+			engine: engines.Anthropic,
+			reply: enginetest.Canned{
+				Text:      "Acme is the top pick.",
+				Citations: []string{"https://g2.com/acme", " ", "https://g2.com/acme"},
+			},
+			citations: []string{"https://g2.com/acme"},
+		},
+		{
+			engine: engines.Gemini,
+			reply: enginetest.Canned{
+				Text:      "Rival leads the market.",
+				Citations: []string{"https://rival.com/blog", "https://www.acme.io/"},
+			},
+			citations: []string{"https://rival.com/blog", "https://www.acme.io/"},
+		},
+		{
+			engine:    engines.Perplexity,
+			reply:     enginetest.Canned{Text: "No clear winner."},
+			citations: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.engine, func(t *testing.T) {
+			query := "best crm for " + tt.engine
+			srv.SetAnswer(tt.engine, query, tt.reply)
+
+			e := clients[tt.engine]
+			if e.Name() != tt.engine {
+				t.Errorf("Name() = %q, want %q", e.Name(), tt.engine)
+			}
+			if e.Model() == "" {
+				t.Error("Model() is empty")
+			}
+
+			got, err := engines.Query(context.Background(), e, query)
+			if err != nil {
+				t.Fatalf("Query: %v", err)
+			}
+			want := engines.Answer{Engine: tt.engine, Model: e.Model(), Text: tt.reply.Text, Citations: tt.citations}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Query() = %+v, want %+v", got, want)
+			}
+			if queries := srv.Queries(tt.engine); !reflect.DeepEqual(queries, []string{query}) {
+				t.Errorf("fake received %q, want %q", queries, []string{query})
+			}
+		})
+	}
+}
+
+func TestEngineUsageIsPriced(t *testing.T) {
+	srv := enginetest.NewServer()
+	defer srv.Close()
+
+	for name, e := range srv.Engines() {
+		t.Run(name, func(t *testing.T) {
+			srv.SetAnswer(name, "best crm", enginetest.Canned{Text: "Acme."})
+			meter := llm.NewMeter()
+			ctx := llm.WithMeter(context.Background(), meter)
+
+			if _, err := e.Ask(ctx, "best crm"); err != nil {
+				t.Fatalf("Ask: %v", err)
+			}
+			s := meter.Summary()
+			if s.Calls != 1 || s.InputTokens == 0 || s.OutputTokens == 0 {
+				t.Fatalf("usage = %+v, want one call with tokens", s)
+			}
+			// the fake answers with tokens on every engine, so a cost of
+			// only the search fee means the model has no price
+			if tokens := s.CostUSD - float64(s.WebSearchCalls)*llm.WebSearchCallPrice; tokens <= 0 {
+				t.Errorf("token cost of %s = %v, want above 0", e.Model(), tokens)
+			}
+		})
+	}
+}
+
+func TestEngineFailureIsClassified(t *testing.T) {
+	srv := enginetest.NewServer()
+	defer srv.Close()
+
+	for name, e := range srv.Engines() {
+		t.Run(name, func(t *testing.T) {
+			srv.SetStatus(name, http.StatusBadRequest)
+
+			_, err := e.Ask(context.Background(), "best crm")
+			if err == nil {
+				t.Fatal("Ask() succeeded, want an error")
+			}
+			if class := llm.ClassOf(err); class != llm.ClassInvalidRequest {
+				t.Errorf("class = %q, want %q (err: %v)", class, llm.ClassInvalidRequest, err)
+			}
+			// invalid requests are not retried
+			if n := len(srv.Queries(name)); n != 1 {
+				t.Errorf("fake received %d requests, want 1", n)
+			}
+		})
+	}
+}
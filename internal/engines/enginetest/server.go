@@ -0,0 +1,221 @@
+// Package enginetest provides a local stand-in for the answer engine APIs
+// so multi-engine code paths can be exercised without network access or
+// API keys. One server speaks every engine's wire format.
+package enginetest
+
+import (
+	"encoding/json"
+	"founders-toolkit-api/internal/engines"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+const APIKey = "test-engine-key"
+
+// Canned is the reply of an engine to one query.
+type Canned struct {
+	Text      string
+	Citations []string
+}
+
+// Server fakes OpenAI's POST /v1/responses, Anthropic's POST /v1/messages,
+// Gemini's POST /v1beta/models/{model}:generateContent and Perplexity's
+// POST /chat/completions. Queries without a canned reply get an empty
+// answer; engines set with SetStatus fail with that status instead.
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	answers map[string]map[string]Canned // engine -> query -> reply
+	status  map[string]int
+	queries map[string][]string
+}
+
+func NewServer() *Server {
+	s := &Server{
+		answers: map[string]map[string]Canned{},
+		status:  map[string]int{},
+		queries: map[string][]string{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Engines returns a client of every engine pointed at the fake.
+func (s *Server) Engines() map[string]engines.Engine {
+	oa := engines.NewOpenAI(APIKey, "gpt-4.1-mini")
+	oa.BaseURL, oa.Client = s.URL+"/v1", s.Client()
+	an := engines.NewAnthropic(APIKey, "")
+	an.BaseURL, an.Client = s.URL, s.Client()
+	ge := engines.NewGemini(APIKey, "")
+	ge.BaseURL, ge.Client = s.URL, s.Client()
+	px := engines.NewPerplexity(APIKey, "")
+	px.BaseURL, px.Client = s.URL, s.Client()
+	return map[string]engines.Engine{
+		engines.OpenAI:     oa,
+		engines.Anthropic:  an,
+		engines.Gemini:     ge,
+		engines.Perplexity: px,
+	}
+}
+
+func (s *Server) SetAnswer(engine, query string, reply Canned) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.answers[engine] == nil {
+		s.answers[engine] = map[string]Canned{}
+	}
+	s.answers[engine][query] = reply
+}
+
+// SetStatus makes every request to engine fail with status; 0 restores it.
+func (s *Server) SetStatus(engine string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status[engine] = status
+}
+
+// Queries returns every query engine received so far, in order.
+func (s *Server) Queries(engine string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.queries[engine]...)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+
+	var engine string
+	switch {
+	case r.URL.Path == "/v1/responses":
+		engine = engines.OpenAI
+	case r.URL.Path == "/v1/messages":
+		engine = engines.Anthropic
+	case strings.HasPrefix(r.URL.Path, "/v1beta/models/") && strings.HasSuffix(r.URL.Path, ":generateContent"):
+		engine = engines.Gemini
+	case r.URL.Path == "/chat/completions":
+		engine = engines.Perplexity
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if !authorized(engine, r) {
+		http.Error(w, `{"error":{"message":"invalid api key"}}`, http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Input    string `json:"input"` // openai
+		Messages []struct {
+			Content string `json:"content"`
+		} `json:"messages"` // anthropic, perplexity
+		Contents []struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"contents"` // gemini
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":{"message":"bad request"}}`, http.StatusBadRequest)
+		return
+	}
+	query := req.Input
+	if len(req.Messages) > 0 {
+		query = req.Messages[0].Content
+	}
+	if len(req.Contents) > 0 && len(req.Contents[0].Parts) > 0 {
+		query = req.Contents[0].Parts[0].Text
+	}
+
+	s.mu.Lock()
+	s.queries[engine] = append(s.queries[engine], query)
+	reply := s.answers[engine][query]
+	status := s.status[engine]
+	s.mu.Unlock()
+
+	if status != 0 {
+		http.Error(w, `{"error":{"message":"fake failure"}}`, status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(encode(engine, reply))
+}
+
+func authorized(engine string, r *http.Request) bool {
+	switch engine {
+	case engines.Anthropic:
+		return r.Header.Get("x-api-key") == APIKey
+	case engines.Gemini:
+		return r.Header.Get("x-goog-api-key") == APIKey
+	default:
+		return r.Header.Get("Authorization") == "Bearer "+APIKey
+	}
+}
+
+// encode renders reply in the response format of engine.
+func encode(engine string, reply Canned) any {
+	citations := reply.Citations
+	if citations == nil {
+		citations = []string{}
+	}
+
+	switch engine {
+	case engines.OpenAI:
+		annotations := []map[string]any{}
+		for _, u := range citations {
+			annotations = append(annotations, map[string]any{"type": "url_citation", "url": u})
+		}
+		return map[string]any{
+			"output": []map[string]any{
+				{"type": "web_search_call", "status": "completed"},
+				{"type": "message", "content": []map[string]any{
+					{"type": "output_text", "text": reply.Text, "annotations": annotations},
+				}},
+			},
+			"usage": map[string]any{"input_tokens": 100, "output_tokens": 50},
+		}
+
+	case engines.Anthropic:
+		cites := []map[string]any{}
+		for _, u := range citations {
+			cites = append(cites, map[string]any{"type": "web_search_result_location", "url": u})
+		}
+		return map[string]any{
+			"content": []map[string]any{
+				{"type": "text", "text": reply.Text, "citations": cites},
+			},
+			"usage": map[string]any{
+				"input_tokens": 100, "output_tokens": 50,
+				"server_tool_use": map[string]any{"web_search_requests": 1},
+			},
+		}
+
+	case engines.Gemini:
+		chunks := []map[string]any{}
+		for _, u := range citations {
+			chunks = append(chunks, map[string]any{"web": map[string]any{"uri": u}})
+		}
+		return map[string]any{
+			"candidates": []map[string]any{{
+				"content": map[string]any{"parts": []map[string]any{{"text": reply.Text}}},
+				"groundingMetadata": map[string]any{
+					"webSearchQueries": []string{"search"},
+					"groundingChunks":  chunks,
+				},
+			}},
+			"usageMetadata": map[string]any{"promptTokenCount": 100, "candidatesTokenCount": 50},
+		}
+
+	default:
+		return map[string]any{
+			"choices":   []map[string]any{{"message": map[string]any{"role": "assistant", "content": reply.Text}}},
+			"citations": citations,
+			"usage":     map[string]any{"prompt_tokens": 100, "completion_tokens": 50},
+		}
+	}
+}
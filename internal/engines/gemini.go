@@ -0,0 +1,126 @@
+package engines
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"founders-toolkit-api/internal/llm"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultGeminiBaseURL = "https://generativelanguage.googleapis.com"
+	defaultGeminiModel   = "gemini-2.5-flash"
+	// grounding chunks point at redirect URLs on this host
+	geminiRedirectHost = "vertexaisearch.cloud.google.com"
+)
+
+// GeminiEngine asks generateContent with Google Search grounding.
+type GeminiEngine struct {
+	APIKey  string
+	BaseURL string
+	model   string
+	Client  *http.Client
+}
+
+func NewGemini(apiKey, model string) *GeminiEngine {
+	if model == "" {
+		model = defaultGeminiModel
+	}
+	return &GeminiEngine{
+		APIKey:  apiKey,
+		BaseURL: defaultGeminiBaseURL,
+		model:   model,
+		Client:  &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+func (e *GeminiEngine) Name() string  { return Gemini }
+func (e *GeminiEngine) Model() string { return e.model }
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"content"`
+		GroundingMetadata struct {
+			WebSearchQueries []string `json:"webSearchQueries"`
+			GroundingChunks  []struct {
+				Web struct {
+					URI   string `json:"uri"`
+					Title string `json:"title"`
+				} `json:"web"`
+			} `json:"groundingChunks"`
+		} `json:"groundingMetadata"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount        int64 `json:"promptTokenCount"`
+		CandidatesTokenCount    int64 `json:"candidatesTokenCount"`
+		CachedContentTokenCount int64 `json:"cachedContentTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+func (e *GeminiEngine) Ask(ctx context.Context, query string) (Answer, error) {
+	header := http.Header{}
+	header.Set("x-goog-api-key", e.APIKey)
+
+	endpoint := strings.TrimRight(e.BaseURL, "/") + "/v1beta/models/" + url.PathEscape(e.model) + ":generateContent"
+	body, err := postJSON(ctx, Gemini, e.Client, endpoint, header, map[string]any{
+		"contents": []map[string]any{{
+			"role":  "user",
+			"parts": []map[string]string{{"text": query}},
+		}},
+		"tools": []map[string]any{{"google_search": map[string]any{}}},
+	})
+	if err != nil {
+		return Answer{}, err
+	}
+
+	var parsed geminiResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return Answer{}, fmt.Errorf("decode gemini response: %w", err)
+	}
+
+	answer := Answer{Engine: Gemini, Model: e.model, Citations: []string{}}
+	searches := 0
+	if len(parsed.Candidates) > 0 {
+		cand := parsed.Candidates[0]
+		var text strings.Builder
+		for _, p := range cand.Content.Parts {
+			text.WriteString(p.Text)
+		}
+		answer.Text = strings.TrimSpace(text.String())
+
+		var citations []string
+		for _, ch := range cand.GroundingMetadata.GroundingChunks {
+			citations = append(citations, geminiSource(ch.Web.URI, ch.Web.Title))
+		}
+		answer.Citations = uniqueURLs(citations)
+		searches = len(cand.GroundingMetadata.WebSearchQueries)
+	}
+	llm.Record(ctx, llm.CallEngine, e.model, llm.Usage{
+		InputTokens:       parsed.UsageMetadata.PromptTokenCount,
+		CachedInputTokens: parsed.UsageMetadata.CachedContentTokenCount,
+		OutputTokens:      parsed.UsageMetadata.CandidatesTokenCount,
+		WebSearchCalls:    searches,
+	})
+	return answer, nil
+}
+
+// geminiSource is the cited page, or its domain when the chunk only has a
+// grounding redirect URL; Gemini puts the source domain in the title then.
+func geminiSource(uri, title string) string {
+	u, err := url.Parse(uri)
+	if err == nil && u.Hostname() != geminiRedirectHost {
+		return uri
+	}
+	if title != "" && strings.Contains(title, ".") && !strings.Contains(title, " ") {
+		return "https://" + title
+	}
+	return uri
+}
@@ -0,0 +1,88 @@
+package engines
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"founders-toolkit-api/internal/llm"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAIEngine asks the Responses API with the web_search tool.
+type OpenAIEngine struct {
+	APIKey  string
+	BaseURL string // including /v1, like the SDK's OPENAI_BASE_URL
+	model   string
+	Client  *http.Client
+}
+
+func NewOpenAI(apiKey, model string) *OpenAIEngine {
+	return &OpenAIEngine{
+		APIKey:  apiKey,
+		BaseURL: defaultOpenAIBaseURL,
+		model:   model,
+		Client:  &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+func (e *OpenAIEngine) Name() string  { return OpenAI }
+func (e *OpenAIEngine) Model() string { return e.model }
+
+type openAIResponse struct {
+	Output []struct {
+		Type    string `json:"type"`
+		Content []struct {
+			Type        string `json:"type"`
+			Text        string `json:"text"`
+			Annotations []struct {
+				Type string `json:"type"`
+				URL  string `json:"url"`
+			} `json:"annotations"`
+		} `json:"content"`
+	} `json:"output"`
+}
+
+func (e *OpenAIEngine) Ask(ctx context.Context, query string) (Answer, error) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+e.APIKey)
+
+	body, err := postJSON(ctx, OpenAI, e.Client, strings.TrimRight(e.BaseURL, "/")+"/responses", header, map[string]any{
+		"model":       e.model,
+		"input":       query,
+		"tools":       []map[string]any{{"type": "web_search"}},
+		"tool_choice": "auto",
+	})
+	if err != nil {
+		return Answer{}, err
+	}
+
+	var parsed openAIResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return Answer{}, fmt.Errorf("decode openai response: %w", err)
+	}
+	llm.Record(ctx, llm.CallEngine, e.model, llm.UsageFromJSON(body))
+
+	var text strings.Builder
+	var citations []string
+	for _, item := range parsed.Output {
+		if item.Type != "message" {
+			continue
+		}
+		for _, c := range item.Content {
+			if c.Type != "output_text" {
+				continue
+			}
+			text.WriteString(c.Text)
+			for _, a := range c.Annotations {
+				if a.Type == "url_citation" {
+					citations = append(citations, a.URL)
+				}
+			}
+		}
+	}
+	return Answer{Engine: OpenAI, Model: e.model, Text: strings.TrimSpace(text.String()), Citations: uniqueURLs(citations)}, nil
+}
@@ -0,0 +1,94 @@
+package engines
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"founders-toolkit-api/internal/llm"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPerplexityBaseURL = "https://api.perplexity.ai"
+	defaultPerplexityModel   = "sonar"
+)
+
+// PerplexityEngine asks the chat completions API of Perplexity-style
+// engines, which search on every request and return citations alongside
+// the message.
+type PerplexityEngine struct {
+	APIKey  string
+	BaseURL string
+	model   string
+	Client  *http.Client
+}
+
+func NewPerplexity(apiKey, model string) *PerplexityEngine {
+	if model == "" {
+		model = defaultPerplexityModel
+	}
+	return &PerplexityEngine{
+		APIKey:  apiKey,
+		BaseURL: defaultPerplexityBaseURL,
+		model:   model,
+		Client:  &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+func (e *PerplexityEngine) Name() string  { return Perplexity }
+func (e *PerplexityEngine) Model() string { return e.model }
+
+type perplexityResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Citations     []string `json:"citations"`
+	SearchResults []struct {
+		URL string `json:"url"`
+	} `json:"search_results"`
+	Usage struct {
+		PromptTokens     int64 `json:"prompt_tokens"`
+		CompletionTokens int64 `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+func (e *PerplexityEngine) Ask(ctx context.Context, query string) (Answer, error) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+e.APIKey)
+
+	body, err := postJSON(ctx, Perplexity, e.Client, strings.TrimRight(e.BaseURL, "/")+"/chat/completions", header, map[string]any{
+		"model":    e.model,
+		"messages": []map[string]string{{"role": "user", "content": query}},
+	})
+	if err != nil {
+		return Answer{}, err
+	}
+
+	var parsed perplexityResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return Answer{}, fmt.Errorf("decode perplexity response: %w", err)
+	}
+	llm.Record(ctx, llm.CallEngine, e.model, llm.Usage{
+		InputTokens:    parsed.Usage.PromptTokens,
+		OutputTokens:   parsed.Usage.CompletionTokens,
+		WebSearchCalls: 1,
+	})
+
+	answer := Answer{Engine: Perplexity, Model: e.model}
+	if len(parsed.Choices) > 0 {
+		answer.Text = strings.TrimSpace(parsed.Choices[0].Message.Content)
+	}
+	citations := parsed.Citations
+	// newer responses carry search_results and may drop citations
+	if len(citations) == 0 {
+		for _, r := range parsed.SearchResults {
+			citations = append(citations, r.URL)
+		}
+	}
+	answer.Citations = uniqueURLs(citations)
+	return answer, nil
+}
@@ -0,0 +1,116 @@
+package jobs
+
+import (
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/models"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+const keepAlive = 15 * time.Second
+
+// GET /jobs/:id
+func GetJob(c *gin.Context) {
+	job, ok := ownedJob(c)
+	if !ok {
+		return
+	}
+	response.Respond(c, http.StatusOK, "Job loaded", job.Snapshot())
+}
+
+// DELETE /jobs/:id  cancels a running job. The job keeps running until the
+// workflow notices; follow /jobs/:id/events for the final "done" event.
+func CancelJob(c *gin.Context) {
+	job, ok := ownedJob(c)
+	if !ok {
+		return
+	}
+	if !job.Cancel() {
+		response.Respond(c, http.StatusConflict, "job is not running", job.Snapshot())
+		return
+	}
+	response.Respond(c, http.StatusAccepted, "Cancellation requested", job.Snapshot())
+}
+
+// GET /jobs/:id/events  Server-Sent Events stream of the job's progress.
+// Past events are replayed first, skipping those up to Last-Event-ID; the
+// stream ends after the "done" event.
+func StreamEvents(c *gin.Context) {
+	job, ok := ownedJob(c)
+	if !ok {
+		return
+	}
+
+	// the stream outlives the server's WriteTimeout; keep-alives and the
+	// request context end it instead
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("[jobs.StreamEvents] clear write deadline: %v", err)
+	}
+
+	lastSeq, _ := strconv.Atoi(c.GetHeader("Last-Event-ID"))
+	past, live, unsubscribe := job.Subscribe()
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	for _, ev := range past {
+		if ev.Seq > lastSeq {
+			render(c, ev)
+		}
+	}
+	c.Writer.Flush()
+	if live == nil {
+		return
+	}
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case ev, ok := <-live:
+			if !ok {
+				return false
+			}
+			render(c, ev)
+			return ev.Type != EventDone
+		case <-ticker.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+func render(c *gin.Context, ev Event) {
+	c.Render(-1, sse.Event{
+		Id:    strconv.Itoa(ev.Seq),
+		Event: ev.Type,
+		Data:  ev,
+	})
+}
+
+func ownedJob(c *gin.Context) (*Job, bool) {
+	uRaw, _ := c.Get("user")
+	user, _ := uRaw.(models.User)
+	if user.ID == 0 {
+		response.Respond(c, http.StatusUnauthorized, "unauthorized", nil)
+		return nil, false
+	}
+
+	job, ok := Default.Get(c.Param("id"))
+	if !ok || job.UserID != user.ID {
+		response.Respond(c, http.StatusNotFound, "job not found", nil)
+		return nil, false
+	}
+	return job, true
+}
@@ -0,0 +1,238 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+const (
+	// EventDone is the last event of every job; its data is the Snapshot.
+	EventDone = "done"
+	// EventCancelRequested is published when a cancel is asked for; the job
+	// finishes once the workflow has stopped and saved what it had.
+	EventCancelRequested = "cancel_requested"
+
+	subscriberBuffer = 64
+	retention        = time.Hour
+)
+
+// Event is one progress update of a job. Seq starts at 1 and doubles as the
+// SSE event id.
+type Event struct {
+	Seq  int       `json:"seq"`
+	Type string    `json:"type"`
+	Data any       `json:"data,omitempty"`
+	At   time.Time `json:"at"`
+}
+
+// Snapshot is the externally visible state of a job.
+type Snapshot struct {
+	ID         string     `json:"id"`
+	Kind       string     `json:"kind"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	Result     any        `json:"result,omitempty"`
+	Events     int        `json:"events"`
+	Cancelling bool       `json:"cancelling,omitempty"`
+}
+
+// Job is a long running workflow whose progress can be followed while it
+// runs and for a while after it finished.
+type Job struct {
+	ID        string
+	UserID    int64
+	Kind      string
+	StartedAt time.Time
+
+	mu         sync.Mutex
+	status     string
+	finishedAt *time.Time
+	errMsg     string
+	result     any
+	events     []Event
+	subs       map[chan Event]struct{}
+	cancel     context.CancelFunc
+	cancelling bool
+}
+
+// SetCancel registers the cancel func of the context the job runs under.
+func (j *Job) SetCancel(cancel context.CancelFunc) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.cancel = cancel
+}
+
+// Cancel cancels the job's context. It reports false when the job already
+// finished or cannot be cancelled.
+func (j *Job) Cancel() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.finishedAt != nil || j.cancel == nil {
+		return false
+	}
+	if !j.cancelling {
+		j.cancelling = true
+		j.publishLocked(EventCancelRequested, nil)
+	}
+	j.cancel()
+	return true
+}
+
+// Cancelled reports whether Cancel was called, as opposed to the context
+// ending for another reason such as a timeout.
+func (j *Job) Cancelled() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.cancelling
+}
+
+// Publish appends an event and fans it out to subscribers. Subscribers that
+// fall behind are dropped; they can reconnect and replay.
+func (j *Job) Publish(typ string, data any) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.finishedAt != nil {
+		return
+	}
+	j.publishLocked(typ, data)
+}
+
+func (j *Job) publishLocked(typ string, data any) {
+	ev := Event{Seq: len(j.events) + 1, Type: typ, Data: data, At: time.Now().UTC()}
+	j.events = append(j.events, ev)
+	for ch := range j.subs {
+		select {
+		case ch <- ev:
+		default:
+			delete(j.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Finish records the outcome, emits EventDone and closes all subscriptions.
+func (j *Job) Finish(status string, result any, errMsg string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.finishedAt != nil {
+		return
+	}
+
+	j.status = status
+	j.result = result
+	j.errMsg = errMsg
+	now := time.Now().UTC()
+	j.finishedAt = &now
+	j.publishLocked(EventDone, j.snapshotLocked())
+
+	for ch := range j.subs {
+		close(ch)
+	}
+	j.subs = nil
+}
+
+// Subscribe returns the events so far and, while the job runs, a channel of
+// the ones to come. The channel is nil for finished jobs.
+func (j *Job) Subscribe() ([]Event, <-chan Event, func()) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	past := append([]Event(nil), j.events...)
+	if j.finishedAt != nil {
+		return past, nil, func() {}
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	j.subs[ch] = struct{}{}
+	return past, ch, func() {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		if _, ok := j.subs[ch]; ok {
+			delete(j.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (j *Job) Snapshot() Snapshot {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.snapshotLocked()
+}
+
+func (j *Job) snapshotLocked() Snapshot {
+	return Snapshot{
+		ID:         j.ID,
+		Kind:       j.Kind,
+		Status:     j.status,
+		StartedAt:  j.StartedAt,
+		FinishedAt: j.finishedAt,
+		Error:      j.errMsg,
+		Result:     j.result,
+		Events:     len(j.events),
+		Cancelling: j.cancelling && j.finishedAt == nil,
+	}
+}
+
+// Registry keeps jobs of this process in memory. Finished jobs are dropped
+// after an hour.
+type Registry struct {
+	mu   sync.RWMutex
+	jobs map[string]*Job
+}
+
+func NewRegistry() *Registry {
+	return &Registry{jobs: map[string]*Job{}}
+}
+
+var Default = NewRegistry()
+
+func (r *Registry) Start(userID int64, kind string) *Job {
+	j := &Job{
+		ID:        newID(),
+		UserID:    userID,
+		Kind:      kind,
+		StartedAt: time.Now().UTC(),
+		status:    StatusRunning,
+		subs:      map[chan Event]struct{}{},
+	}
+	r.mu.Lock()
+	r.jobs[j.ID] = j
+	r.mu.Unlock()
+	return j
+}
+
+func (r *Registry) Get(id string) (*Job, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	j, ok := r.jobs[id]
+	return j, ok
+}
+
+// Finish finishes the job and schedules its removal.
+func (r *Registry) Finish(j *Job, status string, result any, errMsg string) {
+	j.Finish(status, result, errMsg)
+	time.AfterFunc(retention, func() {
+		r.mu.Lock()
+		delete(r.jobs, j.ID)
+		r.mu.Unlock()
+	})
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
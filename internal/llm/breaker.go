@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Breaker is a consecutive-failure circuit breaker. After Threshold
+// retryable failures in a row it opens and rejects calls for Cooldown, then
+// lets a single probe through; the probe's outcome closes or reopens it.
+type Breaker struct {
+	Name      string
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time // zero while closed
+	probing  bool
+}
+
+func NewBreaker(name string, threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{Name: name, Threshold: threshold, Cooldown: cooldown}
+}
+
+// Allow reports whether a call may proceed, returning a ClassCircuitOpen
+// error when it may not.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openedAt.IsZero() {
+		return nil
+	}
+	wait := b.Cooldown - time.Since(b.openedAt)
+	if wait > 0 || b.probing {
+		if wait < 0 {
+			wait = 0
+		}
+		return &Error{
+			Class:      ClassCircuitOpen,
+			RetryAfter: wait,
+			Err:        errors.New(b.Name + " is failing, calls are paused"),
+		}
+	}
+	b.probing = true
+	return nil
+}
+
+// Record feeds the outcome of an allowed call. Cancelled calls say nothing
+// about upstream health and are ignored; permanent errors mean the upstream
+// answered and count as success.
+func (b *Breaker) Record(err *Error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err != nil && (err.Class == ClassCancelled || err.Class == ClassTimeout) {
+		b.probing = false
+		return
+	}
+	if err == nil || !err.Retryable {
+		if !b.openedAt.IsZero() {
+			log.Printf("[llm] %s circuit closed", b.Name)
+		}
+		b.failures, b.openedAt, b.probing = 0, time.Time{}, false
+		return
+	}
+
+	b.failures++
+	if b.probing || (b.openedAt.IsZero() && b.failures >= b.Threshold) {
+		log.Printf("[llm] %s circuit open for %s after %d failures: %v", b.Name, b.Cooldown, b.failures, err)
+		b.openedAt = time.Now()
+	}
+	b.probing = false
+}
+
+// State is "closed", "open" or "half_open", for status endpoints and logs.
+func (b *Breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.openedAt.IsZero():
+		return "closed"
+	case time.Since(b.openedAt) < b.Cooldown:
+		return "open"
+	}
+	return "half_open"
+}
@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	errRetryable = &Error{Class: ClassUnavailable, Retryable: true, Err: errors.New("503")}
+	errPermanent = &Error{Class: ClassInvalidRequest, Err: errors.New("400")}
+	errCancelled = &Error{Class: ClassCancelled, Err: errors.New("cancelled")}
+)
+
+// expire moves the breaker to the end of its cooldown.
+func expire(b *Breaker) {
+	b.mu.Lock()
+	b.openedAt = time.Now().Add(-b.Cooldown)
+	b.mu.Unlock()
+}
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	b := NewBreaker("test", 3, time.Minute)
+
+	b.Record(errRetryable)
+	b.Record(errRetryable)
+	// cancellations neither reset nor add to the count
+	b.Record(errCancelled)
+	if b.State() != "closed" || b.Allow() != nil {
+		t.Fatalf("state after 2 failures = %s, want closed", b.State())
+	}
+	b.Record(errRetryable)
+	if b.State() != "open" {
+		t.Fatalf("state after 3 failures = %s, want open", b.State())
+	}
+
+	err := b.Allow()
+	if ClassOf(err) != ClassCircuitOpen {
+		t.Fatalf("Allow() while open = %v, want %s", err, ClassCircuitOpen)
+	}
+	if e := err.(*Error); e.RetryAfter <= 0 || e.RetryAfter > time.Minute {
+		t.Errorf("RetryAfter = %s, want the rest of the cooldown", e.RetryAfter)
+	}
+}
+
+func TestBreakerSuccessResetsFailures(t *testing.T) {
+	for _, outcome := range []*Error{nil, errPermanent} {
+		b := NewBreaker("test", 2, time.Minute)
+		b.Record(errRetryable)
+		b.Record(outcome)
+		b.Record(errRetryable)
+		if b.State() != "closed" {
+			t.Errorf("failure, %v, failure: state = %s, want closed", outcome, b.State())
+		}
+	}
+}
+
+func TestBreakerHalfOpenProbe(t *testing.T) {
+	b := NewBreaker("test", 1, time.Minute)
+	b.Record(errRetryable)
+	expire(b)
+
+	if b.State() != "half_open" {
+		t.Fatalf("state after cooldown = %s, want half_open", b.State())
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe rejected: %v", err)
+	}
+	if err := b.Allow(); ClassOf(err) != ClassCircuitOpen {
+		t.Fatalf("second call during the probe = %v, want %s", err, ClassCircuitOpen)
+	}
+
+	// a failed probe reopens for a full cooldown
+	b.Record(errRetryable)
+	if b.State() != "open" {
+		t.Fatalf("state after failed probe = %s, want open", b.State())
+	}
+
+	// a cancelled probe frees the slot without deciding
+	expire(b)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe rejected: %v", err)
+	}
+	b.Record(errCancelled)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe after a cancelled one rejected: %v", err)
+	}
+
+	b.Record(nil)
+	if b.State() != "closed" {
+		t.Fatalf("state after successful probe = %s, want closed", b.State())
+	}
+	if err := b.Allow(); err != nil {
+		t.Errorf("Allow() once closed = %v", err)
+	}
+}
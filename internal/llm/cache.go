@@ -0,0 +1,234 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/models"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CallType names what a model call is for; each type has its own TTL.
+type CallType string
+
+const (
+	CallQueries     CallType = "queries"
+	CallResearch    CallType = "research"
+	CallBrands      CallType = "brands"
+	CallSuggestions CallType = "suggestions"
+	CallScan        CallType = "scan"
+	CallEngine      CallType = "engine"
+)
+
+// TTLs per call type. Web search results go stale quickly; extracting brands
+// from a given research text is a pure function of that text. Types missing
+// here are never cached.
+var TTLs = map[CallType]time.Duration{
+	CallQueries:     24 * time.Hour,
+	CallResearch:    time.Hour,
+	CallBrands:      30 * 24 * time.Hour,
+	CallSuggestions: time.Hour,
+	CallScan:        time.Hour,
+	CallEngine:      time.Hour,
+}
+
+// Cache stores model responses in llm_cache, keyed by the hash of the call
+// type and the full request (model, prompt, tools).
+type Cache struct {
+	db    *database.Service
+	mu    sync.Mutex
+	stats map[CallType]*counters
+}
+
+type counters struct {
+	hits, misses, bypassed atomic.Int64
+}
+
+// CacheStats are the lookups served by this process since it started.
+type CacheStats struct {
+	CallType CallType `json:"call_type"`
+	TTL      int64    `json:"ttl_seconds"`
+	Hits     int64    `json:"hits"`
+	Misses   int64    `json:"misses"`
+	Bypassed int64    `json:"bypassed"`
+	HitRate  float64  `json:"hit_rate"`
+}
+
+var defaultCache atomic.Pointer[Cache]
+
+// EnableCache makes Cached use db. Until it is called every call goes
+// straight to the model.
+func EnableCache(db *database.Service) *Cache {
+	c := &Cache{db: db, stats: map[CallType]*counters{}}
+	defaultCache.Store(c)
+	return c
+}
+
+// DefaultCache is the cache set by EnableCache, or nil.
+func DefaultCache() *Cache { return defaultCache.Load() }
+
+type bypassKey struct{}
+
+// WithoutCache marks ctx so lookups are skipped. Fresh responses are still
+// stored, which refreshes the entry for later callers.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	b, _ := ctx.Value(bypassKey{}).(bool)
+	return b
+}
+
+type saltKey struct{}
+
+// WithCacheSalt keeps the entries of calls on ctx apart from those of the
+// same request without salt, e.g. for independent samples of one query.
+func WithCacheSalt(ctx context.Context, salt string) context.Context {
+	return context.WithValue(ctx, saltKey{}, salt)
+}
+
+// Key is the cache key of request for callType.
+func Key(callType CallType, request any) (string, error) {
+	b, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(callType))
+	h.Write([]byte{0})
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cacheKey is Key with the salt of ctx, if any.
+func cacheKey(ctx context.Context, callType CallType, request any) (string, error) {
+	if salt, _ := ctx.Value(saltKey{}).(string); salt != "" {
+		request = []any{request, salt}
+	}
+	return Key(callType, request)
+}
+
+// Cached returns the stored response to request when there is a live one,
+// otherwise it calls fn and stores the result. Cache failures are logged and
+// fall through to fn.
+func Cached(ctx context.Context, callType CallType, model string, request any, fn func(ctx context.Context) (string, error)) (string, error) {
+	c := DefaultCache()
+	ttl := TTLs[callType]
+	if c == nil || ttl <= 0 {
+		return fn(ctx)
+	}
+	key, err := cacheKey(ctx, callType, request)
+	if err != nil {
+		log.Printf("[llm.Cached] key %s: %v", callType, err)
+		return fn(ctx)
+	}
+
+	n := c.counters(callType)
+	if cacheBypassed(ctx) {
+		n.bypassed.Add(1)
+	} else if out, ok := c.get(key); ok {
+		n.hits.Add(1)
+		recordCacheHit(ctx, callType, model)
+		return out, nil
+	} else {
+		n.misses.Add(1)
+	}
+
+	out, err := fn(ctx)
+	if err != nil {
+		return out, err
+	}
+	c.put(key, callType, model, out, ttl)
+	return out, nil
+}
+
+func (c *Cache) counters(callType CallType) *counters {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n, ok := c.stats[callType]
+	if !ok {
+		n = &counters{}
+		c.stats[callType] = n
+	}
+	return n
+}
+
+func (c *Cache) get(key string) (string, bool) {
+	var rows []models.LLMCacheEntry
+	if err := c.db.DB.Where("key = ? AND expires_at > ?", key, time.Now()).
+		Limit(1).Find(&rows).Error; err != nil {
+		log.Printf("[llm.Cache] get: %v", err)
+		return "", false
+	}
+	if len(rows) == 0 {
+		return "", false
+	}
+	c.db.DB.Model(&models.LLMCacheEntry{}).Where("key = ?", key).
+		UpdateColumn("hits", gorm.Expr("hits + 1"))
+	return rows[0].Response, true
+}
+
+func (c *Cache) put(key string, callType CallType, model, response string, ttl time.Duration) {
+	entry := models.LLMCacheEntry{
+		Key:       key,
+		CallType:  string(callType),
+		Model:     model,
+		Response:  response,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	err := c.db.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"response", "model", "created_at", "expires_at"}),
+	}).Create(&entry).Error
+	if err != nil {
+		log.Printf("[llm.Cache] put %s: %v", callType, err)
+	}
+}
+
+// Stats returns the counters of every call type with a TTL.
+func (c *Cache) Stats() []CacheStats {
+	out := make([]CacheStats, 0, len(TTLs))
+	for _, t := range []CallType{CallQueries, CallResearch, CallBrands, CallSuggestions, CallScan, CallEngine} {
+		n := c.counters(t)
+		s := CacheStats{
+			CallType: t,
+			TTL:      int64(TTLs[t] / time.Second),
+			Hits:     n.hits.Load(),
+			Misses:   n.misses.Load(),
+			Bypassed: n.bypassed.Load(),
+		}
+		if lookups := s.Hits + s.Misses; lookups > 0 {
+			s.HitRate = float64(s.Hits) / float64(lookups)
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// StartCacheJanitor deletes expired entries every interval until ctx is done.
+func StartCacheJanitor(ctx context.Context, db *database.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			res := db.DB.Where("expires_at <= ?", time.Now()).Delete(&models.LLMCacheEntry{})
+			if res.Error != nil {
+				log.Printf("[llm.Cache] purge expired: %v", res.Error)
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"founders-toolkit-api/internal/database"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestCacheKey(t *testing.T) {
+	request := map[string]any{"model": "gpt-4.1-mini", "input": "best crm"}
+	ctx := context.Background()
+	key := func(ctx context.Context, callType CallType, request any) string {
+		t.Helper()
+		k, err := cacheKey(ctx, callType, request)
+		if err != nil {
+			t.Fatalf("cacheKey: %v", err)
+		}
+		return k
+	}
+
+	base := key(ctx, CallResearch, request)
+	if again := key(ctx, CallResearch, map[string]any{"input": "best crm", "model": "gpt-4.1-mini"}); again != base {
+		t.Error("the same request should have the same key")
+	}
+	if key(ctx, CallBrands, request) == base {
+		t.Error("call types should not share keys")
+	}
+	if key(ctx, CallResearch, map[string]any{"model": "gpt-4.1-mini", "input": "best crm!"}) == base {
+		t.Error("different requests should not share keys")
+	}
+
+	salted := key(WithCacheSalt(ctx, "sample-1"), CallResearch, request)
+	if salted == base {
+		t.Error("a salted call should not share the key of the unsalted one")
+	}
+	if key(WithCacheSalt(ctx, "sample-2"), CallResearch, request) == salted {
+		t.Error("different salts should not share keys")
+	}
+	if key(WithCacheSalt(ctx, ""), CallResearch, request) != base {
+		t.Error("an empty salt should not change the key")
+	}
+
+	if _, err := Key(CallResearch, func() {}); err == nil {
+		t.Error("Key of an unencodable request should fail")
+	}
+}
+
+// dryRunCache is a cache whose database never finds an entry; stores are
+// counted instead of executed.
+func dryRunCache(t *testing.T) (*Cache, *int) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("open dry run db: %v", err)
+	}
+	puts := 0
+	db.Callback().Create().After("gorm:create").Register("test:count_puts", func(*gorm.DB) { puts++ })
+
+	prev := defaultCache.Load()
+	t.Cleanup(func() { defaultCache.Store(prev) })
+	return EnableCache(&database.Service{DB: db}), &puts
+}
+
+func TestCachedCounters(t *testing.T) {
+	c, puts := dryRunCache(t)
+	ctx := context.Background()
+
+	calls := 0
+	fn := func(context.Context) (string, error) {
+		calls++
+		return "answer", nil
+	}
+
+	if out, err := Cached(ctx, CallBrands, "gpt-4.1-mini", "request", fn); err != nil || out != "answer" {
+		t.Fatalf("Cached() = %q, %v", out, err)
+	}
+	Cached(WithoutCache(ctx), CallBrands, "gpt-4.1-mini", "request", fn)
+	// types without a TTL go straight to fn and are not counted
+	Cached(ctx, CallType("uncached"), "gpt-4.1-mini", "request", fn)
+	Cached(ctx, CallResearch, "gpt-4.1-mini", "request", func(context.Context) (string, error) {
+		calls++
+		return "", errors.New("upstream failed")
+	})
+
+	if calls != 4 {
+		t.Errorf("fn calls = %d, want 4", calls)
+	}
+	// misses and bypasses are stored, failures and uncached types are not
+	if *puts != 2 {
+		t.Errorf("stored %d responses, want 2", *puts)
+	}
+
+	stats := map[CallType]CacheStats{}
+	for _, s := range c.Stats() {
+		stats[s.CallType] = s
+	}
+	if _, ok := stats["uncached"]; ok {
+		t.Error("Stats lists a call type without a TTL")
+	}
+	if s := stats[CallBrands]; s.Hits != 0 || s.Misses != 1 || s.Bypassed != 1 || s.HitRate != 0 {
+		t.Errorf("brands stats = %+v, want one miss and one bypass", s)
+	}
+	if s := stats[CallResearch]; s.Misses != 1 {
+		t.Errorf("research stats = %+v, want one miss", s)
+	}
+}
+
+func TestCacheStatsHitRate(t *testing.T) {
+	c, _ := dryRunCache(t)
+	n := c.counters(CallQueries)
+	n.hits.Add(3)
+	n.misses.Add(1)
+	n.bypassed.Add(4) // bypasses are not lookups
+
+	for _, s := range c.Stats() {
+		switch s.CallType {
+		case CallQueries:
+			if s.HitRate != 0.75 || s.TTL != int64(TTLs[CallQueries].Seconds()) {
+				t.Errorf("queries stats = %+v, want a hit rate of 0.75", s)
+			}
+		default:
+			if s.HitRate != 0 {
+				t.Errorf("%s hit rate = %v without lookups, want 0", s.CallType, s.HitRate)
+			}
+		}
+	}
+}
+
+func TestCachedWithoutCache(t *testing.T) {
+	prev := defaultCache.Swap(nil)
+	defer defaultCache.Store(prev)
+
+	calls := 0
+	out, err := Cached(context.Background(), CallBrands, "gpt-4.1-mini", "request", func(context.Context) (string, error) {
+		calls++
+		return "answer", nil
+	})
+	if err != nil || out != "answer" || calls != 1 {
+		t.Errorf("Cached() without a cache = %q, %v after %d calls, want fn's answer", out, err, calls)
+	}
+}
@@ -0,0 +1,167 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// Error classes. Retryable classes also count against the circuit breaker.
+const (
+	ClassRateLimited    = "rate_limited"    // 429, retryable
+	ClassUnavailable    = "unavailable"     // 5xx, 408, 409, retryable
+	ClassNetwork        = "network"         // connection errors, retryable
+	ClassQuotaExceeded  = "quota_exceeded"  // 429 insufficient_quota
+	ClassAuth           = "auth"            // 401, 403
+	ClassInvalidRequest = "invalid_request" // other 4xx
+	ClassTimeout        = "timeout"         // caller deadline passed
+	ClassCancelled      = "cancelled"       // caller cancelled
+	ClassCircuitOpen    = "circuit_open"    // failed fast, breaker open
+	ClassUnknown        = "unknown"
+)
+
+// Error is a classified failure of an upstream model call.
+type Error struct {
+	Class      string
+	Status     int // HTTP status when the upstream answered
+	Retryable  bool
+	RetryAfter time.Duration // from Retry-After, zero when absent
+	Attempts   int
+	Err        error
+}
+
+func (e *Error) Error() string {
+	msg := e.Class
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	if e.Attempts > 1 {
+		msg += fmt.Sprintf(" (after %d attempts)", e.Attempts)
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// ClassOf returns the class of err, ClassUnknown when it was never classified.
+func ClassOf(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Class
+	}
+	return ClassUnknown
+}
+
+// StatusError is a non-2xx answer to a hand-rolled HTTP call.
+type StatusError struct {
+	Status int
+	Header http.Header
+	Body   string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%d %s | body=%s", e.Status, http.StatusText(e.Status), e.Body)
+}
+
+// Classify turns err into an *Error. Errors that already are one are
+// returned as is.
+func Classify(ctx context.Context, err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	switch {
+	case ctx.Err() == context.Canceled || errors.Is(err, context.Canceled):
+		return &Error{Class: ClassCancelled, Err: err}
+	case ctx.Err() == context.DeadlineExceeded:
+		return &Error{Class: ClassTimeout, Err: err}
+	}
+
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		var header http.Header
+		if apiErr.Response != nil {
+			header = apiErr.Response.Header
+		}
+		return fromStatus(apiErr.StatusCode, header, apiErr.Code, err)
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		code := ""
+		if strings.Contains(statusErr.Body, "insufficient_quota") {
+			code = "insufficient_quota"
+		}
+		return fromStatus(statusErr.Status, statusErr.Header, code, err)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return &Error{Class: ClassNetwork, Retryable: true, Err: err}
+	}
+	return &Error{Class: ClassUnknown, Err: err}
+}
+
+func fromStatus(status int, header http.Header, code string, err error) *Error {
+	e := &Error{Status: status, Err: err, RetryAfter: retryAfter(header)}
+	switch {
+	case status == http.StatusTooManyRequests && code == "insufficient_quota":
+		e.Class = ClassQuotaExceeded
+	case status == http.StatusTooManyRequests:
+		e.Class, e.Retryable = ClassRateLimited, true
+	case status >= 500, status == http.StatusRequestTimeout, status == http.StatusConflict:
+		e.Class, e.Retryable = ClassUnavailable, true
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		e.Class = ClassAuth
+	case status >= 400:
+		e.Class = ClassInvalidRequest
+	default:
+		e.Class = ClassUnknown
+	}
+	return e
+}
+
+// retryAfter reads retry-after-ms or Retry-After (seconds or HTTP date).
+func retryAfter(h http.Header) time.Duration {
+	if h == nil {
+		return 0
+	}
+	if ms, err := strconv.ParseFloat(h.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// HTTPStatus is the status to answer API clients with when err ended a
+// request: 503 while the breaker is open or OpenAI throttles us, 504 on a
+// timeout and 502 otherwise.
+func HTTPStatus(err error) int {
+	switch ClassOf(err) {
+	case ClassCircuitOpen, ClassRateLimited:
+		return http.StatusServiceUnavailable
+	case ClassTimeout:
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadGateway
+}
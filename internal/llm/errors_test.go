@@ -0,0 +1,67 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"absent", http.Header{}, 0},
+		{"nil", nil, 0},
+		{"milliseconds win", http.Header{"Retry-After-Ms": {"1500"}, "Retry-After": {"9"}}, 1500 * time.Millisecond},
+		{"seconds", http.Header{"Retry-After": {"2"}}, 2 * time.Second},
+		{"fractional seconds", http.Header{"Retry-After": {"0.5"}}, 500 * time.Millisecond},
+		{"date in the past", http.Header{"Retry-After": {"Mon, 02 Jan 2006 15:04:05 GMT"}}, 0},
+		{"garbage", http.Header{"Retry-After": {"soon"}}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := retryAfter(tt.header); got != tt.want {
+			t.Errorf("%s: retryAfter = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if got := retryAfter(http.Header{"Retry-After": {future}}); got < 59*time.Minute || got > time.Hour {
+		t.Errorf("retryAfter(date in an hour) = %s", got)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		err       error
+		class     string
+		retryable bool
+	}{
+		{"rate limited", context.Background(), &StatusError{Status: 429}, ClassRateLimited, true},
+		{"server error", context.Background(), &StatusError{Status: 502}, ClassUnavailable, true},
+		{"conflict", context.Background(), &StatusError{Status: 409}, ClassUnavailable, true},
+		{"forbidden", context.Background(), &StatusError{Status: 403}, ClassAuth, false},
+		{"not found", context.Background(), &StatusError{Status: 404}, ClassInvalidRequest, false},
+		{"connection dropped", context.Background(), io.ErrUnexpectedEOF, ClassNetwork, true},
+		{"caller cancelled", cancelled, errors.New("request failed"), ClassCancelled, false},
+		{"unknown", context.Background(), errors.New("boom"), ClassUnknown, false},
+		{"already classified", context.Background(), &Error{Class: ClassCircuitOpen}, ClassCircuitOpen, false},
+	}
+
+	for _, tt := range tests {
+		e := Classify(tt.ctx, tt.err)
+		if e.Class != tt.class || e.Retryable != tt.retryable {
+			t.Errorf("%s: Classify = %s retryable=%v, want %s retryable=%v", tt.name, e.Class, e.Retryable, tt.class, tt.retryable)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package llm
+
+import (
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type storedEntries struct {
+	CallType string `json:"call_type"`
+	Entries  int64  `json:"entries"`
+	Hits     int64  `json:"hits"`
+}
+
+// GET /admin/llm-cache  hit/miss counters of this process and the live
+// entries per call type.
+func CacheStatus(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cache := DefaultCache()
+		if cache == nil {
+			response.Respond(c, http.StatusOK, "LLM cache disabled", gin.H{"enabled": false})
+			return
+		}
+
+		var stored []storedEntries
+		if err := db.DB.Model(&models.LLMCacheEntry{}).
+			Select("call_type, COUNT(*) AS entries, COALESCE(SUM(hits), 0) AS hits").
+			Where("expires_at > NOW()").
+			Group("call_type").
+			Scan(&stored).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load cache entries", nil)
+			return
+		}
+
+		response.Respond(c, http.StatusOK, "LLM cache stats", gin.H{
+			"enabled": true,
+			"process": cache.Stats(),
+			"stored":  stored,
+			"breaker": OpenAI.Breaker.State(),
+		})
+	}
+}
+
+// DELETE /admin/llm-cache[?call_type=research]  drops cached responses.
+func PurgeCache(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := db.DB.Where("1 = 1")
+		if t := c.Query("call_type"); t != "" {
+			q = q.Where("call_type = ?", t)
+		}
+		res := q.Delete(&models.LLMCacheEntry{})
+		if res.Error != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to purge cache", nil)
+			return
+		}
+		response.Respond(c, http.StatusOK, "LLM cache purged", gin.H{"deleted": res.RowsAffected})
+	}
+}
+
+type usageGroup struct {
+	Key string `json:"key"`
+	Summary
+}
+
+// GET /me/usage[?month=2025-10]  the user's model usage and estimated cost
+// for a calendar month (UTC), current month by default.
+func MonthlyUsage(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uRaw, _ := c.Get("user")
+		user, _ := uRaw.(models.User)
+		if user.ID == 0 {
+			response.Respond(c, http.StatusUnauthorized, "unauthorized", nil)
+			return
+		}
+
+		start := time.Now().UTC()
+		start = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if m := c.Query("month"); m != "" {
+			t, err := time.Parse("2006-01", m)
+			if err != nil {
+				response.Respond(c, http.StatusBadRequest, "month must be YYYY-MM", nil)
+				return
+			}
+			start = t
+		}
+		end := start.AddDate(0, 1, 0)
+		scope := func() *gorm.DB {
+			return db.DB.Where("user_id = ? AND created_at >= ? AND created_at < ?", user.ID, start, end)
+		}
+
+		total, err := SumCalls(scope())
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load usage", nil)
+			return
+		}
+		byType, err := groupCalls(scope(), "call_type")
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load usage", nil)
+			return
+		}
+		byModel, err := groupCalls(scope(), "model")
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load usage", nil)
+			return
+		}
+
+		response.Respond(c, http.StatusOK, "Usage loaded", gin.H{
+			"month":        start.Format("2006-01"),
+			"from":         start,
+			"to":           end,
+			"total":        total,
+			"by_call_type": byType,
+			"by_model":     byModel,
+		})
+	}
+}
+
+// groupCalls is SumCalls per value of column, which must be a trusted name.
+func groupCalls(q *gorm.DB, column string) ([]usageGroup, error) {
+	groups := []usageGroup{}
+	err := q.Model(&models.LLMCall{}).Select(column + " AS key," + summaryColumns).
+		Group(column).Order("cost_usd DESC").Scan(&groups).Error
+	return groups, err
+}
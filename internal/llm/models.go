@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// defaultModels are used for steps without an OPENAI_MODEL_<STEP> setting.
+var defaultModels = map[CallType]string{
+	CallQueries:     "gpt-4.1-mini",
+	CallResearch:    "gpt-4.1-mini",
+	CallBrands:      "gpt-4.1-mini",
+	CallSuggestions: "gpt-4.1-mini",
+	CallScan:        "gpt-4o-mini",
+	CallEngine:      "gpt-4.1-mini",
+}
+
+// Models maps workflow steps to the model that runs them.
+type Models map[CallType]string
+
+// ConfiguredModel is the model of step from OPENAI_MODEL_<STEP>, e.g.
+// OPENAI_MODEL_RESEARCH, or the built-in default.
+func ConfiguredModel(step CallType) string {
+	if m := strings.TrimSpace(os.Getenv("OPENAI_MODEL_" + strings.ToUpper(string(step)))); m != "" {
+		return m
+	}
+	return defaultModels[step]
+}
+
+// KnownModel reports whether usage of model can be priced, which is what a
+// per-request override is checked against.
+func KnownModel(model string) bool {
+	_, ok := priceOf(model)
+	return ok
+}
+
+// ResolveModels returns the model of each of steps: the override when one
+// is given, the configured model otherwise. Overrides for other steps or
+// unknown models are an error.
+func ResolveModels(steps []CallType, overrides map[string]string) (Models, error) {
+	out := make(Models, len(steps))
+	for _, step := range steps {
+		out[step] = ConfiguredModel(step)
+	}
+	for step, model := range overrides {
+		if _, ok := out[CallType(step)]; !ok {
+			return nil, fmt.Errorf("unknown step %q", step)
+		}
+		if !KnownModel(model) {
+			return nil, fmt.Errorf("unsupported model %q for step %s", model, step)
+		}
+		out[CallType(step)] = model
+	}
+	return out, nil
+}
+
+type modelsKey struct{}
+
+// WithModels makes ModelFor on ctx use m.
+func WithModels(ctx context.Context, m Models) context.Context {
+	return context.WithValue(ctx, modelsKey{}, m)
+}
+
+// ModelFor is the model of step for the run on ctx.
+func ModelFor(ctx context.Context, step CallType) string {
+	if m, ok := ctx.Value(modelsKey{}).(Models); ok && m[step] != "" {
+		return m[step]
+	}
+	return ConfiguredModel(step)
+}
@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"context"
+	"log"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+)
+
+// Caller runs upstream calls with retries behind a circuit breaker.
+type Caller struct {
+	// MaxAttempts includes the first call.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Breaker     *Breaker
+}
+
+// OpenAI is the process-wide caller for OpenAI requests. OPENAI_MAX_ATTEMPTS
+// overrides the number of attempts.
+var OpenAI = &Caller{
+	MaxAttempts: envInt("OPENAI_MAX_ATTEMPTS", 4),
+	BaseDelay:   time.Second,
+	MaxDelay:    30 * time.Second,
+	Breaker:     NewBreaker("openai", 5, 30*time.Second),
+}
+
+// NewOpenAIClient returns an OpenAI client with the SDK's own retries
+// turned off, since calls go through OpenAI.Do.
+func NewOpenAIClient(opts ...option.RequestOption) openai.Client {
+	return openai.NewClient(append([]option.RequestOption{option.WithMaxRetries(0)}, opts...)...)
+}
+
+// Do calls fn until it succeeds, fails permanently or runs out of attempts.
+// Waits grow exponentially with jitter and never undercut Retry-After; a
+// Retry-After beyond MaxDelay ends the retries instead, leaving the wait to
+// the caller. The returned error is always an *Error.
+func (c *Caller) Do(ctx context.Context, op string, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		if err := c.Breaker.Allow(); err != nil {
+			e := err.(*Error)
+			e.Attempts = attempt - 1
+			return e
+		}
+
+		err := fn(ctx)
+		if err == nil {
+			c.Breaker.Record(nil)
+			return nil
+		}
+		e := Classify(ctx, err)
+		c.Breaker.Record(e)
+		e.Attempts = attempt
+		if !e.Retryable || attempt >= c.MaxAttempts {
+			return e
+		}
+
+		if e.RetryAfter > c.MaxDelay {
+			return e
+		}
+		delay := max(c.backoff(attempt), e.RetryAfter)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return e
+		}
+		log.Printf("[llm] %s attempt %d/%d failed (%s), retrying in %s", op, attempt, c.MaxAttempts, e.Class, delay.Round(time.Millisecond))
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return e
+		case <-t.C:
+		}
+	}
+}
+
+// backoff is the full-jitter wait after the given failed attempt.
+func (c *Caller) backoff(attempt int) time.Duration {
+	d := c.BaseDelay << (attempt - 1)
+	if d <= 0 || d > c.MaxDelay {
+		d = c.MaxDelay
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+func envInt(key string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
@@ -0,0 +1,202 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func testCaller(threshold int) *Caller {
+	return &Caller{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    100 * time.Millisecond,
+		Breaker:     NewBreaker("test", threshold, time.Minute),
+	}
+}
+
+func statusErr(status int, header http.Header) error {
+	return &StatusError{Status: status, Header: header}
+}
+
+func TestCallerDo(t *testing.T) {
+	tests := []struct {
+		name     string
+		results  []error // per attempt, nil is success
+		calls    int
+		class    string // "" for success
+		attempts int
+	}{
+		{
+			name:    "success",
+			results: []error{nil},
+			calls:   1,
+		},
+		{
+			name:    "retryable failures then success",
+			results: []error{statusErr(503, nil), statusErr(429, nil), nil},
+			calls:   3,
+		},
+		{
+			name:     "attempts run out",
+			results:  []error{statusErr(500, nil), statusErr(502, nil), statusErr(503, nil), nil},
+			calls:    3,
+			class:    ClassUnavailable,
+			attempts: 3,
+		},
+		{
+			name:     "permanent 4xx is not retried",
+			results:  []error{statusErr(400, nil), nil},
+			calls:    1,
+			class:    ClassInvalidRequest,
+			attempts: 1,
+		},
+		{
+			name:     "auth failure is not retried",
+			results:  []error{statusErr(401, nil), nil},
+			calls:    1,
+			class:    ClassAuth,
+			attempts: 1,
+		},
+		{
+			name:     "quota exhaustion is not retried",
+			results:  []error{&StatusError{Status: 429, Body: `{"error":{"code":"insufficient_quota"}}`}, nil},
+			calls:    1,
+			class:    ClassQuotaExceeded,
+			attempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := testCaller(10).Do(context.Background(), "test", func(context.Context) error {
+				calls++
+				return tt.results[calls-1]
+			})
+
+			if calls != tt.calls {
+				t.Errorf("calls = %d, want %d", calls, tt.calls)
+			}
+			if tt.class == "" {
+				if err != nil {
+					t.Fatalf("Do() = %v, want success", err)
+				}
+				return
+			}
+			e, ok := err.(*Error)
+			if !ok {
+				t.Fatalf("Do() = %T %v, want *Error", err, err)
+			}
+			if e.Class != tt.class || e.Attempts != tt.attempts {
+				t.Errorf("Do() class %s after %d attempts, want %s after %d", e.Class, e.Attempts, tt.class, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestCallerFailsFastWhileOpen(t *testing.T) {
+	c := testCaller(2)
+	c.MaxAttempts = 2
+
+	calls := 0
+	fail := func(context.Context) error {
+		calls++
+		return statusErr(503, nil)
+	}
+	c.Do(context.Background(), "test", fail)
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+
+	err := c.Do(context.Background(), "test", fail)
+	if calls != 2 {
+		t.Errorf("calls while open = %d, want none", calls-2)
+	}
+	if e, ok := err.(*Error); !ok || e.Class != ClassCircuitOpen || e.Attempts != 0 {
+		t.Errorf("Do() while open = %v, want %s after 0 attempts", err, ClassCircuitOpen)
+	}
+	if HTTPStatus(err) != http.StatusServiceUnavailable {
+		t.Errorf("HTTPStatus = %d, want 503", HTTPStatus(err))
+	}
+}
+
+func TestCallerHonorsRetryAfter(t *testing.T) {
+	c := testCaller(10)
+	header := http.Header{"Retry-After-Ms": {"60"}}
+
+	calls := 0
+	start := time.Now()
+	err := c.Do(context.Background(), "test", func(context.Context) error {
+		calls++
+		if calls == 1 {
+			return statusErr(429, header)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Do() = %v", err)
+	}
+	if waited := time.Since(start); waited < 60*time.Millisecond {
+		t.Errorf("waited %s before the retry, want at least the 60ms of Retry-After", waited)
+	}
+}
+
+func TestCallerRetryAfterBeyondMaxDelay(t *testing.T) {
+	c := testCaller(10)
+	header := http.Header{"Retry-After": {"3600"}}
+
+	calls := 0
+	start := time.Now()
+	err := c.Do(context.Background(), "test", func(context.Context) error {
+		calls++
+		return statusErr(429, header)
+	})
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+	if waited := time.Since(start); waited > c.MaxDelay {
+		t.Errorf("waited %s, want no wait beyond MaxDelay", waited)
+	}
+	if e, ok := err.(*Error); !ok || e.Class != ClassRateLimited || e.RetryAfter != time.Hour {
+		t.Errorf("Do() = %v, want %s with the hour of Retry-After for the caller", err, ClassRateLimited)
+	}
+}
+
+func TestCallerStopsBeforeDeadline(t *testing.T) {
+	c := testCaller(10)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	calls := 0
+	c.Do(ctx, "test", func(context.Context) error {
+		calls++
+		return statusErr(503, http.Header{"Retry-After-Ms": {"50"}})
+	})
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1 since the wait would pass the deadline", calls)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := &Caller{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, 10 * time.Millisecond},
+		{2, 20 * time.Millisecond},
+		{3, 40 * time.Millisecond},
+		{4, 50 * time.Millisecond},
+		{64, 50 * time.Millisecond}, // the shift overflows to 0
+	}
+
+	for _, tt := range tests {
+		for range 50 {
+			if d := c.backoff(tt.attempt); d < tt.max/2 || d > tt.max {
+				t.Fatalf("backoff(%d) = %s, want within [%s, %s]", tt.attempt, d, tt.max/2, tt.max)
+			}
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/models"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Price is in USD per million tokens.
+type Price struct {
+	Input       float64
+	CachedInput float64
+	Output      float64
+}
+
+// Prices by model name prefix, from the providers' public price lists.
+// Dated snapshots ("gpt-4o-mini-2024-07-18", "claude-sonnet-4-5-20250929")
+// match their base model. The answer engine models are listed so engine
+// comparisons are not reported as free.
+var Prices = map[string]Price{
+	"gpt-4.1":      {Input: 2.00, CachedInput: 0.50, Output: 8.00},
+	"gpt-4.1-mini": {Input: 0.40, CachedInput: 0.10, Output: 1.60},
+	"gpt-4.1-nano": {Input: 0.10, CachedInput: 0.025, Output: 0.40},
+	"gpt-4o":       {Input: 2.50, CachedInput: 1.25, Output: 10.00},
+	"gpt-4o-mini":  {Input: 0.15, CachedInput: 0.075, Output: 0.60},
+
+	// Anthropic, cached input is the cache read price
+	"claude-sonnet-4":   {Input: 3.00, CachedInput: 0.30, Output: 15.00},
+	"claude-haiku-4-5":  {Input: 1.00, CachedInput: 0.10, Output: 5.00},
+	"claude-opus-4":     {Input: 15.00, CachedInput: 1.50, Output: 75.00},
+	"claude-opus-4-5":   {Input: 5.00, CachedInput: 0.50, Output: 25.00},
+	"claude-3-5-haiku":  {Input: 0.80, CachedInput: 0.08, Output: 4.00},
+	"claude-3-7-sonnet": {Input: 3.00, CachedInput: 0.30, Output: 15.00},
+
+	// Gemini, prompts up to 200k tokens
+	"gemini-2.5-pro":        {Input: 1.25, CachedInput: 0.31, Output: 10.00},
+	"gemini-2.5-flash":      {Input: 0.30, CachedInput: 0.075, Output: 2.50},
+	"gemini-2.5-flash-lite": {Input: 0.10, CachedInput: 0.025, Output: 0.40},
+
+	// Perplexity, the per request search fee is counted as a web search call
+	"sonar":               {Input: 1.00, CachedInput: 1.00, Output: 1.00},
+	"sonar-pro":           {Input: 3.00, CachedInput: 3.00, Output: 15.00},
+	"sonar-reasoning":     {Input: 1.00, CachedInput: 1.00, Output: 5.00},
+	"sonar-reasoning-pro": {Input: 2.00, CachedInput: 2.00, Output: 8.00},
+}
+
+// WebSearchCallPrice is the USD charged per web_search tool call.
+var WebSearchCallPrice = 0.01
+
+// Usage is what one model call consumed.
+type Usage struct {
+	InputTokens       int64 `json:"input_tokens"`
+	CachedInputTokens int64 `json:"cached_input_tokens"`
+	OutputTokens      int64 `json:"output_tokens"`
+	WebSearchCalls    int   `json:"web_search_calls"`
+}
+
+// UsageFromJSON reads the usage block and counts the web_search_call items
+// of a Responses API body.
+func UsageFromJSON(body []byte) Usage {
+	var env struct {
+		Output []struct {
+			Type string `json:"type"`
+		} `json:"output"`
+		Usage struct {
+			InputTokens        int64 `json:"input_tokens"`
+			OutputTokens       int64 `json:"output_tokens"`
+			InputTokensDetails struct {
+				CachedTokens int64 `json:"cached_tokens"`
+			} `json:"input_tokens_details"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &env); err != nil {
+		return Usage{}
+	}
+	u := Usage{
+		InputTokens:       env.Usage.InputTokens,
+		CachedInputTokens: env.Usage.InputTokensDetails.CachedTokens,
+		OutputTokens:      env.Usage.OutputTokens,
+	}
+	for _, item := range env.Output {
+		if item.Type == "web_search_call" {
+			u.WebSearchCalls++
+		}
+	}
+	return u
+}
+
+// Cost estimates the USD price of u on model. Unknown models cost 0.
+func Cost(model string, u Usage) float64 {
+	price, ok := priceOf(model)
+	cost := float64(u.WebSearchCalls) * WebSearchCallPrice
+	if !ok {
+		return cost
+	}
+	uncached := u.InputTokens - u.CachedInputTokens
+	cost += (float64(uncached)*price.Input +
+		float64(u.CachedInputTokens)*price.CachedInput +
+		float64(u.OutputTokens)*price.Output) / 1e6
+	return cost
+}
+
+func priceOf(model string) (Price, bool) {
+	best := ""
+	for prefix := range Prices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	p, ok := Prices[best]
+	return p, ok
+}
+
+// Meter collects the calls of one scan or workflow run.
+type Meter struct {
+	mu    sync.Mutex
+	calls []models.LLMCall
+}
+
+func NewMeter() *Meter { return &Meter{} }
+
+type meterKey struct{}
+
+// WithMeter makes Record on ctx add to m.
+func WithMeter(ctx context.Context, m *Meter) context.Context {
+	return context.WithValue(ctx, meterKey{}, m)
+}
+
+// Record adds a call to the meter of ctx, if any.
+func Record(ctx context.Context, callType CallType, model string, u Usage) {
+	record(ctx, models.LLMCall{
+		CallType:          string(callType),
+		Model:             model,
+		InputTokens:       u.InputTokens,
+		CachedInputTokens: u.CachedInputTokens,
+		OutputTokens:      u.OutputTokens,
+		WebSearchCalls:    u.WebSearchCalls,
+		CostUSD:           Cost(model, u),
+	})
+}
+
+func recordCacheHit(ctx context.Context, callType CallType, model string) {
+	record(ctx, models.LLMCall{CallType: string(callType), Model: model, CacheHit: true})
+}
+
+func record(ctx context.Context, call models.LLMCall) {
+	m, _ := ctx.Value(meterKey{}).(*Meter)
+	if m == nil {
+		return
+	}
+	call.CreatedAt = time.Now().UTC()
+	m.mu.Lock()
+	m.calls = append(m.calls, call)
+	m.mu.Unlock()
+}
+
+// Summary is the rolled-up usage of a set of calls.
+type Summary struct {
+	Calls             int64   `json:"calls"`
+	CacheHits         int64   `json:"cache_hits"`
+	InputTokens       int64   `json:"input_tokens"`
+	CachedInputTokens int64   `json:"cached_input_tokens"`
+	OutputTokens      int64   `json:"output_tokens"`
+	WebSearchCalls    int64   `json:"web_search_calls"`
+	CostUSD           float64 `json:"cost_usd"`
+}
+
+// Summary totals the calls recorded so far.
+func (m *Meter) Summary() Summary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var s Summary
+	for _, c := range m.calls {
+		s.Calls++
+		if c.CacheHit {
+			s.CacheHits++
+		}
+		s.InputTokens += c.InputTokens
+		s.CachedInputTokens += c.CachedInputTokens
+		s.OutputTokens += c.OutputTokens
+		s.WebSearchCalls += int64(c.WebSearchCalls)
+		s.CostUSD += c.CostUSD
+	}
+	return s
+}
+
+// Owner is what the recorded calls are attributed to.
+type Owner struct {
+	UserID          int64
+	SiteID          int64
+	ScanID          int64
+	BrandAnalysisID int64
+	WorkflowRunID   int64
+}
+
+// Save stores the recorded calls for owner and empties the meter.
+func (m *Meter) Save(db *database.Service, owner Owner) error {
+	m.mu.Lock()
+	calls := m.calls
+	m.calls = nil
+	m.mu.Unlock()
+	if len(calls) == 0 {
+		return nil
+	}
+	for i := range calls {
+		calls[i].UserID = owner.UserID
+		calls[i].SiteID = optionalID(owner.SiteID)
+		calls[i].ScanID = optionalID(owner.ScanID)
+		calls[i].BrandAnalysisID = optionalID(owner.BrandAnalysisID)
+		calls[i].WorkflowRunID = optionalID(owner.WorkflowRunID)
+	}
+	return db.DB.CreateInBatches(&calls, 100).Error
+}
+
+func optionalID(id int64) *int64 {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// SumCalls totals the llm_calls matched by q, e.g. every attempt of a run.
+func SumCalls(q *gorm.DB) (Summary, error) {
+	var s Summary
+	err := q.Model(&models.LLMCall{}).Select(summaryColumns).Scan(&s).Error
+	return s, err
+}
+
+// summaryColumns aggregates llm_calls into the fields of Summary.
+const summaryColumns = `
+	COUNT(*) AS calls,
+	COUNT(*) FILTER (WHERE cache_hit) AS cache_hits,
+	COALESCE(SUM(input_tokens), 0) AS input_tokens,
+	COALESCE(SUM(cached_input_tokens), 0) AS cached_input_tokens,
+	COALESCE(SUM(output_tokens), 0) AS output_tokens,
+	COALESCE(SUM(web_search_calls), 0) AS web_search_calls,
+	COALESCE(SUM(cost_usd), 0) AS cost_usd`
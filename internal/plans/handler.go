@@ -0,0 +1,86 @@
+package plans
+
+import (
+	"founders-toolkit-api/internal/audit"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /me  the current user with their plan and remaining quota.
+func Me(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uRaw, _ := c.Get("user")
+		user, _ := uRaw.(models.User)
+		if user.ID == 0 {
+			response.Respond(c, http.StatusUnauthorized, "unauthorized", nil)
+			return
+		}
+
+		quota, err := Status(db, user)
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load quota", nil)
+			return
+		}
+
+		user.Password = ""
+		response.Respond(c, http.StatusOK, "Account loaded", gin.H{
+			"user":  user,
+			"quota": quota,
+		})
+	}
+}
+
+// GET /plans
+func ListPlans(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var plans []models.Plan
+		if err := db.DB.Order("scans_per_month ASC NULLS LAST").Find(&plans).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load plans", nil)
+			return
+		}
+		response.Respond(c, http.StatusOK, "Plans loaded", plans)
+	}
+}
+
+type setPlanRequest struct {
+	Plan string `json:"plan" binding:"required"`
+}
+
+// PUT /admin/users/:id/plan
+func SetUserPlan(db *database.Service, auditor *audit.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req setPlanRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+
+		var plan models.Plan
+		if err := db.DB.Where("key = ?", req.Plan).First(&plan).Error; err != nil {
+			response.Respond(c, http.StatusBadRequest, "unknown plan", nil)
+			return
+		}
+
+		res := db.DB.Model(&models.User{}).Where("id = ?", c.Param("id")).Update("plan", plan.Key)
+		if res.Error != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to update plan", nil)
+			return
+		}
+		if res.RowsAffected == 0 {
+			response.Respond(c, http.StatusNotFound, "user not found", nil)
+			return
+		}
+
+		auditor.Record(c, audit.Entry{
+			Action:     audit.ActionPlanChange,
+			TargetType: audit.TargetUser,
+			TargetID:   c.Param("id"),
+			Metadata:   map[string]any{"plan": plan.Key},
+		})
+		response.Respond(c, http.StatusOK, "Plan updated", plan)
+	}
+}
@@ -0,0 +1,260 @@
+package plans
+
+import (
+	"database/sql"
+	"fmt"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/models"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Codes of the limits a request can hit.
+const (
+	LimitMonthlyScans   = "monthly_scans"
+	LimitConcurrentRuns = "concurrent_runs"
+	LimitQueriesPerType = "queries_per_type"
+	LimitSites          = "sites"
+	LimitModels         = "models"
+)
+
+// staleRun is how long a run may stay "running" without updates before it
+// no longer counts as concurrent, e.g. after a crash.
+const staleRun = 15 * time.Minute
+
+// For returns the user's plan, the free plan when it is unknown.
+func For(db *database.Service, user models.User) (models.Plan, error) {
+	key := user.Plan
+	if key == "" {
+		key = models.PlanFree
+	}
+	var rows []models.Plan
+	if err := db.DB.Where("key IN ?", []string{key, models.PlanFree}).Find(&rows).Error; err != nil {
+		return models.Plan{}, err
+	}
+	for _, p := range rows {
+		if p.Key == key {
+			return p, nil
+		}
+	}
+	if len(rows) > 0 {
+		return rows[0], nil
+	}
+	return models.Plan{}, fmt.Errorf("plan %q not found", key)
+}
+
+// Quota is the user's plan and what is left of it this month.
+type Quota struct {
+	Plan           models.Plan `json:"plan"`
+	PeriodStart    time.Time   `json:"period_start"`
+	PeriodEnd      time.Time   `json:"period_end"`
+	ScansUsed      int         `json:"scans_used"`
+	ScansRemaining *int        `json:"scans_remaining"` // nil when unlimited
+	RunningRuns    int         `json:"running_runs"`
+	Sites          int         `json:"sites"`
+}
+
+// period is the calendar month (UTC) containing t.
+func period(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// Status loads the user's plan and current usage. Scans and brand workflow
+// runs both count as scans, a run once per sample; failed ones do not.
+func Status(db *database.Service, user models.User) (Quota, error) {
+	plan, err := For(db, user)
+	if err != nil {
+		return Quota{}, err
+	}
+	q := Quota{Plan: plan}
+	q.PeriodStart, q.PeriodEnd = period(time.Now())
+
+	var counts struct {
+		ScansUsed   int
+		RunningRuns int
+		Sites       int
+	}
+	err = db.DB.Raw(`
+		SELECT
+		  (SELECT COUNT(*) FROM scans
+		    WHERE user_id = @uid AND created_at >= @from AND NOT failed)
+		  + (SELECT COALESCE(SUM(GREATEST(COALESCE((config->>'samples')::int, 1), 1)), 0) FROM workflow_runs
+		    WHERE user_id = @uid AND created_at >= @from AND status <> @failed) AS scans_used,
+		  (SELECT COUNT(*) FROM workflow_runs
+		    WHERE user_id = @uid AND status = @running AND updated_at > @stale) AS running_runs,
+		  (SELECT COUNT(*) FROM sites WHERE user_id = @uid) AS sites`,
+		sql.Named("uid", user.ID),
+		sql.Named("from", q.PeriodStart),
+		sql.Named("failed", models.RunStatusFailed),
+		sql.Named("running", models.RunStatusRunning),
+		sql.Named("stale", time.Now().Add(-staleRun)),
+	).Scan(&counts).Error
+	if err != nil {
+		return Quota{}, err
+	}
+	q.ScansUsed, q.RunningRuns, q.Sites = counts.ScansUsed, counts.RunningRuns, counts.Sites
+	if plan.ScansPerMonth != nil {
+		left := max(*plan.ScansPerMonth-q.ScansUsed, 0)
+		q.ScansRemaining = &left
+	}
+	return q, nil
+}
+
+// Request describes the work about to be started. Resuming a failed run
+// counts as a new one since failed runs are not charged against the quota.
+type Request struct {
+	SiteID int64
+	// QueriesPerType is the largest num_* asked for; 0 when not applicable.
+	QueriesPerType int
+	// Samples is how often each query is researched; 0 counts as 1. Each
+	// sample counts against the queries per type and the monthly scans.
+	Samples int
+	// Models are the per-step model overrides asked for.
+	Models map[string]string
+}
+
+// LimitError is a plan limit the request would exceed. Status is 402 when
+// only a bigger plan helps and 429 when waiting does.
+type LimitError struct {
+	Status     int
+	Code       string
+	Plan       string
+	Message    string
+	Limit      int
+	Used       int
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string { return e.Message }
+
+// Check returns the first limit req would exceed, or nil. Admins are not
+// limited.
+func Check(db *database.Service, user models.User, req Request) (*LimitError, error) {
+	if user.IsAdmin() {
+		return nil, nil
+	}
+	q, err := Status(db, user)
+	if err != nil {
+		return nil, err
+	}
+	plan := q.Plan
+	samples := max(req.Samples, 1)
+
+	if lim := plan.MaxQueriesPerType; lim != nil && req.QueriesPerType*samples > *lim {
+		msg := fmt.Sprintf("the %s plan allows at most %d queries per type", plan.Name, *lim)
+		if samples > 1 {
+			msg += fmt.Sprintf(", %d queries with %d samples each count as %d", req.QueriesPerType, samples, req.QueriesPerType*samples)
+		}
+		return &LimitError{
+			Plan:    plan.Key,
+			Status:  http.StatusPaymentRequired,
+			Code:    LimitQueriesPerType,
+			Message: msg,
+			Limit:   *lim,
+			Used:    req.QueriesPerType * samples,
+		}, nil
+	}
+
+	if plan.AllowedModels != nil {
+		for step, model := range req.Models {
+			if !slices.Contains(plan.AllowedModels, model) {
+				return &LimitError{
+					Plan:    plan.Key,
+					Status:  http.StatusPaymentRequired,
+					Code:    LimitModels,
+					Message: fmt.Sprintf("the %s plan does not allow choosing %s for %s", plan.Name, model, step),
+				}, nil
+			}
+		}
+	}
+
+	if lim := plan.MaxSites; lim != nil && req.SiteID != 0 {
+		var rank int64
+		if err := db.DB.Table("sites").
+			Where("user_id = ? AND (created_at, id) < (SELECT created_at, id FROM sites WHERE id = ?)", user.ID, req.SiteID).
+			Count(&rank).Error; err != nil {
+			return nil, err
+		}
+		if int(rank) >= *lim {
+			return &LimitError{
+				Plan:    plan.Key,
+				Status:  http.StatusPaymentRequired,
+				Code:    LimitSites,
+				Message: fmt.Sprintf("the %s plan covers your first %d sites; this site is over the limit", plan.Name, *lim),
+				Limit:   *lim,
+				Used:    q.Sites,
+			}, nil
+		}
+	}
+
+	if lim := plan.MaxConcurrentRuns; lim != nil && q.RunningRuns >= *lim {
+		return &LimitError{
+			Plan:       plan.Key,
+			Status:     http.StatusTooManyRequests,
+			Code:       LimitConcurrentRuns,
+			Message:    fmt.Sprintf("%d runs are already in progress, the %s plan allows %d at a time", q.RunningRuns, plan.Name, *lim),
+			Limit:      *lim,
+			Used:       q.RunningRuns,
+			RetryAfter: 30 * time.Second,
+		}, nil
+	}
+
+	if q.ScansRemaining != nil && samples > *plan.ScansPerMonth {
+		return &LimitError{
+			Plan:    plan.Key,
+			Status:  http.StatusPaymentRequired,
+			Code:    LimitMonthlyScans,
+			Message: fmt.Sprintf("a run with %d samples counts as %d scans, more than the %d a month of the %s plan", samples, samples, *plan.ScansPerMonth, plan.Name),
+			Limit:   *plan.ScansPerMonth,
+			Used:    q.ScansUsed,
+		}, nil
+	}
+	if q.ScansRemaining != nil && *q.ScansRemaining < samples {
+		msg := fmt.Sprintf("monthly limit of %d scans reached, it resets on %s", *plan.ScansPerMonth, q.PeriodEnd.Format("2006-01-02"))
+		if *q.ScansRemaining > 0 {
+			msg = fmt.Sprintf("a run with %d samples counts as %d scans but only %d are left this month, the limit resets on %s",
+				samples, samples, *q.ScansRemaining, q.PeriodEnd.Format("2006-01-02"))
+		}
+		return &LimitError{
+			Plan:       plan.Key,
+			Status:     http.StatusTooManyRequests,
+			Code:       LimitMonthlyScans,
+			Message:    msg,
+			Limit:      *plan.ScansPerMonth,
+			Used:       q.ScansUsed,
+			RetryAfter: time.Until(q.PeriodEnd),
+		}, nil
+	}
+	return nil, nil
+}
+
+// Enforce runs Check and responds when a limit is hit or the check fails.
+// It reports whether the handler may go on.
+func Enforce(c *gin.Context, db *database.Service, user models.User, req Request) bool {
+	lim, err := Check(db, user, req)
+	if err != nil {
+		response.Respond(c, http.StatusInternalServerError, "failed to check plan limits", nil)
+		return false
+	}
+	if lim == nil {
+		return true
+	}
+	if lim.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(lim.RetryAfter.Seconds())+1))
+	}
+	response.Respond(c, lim.Status, lim.Message, gin.H{
+		"limit":      lim.Code,
+		"plan":       lim.Plan,
+		"allowed":    lim.Limit,
+		"used":       lim.Used,
+		"upgradable": lim.Status == http.StatusPaymentRequired,
+	})
+	return false
+}
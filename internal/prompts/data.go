@@ -0,0 +1,79 @@
+package prompts
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Site is the site a prompt is about.
+type Site struct {
+	Name        string
+	URL         string
+	Description string
+	Language    string
+	// Country and Region are the target market, empty when the site has
+	// none.
+	Country string
+	Region  string
+}
+
+// QueriesData feeds the "queries" prompt.
+type QueriesData struct {
+	N    int
+	Type string
+	Site Site
+}
+
+// ResearchData feeds the "research" prompt.
+type ResearchData struct {
+	Query string
+	Site  Site
+}
+
+// BrandsData feeds the "brands" prompt.
+type BrandsData struct {
+	Query        string
+	ResearchText string
+}
+
+// SuggestionsData feeds the "suggestions" prompt.
+type SuggestionsData struct {
+	Site         Site
+	AnalysisJSON string
+}
+
+var sampleSite = Site{
+	Name:        "Acme Tools",
+	URL:         "https://www.acme-tools.io",
+	Description: "Project management for small teams",
+	Language:    "en",
+}
+
+// samples are rendered to check a new version before it is stored.
+var samples = map[string]any{
+	ScanSystem:  nil,
+	Queries:     QueriesData{N: 3, Type: "direct", Site: sampleSite},
+	Research:    ResearchData{Query: "best project management tool", Site: sampleSite},
+	Brands:      BrandsData{Query: "best project management tool", ResearchText: "- Acme Tools (acme-tools.io)"},
+	Suggestions: SuggestionsData{Site: sampleSite, AnalysisJSON: `{"direct":{"queries":[]}}`},
+}
+
+// Validate parses body and renders it with sample data for name.
+func Validate(name, body string) error {
+	sample, ok := samples[name]
+	if !ok {
+		return fmt.Errorf("unknown prompt %q", name)
+	}
+	t, err := parse(name, 0, body)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, sample); err != nil {
+		return err
+	}
+	if buf.Len() == 0 {
+		return fmt.Errorf("prompt renders empty")
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package prompts
+
+import (
+	"database/sql"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/models"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type promptVersions struct {
+	Name        string                  `json:"name"`
+	DefaultBody string                  `json:"default_body"`
+	Versions    []models.PromptTemplate `json:"versions"`
+}
+
+// GET /admin/prompts  every prompt with its default and stored versions
+func ListPrompts(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var rows []models.PromptTemplate
+		if err := db.DB.Order("name ASC, version DESC").Find(&rows).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load prompts", nil)
+			return
+		}
+
+		out := make([]promptVersions, 0, len(Names))
+		for _, name := range Names {
+			body, _ := DefaultBody(name)
+			pv := promptVersions{Name: name, DefaultBody: body, Versions: []models.PromptTemplate{}}
+			for _, row := range rows {
+				if row.Name == name {
+					pv.Versions = append(pv.Versions, row)
+				}
+			}
+			out = append(out, pv)
+		}
+		response.Respond(c, http.StatusOK, "Prompts loaded", out)
+	}
+}
+
+type createPromptRequest struct {
+	Name           string `json:"name" binding:"required"`
+	Body           string `json:"body" binding:"required"`
+	RolloutPercent int    `json:"rollout_percent"`
+}
+
+// POST /admin/prompts  stores the next version of a prompt as a candidate
+func CreatePrompt(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req createPromptRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		if !slices.Contains(Names, req.Name) {
+			response.Respond(c, http.StatusBadRequest, "unknown prompt name", gin.H{"names": Names})
+			return
+		}
+		if req.RolloutPercent < 0 || req.RolloutPercent > 100 {
+			response.Respond(c, http.StatusBadRequest, "rollout_percent must be between 0 and 100", nil)
+			return
+		}
+		if err := Validate(req.Name, req.Body); err != nil {
+			response.Respond(c, http.StatusBadRequest, "invalid template: "+err.Error(), nil)
+			return
+		}
+
+		uRaw, _ := c.Get("user")
+		user, _ := uRaw.(models.User)
+		row := models.PromptTemplate{
+			Name:           req.Name,
+			Body:           req.Body,
+			Status:         models.PromptStatusCandidate,
+			RolloutPercent: req.RolloutPercent,
+			CreatedBy:      &user.ID,
+		}
+		err := db.DB.Transaction(func(tx *gorm.DB) error {
+			var latest int
+			if err := tx.Model(&models.PromptTemplate{}).Where("name = ?", req.Name).
+				Select("COALESCE(MAX(version), ?)", DefaultVersion).Scan(&latest).Error; err != nil {
+				return err
+			}
+			row.Version = latest + 1
+			return tx.Create(&row).Error
+		})
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to save prompt", nil)
+			return
+		}
+
+		Invalidate()
+		response.Respond(c, http.StatusCreated, "Prompt version created", row)
+	}
+}
+
+type updatePromptRequest struct {
+	Status         *string `json:"status"`
+	RolloutPercent *int    `json:"rollout_percent"`
+}
+
+// PUT /admin/prompts/:id  changes the rollout of a version, promotes it to
+// live (retiring the previous live version) or retires it
+func UpdatePrompt(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req updatePromptRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+
+		var row models.PromptTemplate
+		if err := db.DB.Where("id = ?", c.Param("id")).First(&row).Error; err != nil {
+			response.Respond(c, http.StatusNotFound, "prompt version not found", nil)
+			return
+		}
+
+		updates := map[string]any{}
+		if req.RolloutPercent != nil {
+			if *req.RolloutPercent < 0 || *req.RolloutPercent > 100 {
+				response.Respond(c, http.StatusBadRequest, "rollout_percent must be between 0 and 100", nil)
+				return
+			}
+			updates["rollout_percent"] = *req.RolloutPercent
+		}
+		if req.Status != nil {
+			switch *req.Status {
+			case models.PromptStatusCandidate, models.PromptStatusLive, models.PromptStatusRetired:
+				updates["status"] = *req.Status
+			default:
+				response.Respond(c, http.StatusBadRequest, "status must be candidate, live or retired", nil)
+				return
+			}
+		}
+		if len(updates) == 0 {
+			response.Respond(c, http.StatusBadRequest, "nothing to update", nil)
+			return
+		}
+
+		err := db.DB.Transaction(func(tx *gorm.DB) error {
+			if updates["status"] == models.PromptStatusLive {
+				if err := tx.Model(&models.PromptTemplate{}).
+					Where("name = ? AND status = ? AND id <> ?", row.Name, models.PromptStatusLive, row.ID).
+					Update("status", models.PromptStatusRetired).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Model(&row).Updates(updates).Error
+		})
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to update prompt", nil)
+			return
+		}
+
+		Invalidate()
+		response.Respond(c, http.StatusOK, "Prompt version updated", row)
+	}
+}
+
+type versionResult struct {
+	Version       int      `json:"version"`
+	Runs          int64    `json:"runs"`
+	AvgVisibility *float64 `json:"avg_visibility"`
+}
+
+// GET /admin/prompts/:name/results  runs and average visibility per version,
+// to compare a candidate against the live prompt
+func PromptResults(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("name")
+		if !slices.Contains(Names, name) {
+			response.Respond(c, http.StatusNotFound, "unknown prompt name", nil)
+			return
+		}
+
+		// scan_system drives scans, every other prompt brand analyses
+		q := `
+			SELECT (prompt_versions->>@name)::int AS version,
+			       COUNT(*) AS runs,
+			       AVG(visibility_score) AS avg_visibility
+			FROM brand_analyses
+			WHERE prompt_versions->>@name IS NOT NULL AND status = @completed
+			GROUP BY 1 ORDER BY 1`
+		if name == ScanSystem {
+			q = `
+				SELECT (prompt_versions->>@name)::int AS version,
+				       COUNT(*) AS runs,
+				       AVG(visibility_score) AS avg_visibility
+				FROM scans
+				WHERE prompt_versions->>@name IS NOT NULL AND completed AND NOT failed
+				GROUP BY 1 ORDER BY 1`
+		}
+
+		results := []versionResult{}
+		if err := db.DB.Raw(q, sql.Named("name", name), sql.Named("completed", models.AnalysisStatusCompleted)).
+			Scan(&results).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load results", nil)
+			return
+		}
+		response.Respond(c, http.StatusOK, "Prompt results loaded", gin.H{"name": name, "versions": results})
+	}
+}
@@ -0,0 +1,227 @@
+package prompts
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/models"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+// Prompt names.
+const (
+	ScanSystem  = "scan_system"
+	Queries     = "queries"
+	Research    = "research"
+	Brands      = "brands"
+	Suggestions = "suggestions"
+)
+
+// Names lists every prompt in the order the workflows use them.
+var Names = []string{ScanSystem, Queries, Research, Brands, Suggestions}
+
+// DefaultVersion is the version of the embedded defaults.
+const DefaultVersion = 1
+
+//go:embed defaults/*.tmpl
+var defaultsFS embed.FS
+
+// refreshEvery bounds how stale the registry's view of prompt_templates is.
+const refreshEvery = 30 * time.Second
+
+// Template is one version of a named prompt.
+type Template struct {
+	Name           string
+	Version        int
+	RolloutPercent int
+	tmpl           *template.Template
+}
+
+func parse(name string, version int, body string) (*Template, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{Name: name, Version: version, tmpl: t}, nil
+}
+
+var defaults = func() map[string]*Template {
+	m := make(map[string]*Template, len(Names))
+	for _, name := range Names {
+		body, err := defaultsFS.ReadFile("defaults/" + name + ".tmpl")
+		if err != nil {
+			panic(err)
+		}
+		t, err := parse(name, DefaultVersion, string(body))
+		if err != nil {
+			panic(fmt.Sprintf("prompts: default %s: %v", name, err))
+		}
+		m[name] = t
+	}
+	return m
+}()
+
+// DefaultBody returns the embedded default of name.
+func DefaultBody(name string) (string, bool) {
+	body, err := defaultsFS.ReadFile("defaults/" + name + ".tmpl")
+	return string(body), err == nil
+}
+
+// Registry serves the live and candidate versions stored in
+// prompt_templates on top of the embedded defaults.
+type Registry struct {
+	db *database.Service
+
+	mu         sync.Mutex
+	loadedAt   time.Time
+	live       map[string]*Template
+	candidates map[string]*Template
+}
+
+var registry atomic.Pointer[Registry]
+
+// Enable makes Pick consult prompt_templates. Until it is called only the
+// embedded defaults are used.
+func Enable(db *database.Service) {
+	registry.Store(&Registry{db: db})
+}
+
+// Invalidate makes the next Pick reload prompt_templates.
+func Invalidate() {
+	if r := registry.Load(); r != nil {
+		r.mu.Lock()
+		r.loadedAt = time.Time{}
+		r.mu.Unlock()
+	}
+}
+
+// current returns the live and candidate template per name, reloading them
+// when stale. A failed reload keeps the previous view.
+func (r *Registry) current() (map[string]*Template, map[string]*Template) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.loadedAt) < refreshEvery {
+		return r.live, r.candidates
+	}
+
+	var rows []models.PromptTemplate
+	if err := r.db.DB.Where("status IN ?", []string{models.PromptStatusLive, models.PromptStatusCandidate}).
+		Order("version ASC").Find(&rows).Error; err != nil {
+		log.Printf("[prompts] load templates: %v", err)
+		return r.live, r.candidates
+	}
+	live, candidates := map[string]*Template{}, map[string]*Template{}
+	for _, row := range rows {
+		t, err := parse(row.Name, row.Version, row.Body)
+		if err != nil {
+			log.Printf("[prompts] %s v%d does not parse: %v", row.Name, row.Version, err)
+			continue
+		}
+		t.RolloutPercent = row.RolloutPercent
+		// rows are ordered by version, so the newest of each status wins
+		if row.Status == models.PromptStatusLive {
+			live[row.Name] = t
+		} else if row.RolloutPercent > 0 {
+			candidates[row.Name] = t
+		}
+	}
+	r.live, r.candidates, r.loadedAt = live, candidates, time.Now()
+	return live, candidates
+}
+
+// Set is the prompt versions pinned for one run.
+type Set struct {
+	templates map[string]*Template
+}
+
+// Pick pins a version of every prompt for a new run. The run draws one
+// bucket in [0, 100) and gets each prompt's candidate when the bucket is
+// below its rollout percentage, so a run that gets a 20% candidate also gets
+// every candidate rolled out wider.
+func Pick() *Set {
+	s := &Set{templates: make(map[string]*Template, len(Names))}
+	for name, t := range defaults {
+		s.templates[name] = t
+	}
+	r := registry.Load()
+	if r == nil {
+		return s
+	}
+	live, candidates := r.current()
+	bucket := rand.IntN(100)
+	for _, name := range Names {
+		if t, ok := live[name]; ok {
+			s.templates[name] = t
+		}
+		if t, ok := candidates[name]; ok && bucket < t.RolloutPercent {
+			s.templates[name] = t
+		}
+	}
+	return s
+}
+
+// Pin rebuilds the set a run used, e.g. when resuming it. Versions that no
+// longer exist fall back to the current pick.
+func Pin(db *database.Service, versions map[string]int) *Set {
+	s := Pick()
+	for name, version := range versions {
+		if version == DefaultVersion {
+			if t, ok := defaults[name]; ok {
+				s.templates[name] = t
+			}
+			continue
+		}
+		var row models.PromptTemplate
+		if err := db.DB.Where("name = ? AND version = ?", name, version).First(&row).Error; err != nil {
+			log.Printf("[prompts] pin %s v%d: %v", name, version, err)
+			continue
+		}
+		t, err := parse(row.Name, row.Version, row.Body)
+		if err != nil {
+			log.Printf("[prompts] pin %s v%d: %v", name, version, err)
+			continue
+		}
+		s.templates[name] = t
+	}
+	return s
+}
+
+// Versions is the version of every prompt in the set, for storing on runs.
+func (s *Set) Versions() map[string]int {
+	v := make(map[string]int, len(s.templates))
+	for name, t := range s.templates {
+		v[name] = t.Version
+	}
+	return v
+}
+
+type setKey struct{}
+
+// WithSet makes Render on ctx use s.
+func WithSet(ctx context.Context, s *Set) context.Context {
+	return context.WithValue(ctx, setKey{}, s)
+}
+
+// Render executes the prompt name with data, using the set on ctx or the
+// embedded defaults.
+func Render(ctx context.Context, name string, data any) (string, error) {
+	t := defaults[name]
+	if s, ok := ctx.Value(setKey{}).(*Set); ok && s.templates[name] != nil {
+		t = s.templates[name]
+	}
+	if t == nil {
+		return "", fmt.Errorf("unknown prompt %q", name)
+	}
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render prompt %s v%d: %w", name, t.Version, err)
+	}
+	return buf.String(), nil
+}
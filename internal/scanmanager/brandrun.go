@@ -0,0 +1,413 @@
+package scanmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"founders-toolkit-api/internal/audit"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/jobs"
+	"founders-toolkit-api/internal/llm"
+	"founders-toolkit-api/internal/prompts"
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/internal/scoring"
+	"founders-toolkit-api/internal/webhooks"
+	"founders-toolkit-api/models"
+	"log"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const JobKindBrandAnalysis = "brand_analysis"
+
+// brandRun is one brand workflow execution, either inside the request or in
+// the background for async requests.
+type brandRun struct {
+	db        *database.Service
+	auditor   *audit.Service
+	c         *gin.Context // only used for audit records
+	job       *jobs.Job
+	runID     int64 // workflow_runs row whose steps are reused on resume
+	user      models.User
+	site      models.Site
+	siteInput SiteInput
+	cfg       BrandWorkflowConfig
+	prompts   *prompts.Set // prompt versions pinned for the whole run
+
+	bypassCache bool // skip cached model responses
+}
+
+// brandRunOutcome is what the synchronous endpoint responds with; async
+// clients get it as the job result.
+type brandRunOutcome struct {
+	status    int
+	message   string
+	data      gin.H
+	jobStatus string // derived from status when empty
+	errClass  string // llm error class of a failed upstream call
+
+	brandAnalysisID int64 // set once the analysis row is saved
+}
+
+// upstreamFailure is the outcome of a failed OpenAI call.
+func upstreamFailure(prefix string, err error) brandRunOutcome {
+	var e *llm.Error
+	retryable := errors.As(err, &e) && (e.Retryable || e.Class == llm.ClassCircuitOpen)
+	class := llm.ClassOf(err)
+	return brandRunOutcome{
+		status:   llm.HTTPStatus(err),
+		message:  prefix + ": " + err.Error(),
+		data:     gin.H{"error_class": class, "retryable": retryable},
+		errClass: class,
+	}
+}
+
+// startWorkflowRun records a new running workflow of kind for the site and
+// the prompt versions it runs with.
+func startWorkflowRun(db *database.Service, userID, siteID int64, kind string, cfg any, set *prompts.Set) (int64, error) {
+	config, err := json.Marshal(cfg)
+	if err != nil {
+		return 0, err
+	}
+	versions, err := json.Marshal(set.Versions())
+	if err != nil {
+		return 0, err
+	}
+	run := models.WorkflowRun{
+		UserID:         userID,
+		SiteID:         siteID,
+		Kind:           kind,
+		Status:         models.RunStatusRunning,
+		Config:         models.JSONB(config),
+		PromptVersions: models.JSONB(versions),
+	}
+	if err := db.DB.Create(&run).Error; err != nil {
+		return 0, err
+	}
+	return run.ID, nil
+}
+
+// launch starts a job for the run and executes it, in the background when
+// async is set, and responds on c.
+func (r brandRun) launch(c *gin.Context, async bool) {
+	r.job = jobs.Default.Start(r.user.ID, JobKindBrandAnalysis)
+	r.c = c
+	r.db.DB.Model(&models.WorkflowRun{}).Where("id = ?", r.runID).Update("job_id", r.job.ID)
+
+	if async {
+		// gin contexts are recycled after the handler returns
+		r.c = c.Copy()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		r.job.SetCancel(cancel)
+		go func() {
+			defer cancel()
+			r.execute(ctx)
+		}()
+
+		response.Respond(c, http.StatusAccepted, "Brand workflow started", gin.H{
+			"job_id":     r.job.ID,
+			"run_id":     r.runID,
+			"status_url": "/jobs/" + r.job.ID,
+			"events_url": "/jobs/" + r.job.ID + "/events",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Minute)
+	defer cancel()
+	r.job.SetCancel(cancel)
+
+	out := r.execute(ctx)
+	response.Respond(c, out.status, out.message, out.data)
+}
+
+// finishRun stores the outcome on the workflow_runs row and the model calls
+// of this attempt, and returns the usage of all attempts of the run.
+func (r brandRun) finishRun(out brandRunOutcome, meter *llm.Meter) llm.Summary {
+	status := models.RunStatusCompleted
+	errMsg := ""
+	switch {
+	case out.jobStatus == jobs.StatusCancelled:
+		status = models.RunStatusCancelled
+	case out.status >= http.StatusBadRequest:
+		status = models.RunStatusFailed
+		errMsg = out.message
+	}
+	updates := map[string]any{
+		"status":      status,
+		"error":       errMsg,
+		"error_class": out.errClass,
+		"finished_at": time.Now().UTC(),
+	}
+	if out.brandAnalysisID != 0 {
+		updates["brand_analysis_id"] = out.brandAnalysisID
+		saveRunInputs(r.db, "brand_analyses", out.brandAnalysisID, r.prompts, r.cfg.Models)
+	}
+	if err := r.db.DB.Model(&models.WorkflowRun{}).Where("id = ?", r.runID).Updates(updates).Error; err != nil {
+		log.Printf("[BrandWorkflowHandler] update workflow run id=%d: %v", r.runID, err)
+	}
+
+	attempt := meter.Summary()
+	if err := meter.Save(r.db, llm.Owner{
+		UserID:          r.user.ID,
+		SiteID:          r.site.ID,
+		BrandAnalysisID: out.brandAnalysisID,
+		WorkflowRunID:   r.runID,
+	}); err != nil {
+		log.Printf("[BrandWorkflowHandler] save usage run id=%d: %v", r.runID, err)
+		return attempt
+	}
+	total, err := llm.SumCalls(r.db.DB.Where("workflow_run_id = ?", r.runID))
+	if err != nil {
+		return attempt
+	}
+	if out.brandAnalysisID != 0 {
+		b, _ := json.Marshal(total)
+		r.db.DB.Table("brand_analyses").Where("id = ?", out.brandAnalysisID).Update("usage", models.JSONB(b))
+	}
+	return total
+}
+
+// execute runs the workflow, stores the analysis and finishes the job.
+// Progress is published on the job as it happens.
+func (r brandRun) execute(ctx context.Context) (out brandRunOutcome) {
+	meter := llm.NewMeter()
+	ctx = llm.WithMeter(ctx, meter)
+	ctx = prompts.WithSet(ctx, r.prompts)
+	ctx = llm.WithModels(ctx, r.cfg.Models)
+
+	progressCh := make(chan ProgressEvent, 16)
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		for ev := range progressCh {
+			r.job.Publish(ev.Stage, ev)
+		}
+	}()
+
+	defer func() {
+		close(progressCh)
+		<-forwarded
+		if out.data == nil {
+			out.data = gin.H{}
+		}
+		out.data["job_id"] = r.job.ID
+		out.data["run_id"] = r.runID
+		out.data["usage"] = r.finishRun(out, meter)
+		switch {
+		case out.jobStatus != "":
+			jobs.Default.Finish(r.job, out.jobStatus, out.data, "")
+		case out.status >= http.StatusBadRequest:
+			jobs.Default.Finish(r.job, jobs.StatusFailed, out.data, out.message)
+		default:
+			jobs.Default.Finish(r.job, jobs.StatusCompleted, out.data, "")
+		}
+	}()
+
+	if r.bypassCache {
+		ctx = llm.WithoutCache(ctx)
+	}
+
+	cfg := r.cfg
+	cfg.Progress = progressCh
+	cfg.Stages = newRunStageStore(r.db, r.runID)
+	progress := newProgressTracker(progressCh)
+
+	client := llm.NewOpenAIClient()
+	log.Printf("[BrandWorkflowHandler] user=%d site_id=%d url=%s job=%s cfg=%+v",
+		r.user.ID, r.site.ID, r.site.URL, r.job.ID, cfg)
+
+	// --- run main workflow ---
+	analysis, err := RunFullBrandWorkflow(ctx, &client, r.siteInput, cfg)
+	if err != nil && r.job.Cancelled() {
+		return r.saveCancelled(analysis)
+	}
+	if err != nil {
+		log.Printf("[BrandWorkflowHandler] RunFullBrandWorkflow error: %v", err)
+		r.auditor.Record(r.c, audit.Entry{
+			Action:     audit.ActionScanFailed,
+			TargetType: audit.TargetSite,
+			TargetID:   audit.ID(r.site.ID),
+			Metadata:   map[string]any{"workflow": "brand", "error": err.Error()},
+		})
+		afterRunFailed(r.db, r.user.ID, r.site.ID, webhooks.EventBrandAnalysisFailed, err)
+		return upstreamFailure("openai error", err)
+	}
+
+	// --- scores (0–100) under the site's scoring profile ---
+	profile, err := scoring.Resolve(r.db, r.user.ID, r.site.ID)
+	if err != nil {
+		return brandRunOutcome{status: http.StatusInternalServerError, message: "failed to load scoring profile"}
+	}
+	scores := scoreBrandAnalysis(&analysis, r.siteInput, profile)
+
+	// --- collect all queries used ---
+	allQueries := collectAllQueries(analysis)
+
+	// --- generate suggestions (second OpenAI call) ---
+	var suggestions []string
+	if !loadStep(cfg.Stages, stepSuggestions, &suggestions) {
+		suggestions, err = GenerateSuggestionsForSite(ctx, &client, r.siteInput, analysis)
+		if err != nil && r.job.Cancelled() {
+			return r.saveCancelled(analysis)
+		}
+		if err != nil {
+			log.Printf("[BrandWorkflowHandler] GenerateSuggestionsForSite error: %v", err)
+			return upstreamFailure("suggestions error", err)
+		}
+		saveStep(cfg.Stages, stepSuggestions, suggestions)
+	}
+	progress.emit(ctx, ProgressEvent{Stage: StageSuggestionsGenerated, Count: len(suggestions)})
+
+	// --- marshal full FinalBrandAnalysis for storage ---
+	analysisBytes, err := json.Marshal(analysis)
+	if err != nil {
+		log.Printf("[BrandWorkflowHandler] json.Marshal analysis error: %v", err)
+		return brandRunOutcome{status: http.StatusInternalServerError, message: "marshal analysis failed: " + err.Error()}
+	}
+
+	// --- build and save BrandAnalysis row ---
+	ba := models.BrandAnalysis{
+		SiteID:            r.site.ID,
+		UserID:            r.user.ID,
+		DirectScore:       scores.Direct,
+		IntermediateScore: scores.Intermediate,
+		IndirectScore:     scores.Indirect,
+		VisibilityScore:   scores.Visibility,
+		Suggestions:       models.StringArray(suggestions),
+		Queries:           models.StringArray(allQueries),
+		Analysis:          models.JSONB(analysisBytes),
+	}
+
+	if err := r.db.DB.Table("brand_analyses").Create(&ba).Error; err != nil {
+		log.Printf("[BrandWorkflowHandler] DB create error: %v", err)
+		return brandRunOutcome{status: http.StatusInternalServerError, message: "brand analysis save failed: " + err.Error(), data: gin.H{
+			"analysis":    analysis,
+			"suggestions": suggestions,
+			"queries":     allQueries,
+		}}
+	}
+
+	updates := map[string]any{
+		"scoring_profile_id": profile.StoredID(),
+		"share_of_voice":     scores.ShareOfVoice,
+		"samples":            max(cfg.Samples, 1),
+		"score_intervals":    intervalsJSON(scores.Intervals),
+		"query_set_id":       querySetColumn(cfg.QuerySetID),
+	}
+	maps.Copy(updates, marketColumns(cfg.Market))
+	r.db.DB.Table("brand_analyses").Where("id = ?", ba.ID).Updates(updates)
+
+	afterBrandAnalysisSaved(r.db, ba, analysis, scores, r.siteInput)
+	progress.emit(ctx, ProgressEvent{Stage: StageSaved})
+
+	log.Printf(
+		"[BrandWorkflowHandler] saved brand_analyses id=%d site_id=%d user_id=%d scores={d=%.2f i=%.2f n=%.2f vis=%.2f} suggestions=%d queries=%d",
+		ba.ID, ba.SiteID, ba.UserID,
+		scores.Direct, scores.Intermediate, scores.Indirect, scores.Visibility,
+		len(suggestions), len(allQueries),
+	)
+
+	r.auditor.Record(r.c, audit.Entry{
+		Action:     audit.ActionBrandAnalysis,
+		TargetType: audit.TargetBrandAnalysis,
+		TargetID:   audit.ID(ba.ID),
+		Metadata: map[string]any{
+			"site_id":          r.site.ID,
+			"num_direct":       cfg.NumDirect,
+			"num_intermediate": cfg.NumIntermediate,
+			"num_indirect":     cfg.NumIndirect,
+			"samples":          max(cfg.Samples, 1),
+			"query_set_id":     cfg.QuerySetID,
+			"market":           cfg.Market,
+		},
+	})
+
+	// --- final response ---
+	return brandRunOutcome{status: http.StatusOK, message: "ok", brandAnalysisID: ba.ID, data: gin.H{
+		"brand_analysis_id": ba.ID,
+		"site_id":           r.site.ID,
+		"market":            cfg.Market,
+		"scores": gin.H{
+			"direct":         scores.Direct,
+			"intermediate":   scores.Intermediate,
+			"indirect":       scores.Indirect,
+			"visibility":     scores.Visibility,
+			"share_of_voice": scores.ShareOfVoice,
+			"intervals":      scores.Intervals,
+		},
+		"queries":     allQueries,
+		"suggestions": suggestions,
+		"analysis":    analysis,
+	}}
+}
+
+// saveCancelled stores whatever a cancelled run collected, scored as is and
+// marked cancelled. Follow-up hooks (competitors, alerts, webhooks) are
+// skipped since the analysis is incomplete.
+func (r brandRun) saveCancelled(analysis FinalBrandAnalysis) brandRunOutcome {
+	profile, err := scoring.Resolve(r.db, r.user.ID, r.site.ID)
+	if err != nil {
+		profile = scoring.Default()
+	}
+	scores := scoreBrandAnalysis(&analysis, r.siteInput, profile)
+	allQueries := collectAllQueries(analysis)
+
+	analysisBytes, err := json.Marshal(analysis)
+	if err != nil {
+		return brandRunOutcome{status: http.StatusInternalServerError, message: "marshal analysis failed: " + err.Error(), jobStatus: jobs.StatusCancelled}
+	}
+
+	ba := models.BrandAnalysis{
+		SiteID:            r.site.ID,
+		UserID:            r.user.ID,
+		DirectScore:       scores.Direct,
+		IntermediateScore: scores.Intermediate,
+		IndirectScore:     scores.Indirect,
+		VisibilityScore:   scores.Visibility,
+		Suggestions:       models.StringArray{},
+		Queries:           models.StringArray(allQueries),
+		Analysis:          models.JSONB(analysisBytes),
+	}
+	err = r.db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("brand_analyses").Create(&ba).Error; err != nil {
+			return err
+		}
+		updates := map[string]any{
+			"status":             models.AnalysisStatusCancelled,
+			"scoring_profile_id": profile.StoredID(),
+			"share_of_voice":     scores.ShareOfVoice,
+			"samples":            max(r.cfg.Samples, 1),
+			"score_intervals":    intervalsJSON(scores.Intervals),
+			"query_set_id":       querySetColumn(r.cfg.QuerySetID),
+		}
+		maps.Copy(updates, marketColumns(r.cfg.Market))
+		return tx.Table("brand_analyses").Where("id = ?", ba.ID).Updates(updates).Error
+	})
+	if err != nil {
+		log.Printf("[BrandWorkflowHandler] save cancelled analysis: %v", err)
+		return brandRunOutcome{status: http.StatusInternalServerError, message: "brand analysis save failed: " + err.Error(), data: gin.H{
+			"analysis": analysis,
+		}, jobStatus: jobs.StatusCancelled}
+	}
+
+	r.auditor.Record(r.c, audit.Entry{
+		Action:     audit.ActionScanCancelled,
+		TargetType: audit.TargetBrandAnalysis,
+		TargetID:   audit.ID(ba.ID),
+		Metadata:   map[string]any{"site_id": r.site.ID, "queries": len(allQueries)},
+	})
+
+	return brandRunOutcome{status: http.StatusOK, message: "Brand workflow cancelled", brandAnalysisID: ba.ID, data: gin.H{
+		"brand_analysis_id": ba.ID,
+		"site_id":           r.site.ID,
+		"status":            models.AnalysisStatusCancelled,
+		"scores":            scores,
+		"queries":           allQueries,
+		"analysis":          analysis,
+	}, jobStatus: jobs.StatusCancelled}
+}
@@ -0,0 +1,173 @@
+package scanmanager
+
+import (
+	"encoding/json"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/internal/scoring"
+	"founders-toolkit-api/internal/sites"
+	"founders-toolkit-api/models"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BrandScores struct {
+	Direct       float64 `json:"direct"`
+	Intermediate float64 `json:"intermediate"`
+	Indirect     float64 `json:"indirect"`
+	Visibility   float64 `json:"visibility"`
+	ShareOfVoice float64 `json:"share_of_voice"`
+	// Intervals is set when the queries were researched more than once.
+	Intervals *ScoreIntervals `json:"intervals,omitempty"`
+}
+
+// scoreBrandAnalysis annotates every query with the target site's presence
+// and scores each query type as the mean rank weight of the target's
+// position among the brands (0 when absent), scaled to 0-100. Only the first
+// profile.MaxBrands brands of a query are considered. ShareOfVoice is
+// the target's fraction of all brand appearances. Sampled analyses are
+// scored per sample, see scoreSamples.
+func scoreBrandAnalysis(analysis *FinalBrandAnalysis, site SiteInput, profile scoring.Profile) BrandScores {
+	if n := sampleCount(*analysis); n > 1 {
+		return scoreSamples(analysis, site, profile, n)
+	}
+	return scoreSingle(analysis, site, profile)
+}
+
+func scoreSingle(analysis *FinalBrandAnalysis, site SiteInput, profile scoring.Profile) BrandScores {
+	annotateTargetPresence(analysis, site, profile.MaxBrands)
+
+	var targetAppearances, totalAppearances int
+	groupScore := func(g QueryGroup) float64 {
+		if len(g.Queries) == 0 {
+			return 0
+		}
+		var sum float64
+		for _, q := range g.Queries {
+			brands := len(q.Brands)
+			if limit := profile.MaxBrands; limit > 0 && brands > limit {
+				brands = limit
+			}
+			totalAppearances += brands
+			if q.Target != nil && q.Target.Present {
+				targetAppearances++
+				sum += profile.RankWeight(q.Target.Position)
+			}
+		}
+		return sum / float64(len(g.Queries)) * 100.0
+	}
+
+	s := BrandScores{
+		Direct:       groupScore(analysis.Direct),
+		Intermediate: groupScore(analysis.Intermediate),
+		Indirect:     groupScore(analysis.Indirect),
+	}
+	s.Visibility = profile.Visibility(s.Direct, s.Intermediate, s.Indirect)
+	if totalAppearances > 0 {
+		s.ShareOfVoice = float64(targetAppearances) / float64(totalAppearances)
+	}
+	return s
+}
+
+// POST /sites/:id/brand-analyses/rescore  re-score stored analyses under a
+// profile (the site's active one unless profile_id is given). With dry_run
+// the new scores are returned without being saved.
+func RescoreBrandAnalyses(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+
+		var body struct {
+			ProfileID int64 `json:"profile_id"`
+			DryRun    bool  `json:"dry_run"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+
+		siteInput := siteInputFor(db, site)
+
+		var (
+			profile scoring.Profile
+			err     error
+		)
+		if body.ProfileID != 0 {
+			profile, err = scoring.Load(db, user.ID, body.ProfileID)
+		} else {
+			profile, err = scoring.Resolve(db, user.ID, site.ID)
+		}
+		if err != nil {
+			response.Respond(c, http.StatusNotFound, "scoring profile not found", nil)
+			return
+		}
+
+		var analyses []models.BrandAnalysis
+		if err := db.DB.Table("brand_analyses").
+			Where("site_id = ? AND user_id = ?", site.ID, user.ID).
+			Order("created_at DESC").
+			Find(&analyses).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load brand analyses", nil)
+			return
+		}
+
+		type rescored struct {
+			ID     int64       `json:"id"`
+			Before BrandScores `json:"before"`
+			After  BrandScores `json:"after"`
+		}
+		results := make([]rescored, 0, len(analyses))
+
+		for _, ba := range analyses {
+			var analysis FinalBrandAnalysis
+			if err := json.Unmarshal(ba.Analysis, &analysis); err != nil {
+				log.Printf("[RescoreBrandAnalyses] skip id=%d: %v", ba.ID, err)
+				continue
+			}
+
+			after := scoreBrandAnalysis(&analysis, siteInput, profile)
+			results = append(results, rescored{
+				ID: ba.ID,
+				Before: BrandScores{
+					Direct:       ba.DirectScore,
+					Intermediate: ba.IntermediateScore,
+					Indirect:     ba.IndirectScore,
+					Visibility:   ba.VisibilityScore,
+				},
+				After: after,
+			})
+
+			if body.DryRun {
+				continue
+			}
+			annotated, err := json.Marshal(analysis)
+			if err != nil {
+				response.Respond(c, http.StatusInternalServerError, "marshal analysis failed: "+err.Error(), nil)
+				return
+			}
+			if err := db.DB.Table("brand_analyses").Where("id = ?", ba.ID).Updates(map[string]any{
+				"direct_score":       after.Direct,
+				"intermediate_score": after.Intermediate,
+				"indirect_score":     after.Indirect,
+				"visibility_score":   after.Visibility,
+				"share_of_voice":     after.ShareOfVoice,
+				"score_intervals":    intervalsJSON(after.Intervals),
+				"scoring_profile_id": profile.StoredID(),
+				"analysis":           models.JSONB(annotated),
+			}).Error; err != nil {
+				response.Respond(c, http.StatusInternalServerError, "rescore save failed: "+err.Error(), nil)
+				return
+			}
+		}
+
+		response.Respond(c, http.StatusOK, "Brand analyses rescored", gin.H{
+			"profile":  profile,
+			"dry_run":  body.DryRun,
+			"analyses": results,
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package scanmanager
+
+import (
+	"context"
+	"founders-toolkit-api/internal/audit"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/engines"
+	"founders-toolkit-api/internal/llm"
+	"founders-toolkit-api/internal/plans"
+	"founders-toolkit-api/internal/prompts"
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/internal/scoring"
+	"founders-toolkit-api/models"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openai/openai-go/v3"
+)
+
+const JobKindEngineComparison = "engine_comparison"
+
+type EngineComparisonRequest struct {
+	URL string `json:"url" binding:"required"`
+	// Engines to ask; every configured engine when empty.
+	Engines []string `json:"engines"`
+
+	NumDirect       int `json:"num_direct"`
+	NumIntermediate int `json:"num_intermediate"`
+	NumIndirect     int `json:"num_indirect"`
+
+	BypassCache bool `json:"bypass_cache"`
+}
+
+// EngineAnswer is how one engine answered one query.
+type EngineAnswer struct {
+	Model     string          `json:"model"`
+	Brands    []BrandCitation `json:"brands"`
+	Citations []string        `json:"citations"`
+	Target    *TargetPresence `json:"target,omitempty"`
+	// TargetCited is set when the engine linked to one of the site's domains.
+	TargetCited bool   `json:"target_cited"`
+	Error       string `json:"error,omitempty"`
+}
+
+// EngineComparisonQuery is one query with the answer of every engine.
+type EngineComparisonQuery struct {
+	Type    QueryType               `json:"type"`
+	Query   string                  `json:"query"`
+	Engines map[string]EngineAnswer `json:"engines"`
+}
+
+// EngineSummary is the visibility of the site on one engine.
+type EngineSummary struct {
+	Engine       string   `json:"engine"`
+	Model        string   `json:"model"`
+	Answered     int      `json:"answered"`
+	Failed       int      `json:"failed"`
+	PresenceRate float64  `json:"presence_rate"`
+	CitationRate float64  `json:"citation_rate"`
+	AvgPosition  *float64 `json:"avg_position"` // nil when never present
+	ShareOfVoice float64  `json:"share_of_voice"`
+}
+
+type EngineComparison struct {
+	Engines []EngineSummary         `json:"engines"`
+	Queries []EngineComparisonQuery `json:"queries"`
+}
+
+// compareEngines asks every engine each query, extracts the brands of each
+// answer and locates the target among them. A failing engine is reported
+// per query rather than failing the comparison.
+func compareEngines(
+	ctx context.Context,
+	client *openai.Client,
+	site SiteInput,
+	queries map[QueryType][]string,
+	clients map[string]engines.Engine,
+	maxBrands int,
+) EngineComparison {
+	m := newTargetMatcher(site)
+	out := EngineComparison{Queries: []EngineComparisonQuery{}}
+
+	for _, qType := range []QueryType{QueryTypeDirect, QueryTypeIntermediate, QueryTypeIndirect} {
+		for _, query := range queries[qType] {
+			row := EngineComparisonQuery{Type: qType, Query: query, Engines: map[string]EngineAnswer{}}
+
+			var mu sync.Mutex
+			var wg sync.WaitGroup
+			for name, e := range clients {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					ans := askEngine(ctx, client, e, query, m, maxBrands)
+					mu.Lock()
+					row.Engines[name] = ans
+					mu.Unlock()
+				}()
+			}
+			wg.Wait()
+			out.Queries = append(out.Queries, row)
+		}
+	}
+
+	for _, name := range engines.Available(clients) {
+		out.Engines = append(out.Engines, summarizeEngine(name, clients[name].Model(), out.Queries))
+	}
+	return out
+}
+
+func askEngine(ctx context.Context, client *openai.Client, e engines.Engine, query string, m targetMatcher, maxBrands int) EngineAnswer {
+	ans := EngineAnswer{Model: e.Model(), Brands: []BrandCitation{}, Citations: []string{}}
+
+	answer, err := engines.Query(ctx, e, query)
+	if err != nil {
+		log.Printf("[compareEngines] engine=%s query=%q: %v", e.Name(), query, err)
+		ans.Error = err.Error()
+		return ans
+	}
+	ans.Citations = answer.Citations
+	ans.TargetCited = m.cited(answer.Citations)
+
+	if answer.Text != "" {
+		brands, err := ExtractBrandsFromResearchText(ctx, client, query, answer.Text)
+		if err != nil {
+			ans.Error = "brand extraction failed: " + err.Error()
+			return ans
+		}
+		ans.Brands = brands
+	}
+	brands := ans.Brands
+	if maxBrands > 0 && len(brands) > maxBrands {
+		brands = brands[:maxBrands]
+	}
+	ans.Target = m.presence(brands)
+	return ans
+}
+
+func summarizeEngine(name, model string, queries []EngineComparisonQuery) EngineSummary {
+	s := EngineSummary{Engine: name, Model: model}
+	var present, cited, positions int
+	var sov float64
+	for _, q := range queries {
+		ans := q.Engines[name]
+		if ans.Target == nil {
+			s.Failed++
+			continue
+		}
+		s.Answered++
+		if ans.Target.Present {
+			present++
+			positions += ans.Target.Position
+			sov += ans.Target.ShareOfVoice
+		}
+		if ans.TargetCited {
+			cited++
+		}
+	}
+	if s.Answered > 0 {
+		s.PresenceRate = float64(present) / float64(s.Answered)
+		s.CitationRate = float64(cited) / float64(s.Answered)
+		s.ShareOfVoice = sov / float64(s.Answered)
+	}
+	if present > 0 {
+		avg := float64(positions) / float64(present)
+		s.AvgPosition = &avg
+	}
+	return s
+}
+
+// POST /scans/engines  asks several answer engines the same generated
+// queries and reports the site's presence and citations per engine.
+func EngineComparisonHandler(db *database.Service, auditor *audit.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uRaw, _ := c.Get("user")
+		user, _ := uRaw.(models.User)
+		if user.ID == 0 {
+			response.Respond(c, http.StatusUnauthorized, "unauthorized", nil)
+			return
+		}
+
+		var req EngineComparisonRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		req.NumDirect = max(req.NumDirect, 1)
+		req.NumIntermediate = max(req.NumIntermediate, 1)
+		req.NumIndirect = max(req.NumIndirect, 1)
+
+		configured := engines.FromEnv()
+		if len(req.Engines) == 0 {
+			req.Engines = engines.Available(configured)
+		}
+		clients := map[string]engines.Engine{}
+		for _, name := range req.Engines {
+			e, ok := configured[name]
+			if !ok {
+				response.Respond(c, http.StatusBadRequest, "engine not available: "+name, gin.H{
+					"available": engines.Available(configured),
+				})
+				return
+			}
+			clients[name] = e
+		}
+		if len(clients) == 0 {
+			response.Respond(c, http.StatusServiceUnavailable, "no answer engines configured", nil)
+			return
+		}
+
+		var site models.Site
+		if err := db.DB.Table("sites").
+			Where("user_id = ? AND url = ?", user.ID, req.URL).
+			First(&site).Error; err != nil || site.ID == 0 {
+			response.Respond(c, http.StatusNotFound, "site not found", nil)
+			return
+		}
+
+		if !plans.Enforce(c, db, user, plans.Request{
+			SiteID:         site.ID,
+			QueriesPerType: max(req.NumDirect, req.NumIntermediate, req.NumIndirect),
+		}) {
+			return
+		}
+
+		profile, err := scoring.Resolve(db, user.ID, site.ID)
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load scoring profile", nil)
+			return
+		}
+
+		promptSet := prompts.Pick()
+		runID, err := startWorkflowRun(db, user.ID, site.ID, JobKindEngineComparison, req, promptSet)
+		if err != nil {
+			log.Printf("[EngineComparisonHandler] create workflow run: %v", err)
+			response.Respond(c, http.StatusInternalServerError, "failed to start engine comparison", nil)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Minute)
+		defer cancel()
+		if req.BypassCache {
+			ctx = llm.WithoutCache(ctx)
+		}
+		meter := llm.NewMeter()
+		ctx = llm.WithMeter(ctx, meter)
+		ctx = prompts.WithSet(ctx, promptSet)
+
+		siteInput := siteInputFor(db, site)
+		client := llm.NewOpenAIClient()
+
+		queries := map[QueryType][]string{}
+		counts := map[QueryType]int{
+			QueryTypeDirect:       req.NumDirect,
+			QueryTypeIntermediate: req.NumIntermediate,
+			QueryTypeIndirect:     req.NumIndirect,
+		}
+		for _, qType := range []QueryType{QueryTypeDirect, QueryTypeIntermediate, QueryTypeIndirect} {
+			qs, err := GenerateQueriesForType(ctx, &client, siteInput, qType, counts[qType])
+			if err != nil {
+				usage := finishEngineRun(db, runID, user.ID, site.ID, meter, err)
+				respondLLMError(c, "generate "+string(qType)+" queries", err, gin.H{"run_id": runID, "usage": usage})
+				return
+			}
+			queries[qType] = qs
+		}
+
+		result := compareEngines(ctx, &client, siteInput, queries, clients, profile.MaxBrands)
+		usage := finishEngineRun(db, runID, user.ID, site.ID, meter, nil)
+
+		auditor.Record(c, audit.Entry{
+			Action:     audit.ActionEngineCompare,
+			TargetType: audit.TargetSite,
+			TargetID:   audit.ID(site.ID),
+			Metadata:   map[string]any{"run_id": runID, "engines": engines.Available(clients)},
+		})
+		response.Respond(c, http.StatusOK, "Engine comparison completed", gin.H{
+			"run_id":  runID,
+			"engines": result.Engines,
+			"queries": result.Queries,
+			"usage":   usage,
+		})
+	}
+}
+
+// finishEngineRun stores the outcome and model calls of an engine
+// comparison run.
+func finishEngineRun(db *database.Service, runID, userID, siteID int64, meter *llm.Meter, runErr error) llm.Summary {
+	updates := map[string]any{
+		"status":      models.RunStatusCompleted,
+		"finished_at": time.Now().UTC(),
+	}
+	if runErr != nil {
+		updates["status"] = models.RunStatusFailed
+		updates["error"] = runErr.Error()
+		updates["error_class"] = llm.ClassOf(runErr)
+	}
+	if err := db.DB.Model(&models.WorkflowRun{}).Where("id = ?", runID).Updates(updates).Error; err != nil {
+		log.Printf("[EngineComparisonHandler] update workflow run id=%d: %v", runID, err)
+	}
+	if err := meter.Save(db, llm.Owner{UserID: userID, SiteID: siteID, WorkflowRunID: runID}); err != nil {
+		log.Printf("[EngineComparisonHandler] save usage run id=%d: %v", runID, err)
+	}
+	return meter.Summary()
+}
@@ -0,0 +1,132 @@
+package scanmanager
+
+import (
+	"context"
+	"encoding/json"
+	"founders-toolkit-api/internal/engines"
+	"founders-toolkit-api/internal/engines/enginetest"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// brandsInNotes stands in for brand extraction: it lists the known brands
+// named in the research notes of the prompt, in the order they appear.
+func brandsInNotes(input string) string {
+	known := []BrandCitation{
+		{Name: "Acme", URL: "https://acme.io"},
+		{Name: "Rival", URL: "https://rival.com"},
+		{Name: "Other", URL: "https://other.dev"},
+	}
+	_, notes, _ := strings.Cut(input, "Research notes:")
+
+	var found []BrandCitation
+	for _, b := range known {
+		if strings.Contains(notes, b.Name) {
+			found = append(found, b)
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return strings.Index(notes, found[i].Name) < strings.Index(notes, found[j].Name)
+	})
+	out, _ := json.Marshal(map[string]any{"brands": found})
+	return string(out)
+}
+
+func TestCompareEngines(t *testing.T) {
+	client, closeFake := fakeOpenAI(brandsInNotes)
+	defer closeFake()
+
+	srv := enginetest.NewServer()
+	defer srv.Close()
+	srv.SetAnswer(engines.OpenAI, "best crm", enginetest.Canned{
+		Text:      "Rival leads, then Acme.",
+		Citations: []string{"https://acme.io/pricing", "https://rival.com", "https://acme.io/pricing"},
+	})
+	srv.SetAnswer(engines.OpenAI, "crm for startups", enginetest.Canned{Text: "Acme first."})
+	srv.SetAnswer(engines.Anthropic, "best crm", enginetest.Canned{
+		Text:      "Acme is the top pick.",
+		Citations: []string{"https://g2.com/acme"},
+	})
+	srv.SetAnswer(engines.Gemini, "best crm", enginetest.Canned{
+		Text:      "Rival and Other.",
+		Citations: []string{"https://www.acme.io/blog"},
+	})
+	srv.SetStatus(engines.Perplexity, http.StatusBadRequest)
+
+	site := SiteInput{Name: "Acme", URL: "https://acme.io"}
+	queries := map[QueryType][]string{
+		QueryTypeDirect:       {"best crm"},
+		QueryTypeIntermediate: {"crm for startups"},
+	}
+	result := compareEngines(context.Background(), client, site, queries, srv.Engines(), 0)
+
+	if len(result.Queries) != 2 {
+		t.Fatalf("queries = %d, want 2", len(result.Queries))
+	}
+	for _, name := range engines.Names {
+		if got := srv.Queries(name); !reflect.DeepEqual(got, []string{"best crm", "crm for startups"}) {
+			t.Errorf("%s received %q, want both queries", name, got)
+		}
+	}
+
+	type presence struct {
+		present  bool
+		position int
+		cited    bool
+	}
+	answers := []struct {
+		engine    string
+		query     int
+		want      presence
+		citations []string
+	}{
+		{engines.OpenAI, 0, presence{true, 2, true}, []string{"https://acme.io/pricing", "https://rival.com"}},
+		{engines.OpenAI, 1, presence{true, 1, false}, []string{}},
+		{engines.Anthropic, 0, presence{true, 1, false}, []string{"https://g2.com/acme"}},
+		{engines.Anthropic, 1, presence{false, 0, false}, []string{}},
+		{engines.Gemini, 0, presence{false, 0, true}, []string{"https://www.acme.io/blog"}},
+		{engines.Gemini, 1, presence{false, 0, false}, []string{}},
+	}
+	for _, tt := range answers {
+		ans := result.Queries[tt.query].Engines[tt.engine]
+		if ans.Error != "" || ans.Target == nil {
+			t.Errorf("%s query %d: error %q, target %v", tt.engine, tt.query, ans.Error, ans.Target)
+			continue
+		}
+		got := presence{ans.Target.Present, ans.Target.Position, ans.TargetCited}
+		if got != tt.want {
+			t.Errorf("%s query %d: presence = %+v, want %+v", tt.engine, tt.query, got, tt.want)
+		}
+		if !reflect.DeepEqual(ans.Citations, tt.citations) {
+			t.Errorf("%s query %d: citations = %q, want %q", tt.engine, tt.query, ans.Citations, tt.citations)
+		}
+	}
+	if by := result.Queries[0].Engines[engines.OpenAI].Target.MatchedBy; by != TargetMatchURL {
+		t.Errorf("matched by %q, want %q", by, TargetMatchURL)
+	}
+	for _, q := range result.Queries {
+		if ans := q.Engines[engines.Perplexity]; ans.Error == "" || ans.Target != nil {
+			t.Errorf("perplexity %q: error %q, target %v, want a failure", q.Query, ans.Error, ans.Target)
+		}
+	}
+
+	avg := func(v float64) *float64 { return &v }
+	want := []EngineSummary{
+		{Engine: engines.Anthropic, Answered: 2, PresenceRate: 0.5, CitationRate: 0, AvgPosition: avg(1), ShareOfVoice: 0.5},
+		{Engine: engines.Gemini, Answered: 2, PresenceRate: 0, CitationRate: 0.5},
+		{Engine: engines.OpenAI, Answered: 2, PresenceRate: 1, CitationRate: 0.5, AvgPosition: avg(1.5), ShareOfVoice: 0.75},
+		{Engine: engines.Perplexity, Failed: 2},
+	}
+	if len(result.Engines) != len(want) {
+		t.Fatalf("engine summaries = %d, want %d", len(result.Engines), len(want))
+	}
+	for i, got := range result.Engines {
+		want[i].Model = got.Model
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("summary %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
@@ -1,19 +1,26 @@
 package scanmanager
 
 import (
-	"fmt"
+	"founders-toolkit-api/internal/prompts"
 	"strings"
 )
 
-// Build the site context as text for prompts.
-func buildSiteContext(site SiteInput) string {
-	return fmt.Sprintf(
-		"Site:\n- Name: %s\n- URL: %s\n- Description: %s\n- Language: %s\n",
-		site.Name,
-		site.URL,
-		site.Description,
-		site.Language,
-	)
+// promptSite is the site as the prompt templates see it. A market's locale
+// takes precedence over the site language.
+func promptSite(site SiteInput) prompts.Site {
+	ps := prompts.Site{
+		Name:        site.Name,
+		URL:         site.URL,
+		Description: site.Description,
+		Language:    site.Language,
+	}
+	if m := site.Market; m != nil {
+		ps.Country, ps.Region = m.Country, m.Region
+		if m.Locale != "" {
+			ps.Language = m.Locale
+		}
+	}
+	return ps
 }
 
 // Extract JSON portion from model output (similar to your trimToBalancedJSON/strip fences).
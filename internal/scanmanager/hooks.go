@@ -0,0 +1,189 @@
+package scanmanager
+
+import (
+	"context"
+	"encoding/json"
+	"founders-toolkit-api/internal/alerts"
+	"founders-toolkit-api/internal/competitors"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/llm"
+	"founders-toolkit-api/internal/prompts"
+	"founders-toolkit-api/internal/webhooks"
+	"founders-toolkit-api/models"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// afterBrandAnalysisSaved runs the follow-up work for a stored brand
+// analysis. Failures are logged; the analysis itself is already persisted.
+func afterBrandAnalysisSaved(db *database.Service, ba models.BrandAnalysis, analysis FinalBrandAnalysis, scores BrandScores, site SiteInput) {
+	apps := competitorAppearances(analysis, site)
+	if err := competitors.Record(db, ba.SiteID, ba.UserID, ba.ID, ba.CreatedAt, apps); err != nil {
+		log.Printf("[afterBrandAnalysisSaved] record competitors brand_analysis_id=%d: %v", ba.ID, err)
+	}
+
+	directPresent := 0
+	for _, q := range analysis.Direct.Queries {
+		if q.Target != nil && q.Target.Present {
+			directPresent++
+		}
+	}
+	// alert delivery talks to external endpoints; keep it off the request
+	go alerts.Evaluate(context.Background(), db, alerts.Observation{
+		Source:        models.AlertSourceBrand,
+		SourceID:      ba.ID,
+		SiteID:        ba.SiteID,
+		UserID:        ba.UserID,
+		SiteName:      site.Name,
+		Visibility:    ba.VisibilityScore,
+		DirectPresent: directPresent,
+		CreatedAt:     ba.CreatedAt,
+	})
+
+	webhooks.Dispatch(db, ba.UserID, webhooks.EventBrandAnalysisCompleted, map[string]any{
+		"brand_analysis_id": ba.ID,
+		"site_id":           ba.SiteID,
+		"scores":            scores,
+		"queries":           len(ba.Queries),
+		"created_at":        ba.CreatedAt,
+	})
+}
+
+// afterScanSaved runs the follow-up work for a stored SEO scan.
+func afterScanSaved(db *database.Service, scan models.Scan, site SiteInput) {
+	go alerts.Evaluate(context.Background(), db, alerts.Observation{
+		Source:     models.AlertSourceScan,
+		SourceID:   scan.ID,
+		SiteID:     scan.SiteID,
+		UserID:     scan.UserID,
+		SiteName:   site.Name,
+		Visibility: scan.VisibilityScore,
+		CreatedAt:  scan.CreatedAt,
+	})
+
+	webhooks.Dispatch(db, scan.UserID, webhooks.EventScanCompleted, map[string]any{
+		"scan_id": scan.ID,
+		"site_id": scan.SiteID,
+		"scores": map[string]float64{
+			"direct":       scan.Score1,
+			"intermediate": scan.Score2,
+			"indirect":     scan.Score3,
+			"visibility":   scan.VisibilityScore,
+		},
+		"created_at": scan.CreatedAt,
+	})
+}
+
+// afterRunFailed notifies subscribers that a scan or brand workflow failed.
+func afterRunFailed(db *database.Service, userID, siteID int64, event string, runErr error) {
+	webhooks.Dispatch(db, userID, event, map[string]any{
+		"site_id": siteID,
+		"error":   runErr.Error(),
+	})
+}
+
+// competitorAppearances lists every non-target brand of the analysis with
+// its position relative to the target site.
+func competitorAppearances(analysis FinalBrandAnalysis, site SiteInput) []competitors.Appearance {
+	m := newTargetMatcher(site)
+	groups := []struct {
+		qType QueryType
+		group QueryGroup
+	}{
+		{QueryTypeDirect, analysis.Direct},
+		{QueryTypeIntermediate, analysis.Intermediate},
+		{QueryTypeIndirect, analysis.Indirect},
+	}
+
+	var apps []competitors.Appearance
+	for _, g := range groups {
+		for _, q := range g.group.Queries {
+			targetPos := 0
+			if q.Target != nil && q.Target.Present {
+				targetPos = q.Target.Position
+			}
+			for i, b := range q.Brands {
+				if ok, _ := m.match(b); ok {
+					continue
+				}
+				apps = append(apps, competitors.Appearance{
+					Name:           b.Name,
+					URL:            b.URL,
+					Domain:         registrableDomain(b.URL),
+					QueryType:      string(g.qType),
+					Query:          q.Query,
+					Position:       i + 1,
+					TargetPosition: targetPos,
+					Citations:      b.Citations,
+				})
+			}
+		}
+	}
+	return apps
+}
+
+// recordFailedScan stores a failed scan with the classified reason so the
+// failure shows up in the site's scan history. Returns 0 if saving fails.
+func recordFailedScan(db *database.Service, userID, siteID int64, runErr error) int64 {
+	scan := models.Scan{
+		SiteID:      siteID,
+		UserID:      userID,
+		Failed:      true,
+		Keywords:    models.StringArray{},
+		Suggestions: models.StringArray{},
+		Citations:   models.StringArray{},
+		Queries:     models.StringArray{},
+	}
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&scan).Error; err != nil {
+			return err
+		}
+		return tx.Model(&scan).Updates(map[string]any{
+			"failure_class":  llm.ClassOf(runErr),
+			"failure_reason": runErr.Error(),
+		}).Error
+	})
+	if err != nil {
+		log.Printf("[recordFailedScan] site_id=%d: %v", siteID, err)
+		return 0
+	}
+	return scan.ID
+}
+
+// saveScanUsage stores the model calls of a scan and their total on the
+// scan row. scanID is 0 when the scan itself could not be saved.
+func saveScanUsage(db *database.Service, meter *llm.Meter, userID, siteID, scanID int64) llm.Summary {
+	summary := meter.Summary()
+	if err := meter.Save(db, llm.Owner{UserID: userID, SiteID: siteID, ScanID: scanID}); err != nil {
+		log.Printf("[saveScanUsage] site_id=%d scan_id=%d: %v", siteID, scanID, err)
+	}
+	if scanID != 0 {
+		b, _ := json.Marshal(summary)
+		db.DB.Table("scans").Where("id = ?", scanID).Update("usage", models.JSONB(b))
+	}
+	return summary
+}
+
+// saveRunInputs records the prompt versions and the model of each step that
+// produced a row of table, so the result can be reproduced.
+func saveRunInputs(db *database.Service, table string, id int64, set *prompts.Set, stepModels llm.Models) {
+	if id == 0 {
+		return
+	}
+	updates := map[string]any{}
+	if set != nil {
+		b, _ := json.Marshal(set.Versions())
+		updates["prompt_versions"] = models.JSONB(b)
+	}
+	if stepModels != nil {
+		b, _ := json.Marshal(stepModels)
+		updates["step_models"] = models.JSONB(b)
+	}
+	if len(updates) == 0 {
+		return
+	}
+	if err := db.DB.Table(table).Where("id = ?", id).Updates(updates).Error; err != nil {
+		log.Printf("[saveRunInputs] %s id=%d: %v", table, id, err)
+	}
+}
@@ -0,0 +1,258 @@
+package scanmanager
+
+import (
+	"database/sql"
+	"fmt"
+	"founders-toolkit-api/internal/analytics"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/internal/sites"
+	"founders-toolkit-api/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openai/openai-go/v3/packages/param"
+	"github.com/openai/openai-go/v3/responses"
+	"gorm.io/gorm"
+)
+
+// Market is where a site's customers search from. It localizes generated
+// queries and is passed to web search as the approximate user location.
+type Market struct {
+	Country string `json:"country"`          // ISO 3166-1 alpha-2, e.g. "DE"
+	Region  string `json:"region,omitempty"` // free text, e.g. "Bavaria"
+	Locale  string `json:"locale,omitempty"` // BCP 47, e.g. "de-DE"
+}
+
+// Key identifies the market within a site: the country, or country/region.
+func (m Market) Key() string {
+	if m.Region == "" {
+		return m.Country
+	}
+	return m.Country + "/" + m.Region
+}
+
+func normalizeMarket(m Market) (Market, error) {
+	m.Country = strings.ToUpper(strings.TrimSpace(m.Country))
+	m.Region = strings.TrimSpace(m.Region)
+	m.Locale = strings.ReplaceAll(strings.TrimSpace(m.Locale), "_", "-")
+
+	if len(m.Country) != 2 || !isLetters(m.Country) {
+		return m, fmt.Errorf("country must be a two letter ISO code")
+	}
+	if len(m.Region) > 100 {
+		return m, fmt.Errorf("region is too long")
+	}
+	if m.Locale != "" {
+		lang, _, _ := strings.Cut(m.Locale, "-")
+		if len(m.Locale) > 35 || len(lang) < 2 || len(lang) > 3 || !isLetters(lang) {
+			return m, fmt.Errorf("locale must look like de or de-DE")
+		}
+	}
+	return m, nil
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// webSearchLocation is the web_search user_location of a market; the zero
+// value, which is omitted from requests, without one.
+func webSearchLocation(m *Market) responses.WebSearchToolUserLocationParam {
+	if m == nil {
+		return responses.WebSearchToolUserLocationParam{}
+	}
+	loc := responses.WebSearchToolUserLocationParam{
+		Type:    "approximate",
+		Country: param.NewOpt(m.Country),
+	}
+	if m.Region != "" {
+		loc.Region = param.NewOpt(m.Region)
+	}
+	return loc
+}
+
+// marketColumns are the country, region and locale columns of a scan or
+// brand analysis run for m.
+func marketColumns(m *Market) map[string]any {
+	if m == nil {
+		return map[string]any{"country": nil, "region": nil, "locale": nil}
+	}
+	cols := map[string]any{"country": m.Country, "region": nil, "locale": nil}
+	if m.Region != "" {
+		cols["region"] = m.Region
+	}
+	if m.Locale != "" {
+		cols["locale"] = m.Locale
+	}
+	return cols
+}
+
+// resolveMarket picks the market a run targets: the site's primary market
+// when key is empty, otherwise the site market with that key.
+func resolveMarket(db *database.Service, site SiteInput, siteID int64, key string) (*Market, error) {
+	if key == "" {
+		return site.Market, nil
+	}
+	var rows []models.SiteMarket
+	if err := db.DB.Where("site_id = ?", siteID).Order("position").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	available := make([]string, 0, len(rows))
+	for _, r := range rows {
+		m := Market{Country: r.Country, Region: r.Region, Locale: r.Locale}
+		if strings.EqualFold(m.Key(), key) {
+			return &m, nil
+		}
+		available = append(available, m.Key())
+	}
+	return nil, fmt.Errorf("market %q is not one of the site's markets %v", key, available)
+}
+
+// GET /sites/:id/markets
+func ListSiteMarkets(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+
+		var markets []models.SiteMarket
+		if err := db.DB.Where("site_id = ?", site.ID).Order("position").Find(&markets).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load markets", nil)
+			return
+		}
+		response.Respond(c, http.StatusOK, "Markets loaded", markets)
+	}
+}
+
+// PUT /sites/:id/markets  replaces the site's markets; the first one is the
+// primary market used when a run names none. An empty list removes the
+// location context.
+func UpdateSiteMarkets(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+
+		var body struct {
+			Markets []Market `json:"markets"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+
+		rows := []models.SiteMarket{}
+		seen := map[string]bool{}
+		for _, m := range body.Markets {
+			m, err := normalizeMarket(m)
+			if err != nil {
+				response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+				return
+			}
+			if seen[strings.ToLower(m.Key())] {
+				response.Respond(c, http.StatusBadRequest, "duplicate market: "+m.Key(), nil)
+				return
+			}
+			seen[strings.ToLower(m.Key())] = true
+			rows = append(rows, models.SiteMarket{
+				SiteID:   site.ID,
+				Country:  m.Country,
+				Region:   m.Region,
+				Locale:   m.Locale,
+				Position: len(rows),
+			})
+		}
+
+		var primary *Market
+		if len(rows) > 0 {
+			primary = &Market{Country: rows[0].Country, Region: rows[0].Region, Locale: rows[0].Locale}
+		}
+		err := db.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("site_id = ?", site.ID).Delete(&models.SiteMarket{}).Error; err != nil {
+				return err
+			}
+			if len(rows) > 0 {
+				if err := tx.Create(&rows).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Table("sites").Where("id = ?", site.ID).Updates(marketColumns(primary)).Error
+		})
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "markets save failed", nil)
+			return
+		}
+		response.Respond(c, http.StatusOK, "Markets saved", rows)
+	}
+}
+
+// MarketVisibility is the brand visibility of a site in one market.
+type MarketVisibility struct {
+	Country            string    `json:"country"`
+	Region             string    `json:"region,omitempty"`
+	Analyses           int       `json:"analyses"`
+	AvgVisibility      float64   `json:"avg_visibility"`
+	LatestVisibility   float64   `json:"latest_visibility"`
+	LatestShareOfVoice float64   `json:"latest_share_of_voice"`
+	LastRunAt          time.Time `json:"last_run_at"`
+}
+
+// GET /sites/:id/markets/visibility?from=&to=  brand visibility per market
+// over the completed brand analyses of the window (RFC3339, the last 90
+// days by default). Analyses run without a market have an empty country.
+func SiteMarketVisibility(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+
+		r, err := analytics.ParseRange("", c.Query("from"), c.Query("to"))
+		if err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+
+		rows := []MarketVisibility{}
+		err = db.DB.Raw(`
+			SELECT COALESCE(country, '') AS country,
+			       COALESCE(region, '') AS region,
+			       COUNT(*) AS analyses,
+			       AVG(visibility_score) AS avg_visibility,
+			       (ARRAY_AGG(visibility_score ORDER BY created_at DESC))[1] AS latest_visibility,
+			       (ARRAY_AGG(COALESCE(share_of_voice, 0) ORDER BY created_at DESC))[1] AS latest_share_of_voice,
+			       MAX(created_at) AS last_run_at
+			FROM brand_analyses
+			WHERE site_id = @site AND user_id = @uid
+			  AND status = @completed
+			  AND created_at >= @from AND created_at < @to
+			GROUP BY 1, 2
+			ORDER BY 1, 2`,
+			sql.Named("site", site.ID),
+			sql.Named("uid", user.ID),
+			sql.Named("completed", models.AnalysisStatusCompleted),
+			sql.Named("from", r.From),
+			sql.Named("to", r.To),
+		).Scan(&rows).Error
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load market visibility", nil)
+			return
+		}
+
+		response.Respond(c, http.StatusOK, "Market visibility loaded", gin.H{
+			"from":    r.From,
+			"to":      r.To,
+			"markets": rows,
+		})
+	}
+}
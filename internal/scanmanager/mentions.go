@@ -0,0 +1,185 @@
+package scanmanager
+
+import (
+	"founders-toolkit-api/internal/scoring"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+const (
+	MentionReasonDomain      = "domain"
+	MentionReasonBrandInText = "brand_in_text"
+)
+
+// multiLabelSuffixes are public suffixes made of two labels, for which the
+// registrable domain keeps three labels (e.g. "acme.co.uk").
+var multiLabelSuffixes = map[string]bool{
+	"co.uk": true, "org.uk": true, "ac.uk": true, "gov.uk": true,
+	"com.au": true, "net.au": true, "org.au": true,
+	"co.nz": true, "co.jp": true, "co.kr": true, "co.in": true, "co.za": true,
+	"com.br": true, "com.tr": true, "com.mx": true, "com.ar": true, "com.cn": true,
+	"com.sg": true, "com.hk": true, "com.tw": true,
+}
+
+// registrableDomain returns the domain a brand would register for rawURL,
+// e.g. "https://docs.acme-tools.co.uk/x" -> "acme-tools.co.uk". Bare hosts
+// without a scheme are accepted.
+func registrableDomain(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return ""
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	labels := strings.Split(strings.TrimSuffix(strings.ToLower(parsed.Hostname()), "."), ".")
+	if len(labels) < 2 {
+		return strings.Join(labels, ".")
+	}
+	keep := 2
+	if len(labels) >= 3 && multiLabelSuffixes[strings.Join(labels[len(labels)-2:], ".")] {
+		keep = 3
+	}
+	if keep > len(labels) {
+		keep = len(labels)
+	}
+	return strings.Join(labels[len(labels)-keep:], ".")
+}
+
+// domainRoot is the registrable domain without its public suffix,
+// e.g. "acme-tools.co.uk" -> "acme-tools".
+func domainRoot(registrable string) string {
+	root, _, _ := strings.Cut(registrable, ".")
+	return root
+}
+
+// genericWords are words of multi-word brand names that say nothing about
+// the brand on their own; "tools" in "Acme Tools" would match any result
+// about tools.
+var genericWords = map[string]bool{
+	"app": true, "apps": true, "tool": true, "tools": true, "software": true,
+	"labs": true, "lab": true, "studio": true, "cloud": true, "data": true,
+	"online": true, "digital": true, "group": true, "solutions": true,
+	"systems": true, "tech": true, "technologies": true, "platform": true,
+	"services": true, "media": true, "agency": true, "web": true, "hub": true,
+	"pro": true, "shop": true, "store": true, "the": true, "and": true,
+	"inc": true, "ltd": true, "llc": true, "gmbh": true, "company": true,
+	"get": true, "try": true, "use": true,
+}
+
+// BrandTokens derives the lowercased tokens that identify the site in result
+// text: the full name, its distinctive words, the registrable domain root
+// and their joined, hyphenated and spaced variants.
+// "Acme Tools" + "https://www.acme-tools.io" ->
+// acme tools, acme, acmetools, acme-tools.
+func BrandTokens(site SiteInput) []string {
+	var tokens []string
+	add := func(t string) {
+		t = strings.TrimSpace(t)
+		if len([]rune(t)) < 3 {
+			return
+		}
+		tokens = appendUnique(tokens, t)
+	}
+	addVariants := func(words []string) {
+		if len(words) == 0 {
+			return
+		}
+		add(strings.Join(words, " "))
+		for _, w := range words {
+			if len(words) > 1 && genericWords[w] {
+				continue
+			}
+			add(w)
+		}
+		if len(words) > 1 {
+			add(strings.Join(words, ""))
+			add(strings.Join(words, "-"))
+		}
+	}
+
+	addVariants(splitWords(strings.ToLower(site.Name)))
+	addVariants(splitWords(domainRoot(registrableDomain(site.URL))))
+
+	return tokens
+}
+
+func splitWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// containsToken reports whether token occurs in text on word boundaries,
+// so "acme" matches "Acme's pricing" but not "acmeville".
+func containsToken(text, token string) bool {
+	text = strings.ToLower(text)
+	for offset := 0; offset < len(text); {
+		idx := strings.Index(text[offset:], token)
+		if idx < 0 {
+			return false
+		}
+		start := offset + idx
+		end := start + len(token)
+		if isBoundary(text, start-1) && isBoundary(text, end) {
+			return true
+		}
+		offset = start + 1
+	}
+	return false
+}
+
+func isBoundary(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return true
+	}
+	c := rune(s[i])
+	return c < 0x80 && !unicode.IsLetter(c) && !unicode.IsDigit(c)
+}
+
+// detectMention decides whether a single search result is about the site:
+// its domain is the site's registrable domain (or a subdomain of it), or its
+// title or snippet contains a brand token.
+func detectMention(res SEOSearchResult, tokens []string, siteDomain string) (bool, *string) {
+	domain := res.Domain
+	if domain == "" {
+		domain = res.URL
+	}
+	if siteDomain != "" && registrableDomain(domain) == siteDomain {
+		return true, strPtr(MentionReasonDomain)
+	}
+
+	text := res.Title + " " + res.Snippet
+	for _, t := range tokens {
+		if containsToken(text, t) {
+			return true, strPtr(MentionReasonBrandInText)
+		}
+	}
+	return false, nil
+}
+
+// scoreSEOResult overrides every model provided is_mention / mention_reason
+// and recomputes the scores from them, so the numbers never depend on the
+// model's arithmetic.
+func scoreSEOResult(r *SEOAnalysisResult, site SiteInput, profile scoring.Profile) {
+	tokens := BrandTokens(site)
+	siteDomain := registrableDomain(site.URL)
+
+	for i := range r.PerQueryResults {
+		results := r.PerQueryResults[i].Results
+		for j := range results {
+			if results[j].Domain == "" {
+				results[j].Domain = domainFromURL(results[j].URL)
+			}
+			results[j].IsMention, results[j].MentionReason = detectMention(results[j], tokens, siteDomain)
+		}
+	}
+
+	computeScores(r, profile)
+}
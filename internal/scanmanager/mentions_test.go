@@ -0,0 +1,172 @@
+package scanmanager
+
+import (
+	"founders-toolkit-api/internal/scoring"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestBrandTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		site SiteInput
+		want []string
+	}{
+		{
+			name: "name split with joined and hyphenated variants, generic word dropped",
+			site: SiteInput{Name: "Acme Tools", URL: "https://www.acme-tools.io"},
+			want: []string{"acme tools", "acme", "acmetools", "acme-tools"},
+		},
+		{
+			name: "single word",
+			site: SiteInput{Name: "Notion", URL: "https://www.notion.so"},
+			want: []string{"notion"},
+		},
+		{
+			name: "domain root of a multi-part TLD adds variants",
+			site: SiteInput{Name: "Acme", URL: "https://shop.acme-tools.co.uk/pricing"},
+			want: []string{"acme", "acme tools", "acmetools", "acme-tools"},
+		},
+		{
+			name: "punctuation and short words",
+			site: SiteInput{Name: "Brew & Co", URL: "https://brew.co.uk"},
+			want: []string{"brew co", "brew", "brewco", "brew-co"},
+		},
+		{
+			name: "only generic words keep the full name",
+			site: SiteInput{Name: "The App", URL: "https://theapp.com"},
+			want: []string{"the app", "theapp", "the-app"},
+		},
+		{
+			name: "a generic single word name is still the brand",
+			site: SiteInput{Name: "Tools", URL: "https://tools.com"},
+			want: []string{"tools"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BrandTokens(tt.site); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BrandTokens(%+v) = %q, want %q", tt.site, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistrableDomain(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"https://docs.acme-tools.co.uk/x", "acme-tools.co.uk"},
+		{"acme-tools.co.uk", "acme-tools.co.uk"},
+		{"https://a.b.acme.com.au", "acme.com.au"},
+		{"http://WWW.ACME.IO:8080/", "acme.io"},
+		{"https://www.acme.io.", "acme.io"},
+		{"acme.io", "acme.io"},
+		{"co.uk", "co.uk"},
+		{"localhost", "localhost"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := registrableDomain(tt.in); got != tt.want {
+			t.Errorf("registrableDomain(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestContainsToken(t *testing.T) {
+	tests := []struct {
+		text, token string
+		want        bool
+	}{
+		{"Acme's pricing", "acme", true},
+		{"ACME", "acme", true},
+		{"Try acme-tools today", "acme-tools", true},
+		{"acmeville and acme", "acme", true},
+		{"acmeville", "acme", false},
+		{"reacme", "acme", false},
+		{"acme2", "acme", false},
+		{"über acme", "acme", true},
+		{"éacme", "acme", false},
+		{"best acme tools", "acme tools", true},
+		{"", "acme", false},
+	}
+
+	for _, tt := range tests {
+		if got := containsToken(tt.text, tt.token); got != tt.want {
+			t.Errorf("containsToken(%q, %q) = %v, want %v", tt.text, tt.token, got, tt.want)
+		}
+	}
+}
+
+func TestScoreSEOResult(t *testing.T) {
+	site := SiteInput{Name: "Acme Tools", URL: "https://acme-tools.io"}
+	result := func() *SEOAnalysisResult {
+		return &SEOAnalysisResult{PerQueryResults: []SEOQueryResult{
+			{Type: "direct", Query: "acme tools review", Results: []SEOSearchResult{
+				{Rank: 1, URL: "https://rival.com", Title: "Rival", Snippet: "best tools"},
+				{Rank: 2, URL: "https://docs.acme-tools.io/start", Title: "Docs"},
+			}},
+			{Type: "intermediate", Query: "crm for startups", Results: []SEOSearchResult{
+				{Rank: 1, URL: "https://blog.example.com", Title: "Top CRMs", Snippet: "we compared Acme and others"},
+			}},
+			{Type: "intermediate", Query: "startup sales tools", Results: []SEOSearchResult{
+				{Rank: 1, URL: "https://rival.com", Title: "Sales tools"},
+				{Rank: 6, URL: "https://acme-tools.io", Title: "Acme Tools"},
+			}},
+			{Type: "indirect", Query: "how to grow sales", Results: []SEOSearchResult{
+				{Rank: 1, URL: "https://acmeville.com", Title: "Acmeville"},
+			}},
+		}}
+	}
+
+	tests := []struct {
+		name                                string
+		profile                             scoring.Profile
+		direct, intermediate, indirect, vis float64
+	}{
+		{
+			name:    "default weights",
+			profile: scoring.Default(),
+			// direct: rank 2 -> 0.8; intermediate: (1.0 + 0 for rank 6) / 2
+			direct: 80, intermediate: 50, indirect: 0, vis: 0.5*80 + 0.3*50,
+		},
+		{
+			name: "custom rank and type weights",
+			profile: scoring.Profile{
+				RankWeights: map[int]float64{1: 1, 2: 0.25, 6: 0.5},
+				TypeWeights: scoring.TypeWeights{Direct: 0.2, Intermediate: 0.8},
+			},
+			direct: 25, intermediate: 75, indirect: 0, vis: 0.2*25 + 0.8*75,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := result()
+			scoreSEOResult(r, site, tt.profile)
+
+			got := []float64{r.Scores.DirectQueryScore, r.Scores.IntermediateContextQueryScore, r.Scores.IndirectQueryScore, r.Scores.VisibilityScore}
+			want := []float64{tt.direct, tt.intermediate, tt.indirect, tt.vis}
+			for i := range got {
+				if math.Abs(got[i]-want[i]) > 1e-9 {
+					t.Errorf("scores (direct, intermediate, indirect, visibility) = %v, want %v", got, want)
+					break
+				}
+			}
+
+			// "best tools" must not count as a mention of "Acme Tools"
+			if r.PerQueryResults[0].Results[0].IsMention {
+				t.Error("a generic word of the name should not make a mention")
+			}
+			if reason := r.PerQueryResults[0].Results[1].MentionReason; reason == nil || *reason != MentionReasonDomain {
+				t.Errorf("subdomain mention reason = %v, want %q", reason, MentionReasonDomain)
+			}
+			if reason := r.PerQueryResults[1].Results[0].MentionReason; reason == nil || *reason != MentionReasonBrandInText {
+				t.Errorf("snippet mention reason = %v, want %q", reason, MentionReasonBrandInText)
+			}
+		})
+	}
+}
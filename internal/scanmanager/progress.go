@@ -0,0 +1,66 @@
+package scanmanager
+
+import (
+	"context"
+	"time"
+)
+
+// Progress stages of the brand workflow, in the order they happen.
+const (
+	StageQueriesGenerated     = "queries_generated"
+	StageQuerySearched        = "query_searched"
+	StageBrandsExtracted      = "brands_extracted"
+	StageSuggestionsGenerated = "suggestions_generated"
+	StageSaved                = "saved"
+)
+
+// ProgressEvent is a structured step of a running brand workflow. Index and
+// Total count queries across all three types.
+type ProgressEvent struct {
+	Stage     string    `json:"stage"`
+	QueryType QueryType `json:"query_type,omitempty"`
+	Query     string    `json:"query,omitempty"`
+	Queries   []string  `json:"queries,omitempty"`
+	Index     int       `json:"index,omitempty"`
+	Total     int       `json:"total,omitempty"`
+	Count     int       `json:"count,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// progressTracker numbers the per-query events of one workflow run and
+// sends them to the configured channel. A nil tracker or channel is a no-op.
+type progressTracker struct {
+	ch    chan<- ProgressEvent
+	done  int
+	total int
+}
+
+func newProgressTracker(ch chan<- ProgressEvent) *progressTracker {
+	return &progressTracker{ch: ch}
+}
+
+func (p *progressTracker) emit(ctx context.Context, ev ProgressEvent) {
+	if p == nil || p.ch == nil {
+		return
+	}
+	ev.At = time.Now().UTC()
+	select {
+	case p.ch <- ev:
+	case <-ctx.Done():
+	}
+}
+
+func (p *progressTracker) searched(ctx context.Context, qType QueryType, query string) {
+	if p == nil {
+		return
+	}
+	p.done++
+	p.emit(ctx, ProgressEvent{Stage: StageQuerySearched, QueryType: qType, Query: query, Index: p.done, Total: p.total})
+}
+
+func (p *progressTracker) extracted(ctx context.Context, qType QueryType, query string, brands int) {
+	if p == nil {
+		return
+	}
+	p.emit(ctx, ProgressEvent{Stage: StageBrandsExtracted, QueryType: qType, Query: query, Index: p.done, Total: p.total, Count: brands})
+}
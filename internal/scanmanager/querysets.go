@@ -0,0 +1,315 @@
+package scanmanager
+
+import (
+	"encoding/json"
+	"errors"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/internal/sites"
+	"founders-toolkit-api/models"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type querySetQuery struct {
+	Type  QueryType `json:"type"`
+	Query string    `json:"query"`
+}
+
+type querySetRequest struct {
+	Name        string          `json:"name" binding:"required"`
+	Description string          `json:"description"`
+	Queries     []querySetQuery `json:"queries"`
+	// BrandAnalysisID promotes the queries of a previous brand analysis of
+	// the site into the set when no queries are given.
+	BrandAnalysisID int64 `json:"brand_analysis_id"`
+}
+
+var queryTypes = []QueryType{QueryTypeDirect, QueryTypeIntermediate, QueryTypeIndirect}
+
+// querySetQueries groups the items of a set by query type, in order. Every
+// type is present, possibly empty, so a set never falls back to generated
+// queries.
+func querySetQueries(set models.QuerySet) map[QueryType][]string {
+	out := map[QueryType][]string{}
+	for _, qType := range queryTypes {
+		out[qType] = []string{}
+	}
+	for _, it := range set.Items {
+		out[QueryType(it.QueryType)] = append(out[QueryType(it.QueryType)], it.Query)
+	}
+	return out
+}
+
+// loadQuerySet returns the set with its items, gorm.ErrRecordNotFound when
+// it does not belong to the site.
+func loadQuerySet(db *database.Service, userID, siteID int64, id any) (models.QuerySet, error) {
+	var set models.QuerySet
+	if err := db.DB.Where("id = ? AND site_id = ? AND user_id = ?", id, siteID, userID).
+		First(&set).Error; err != nil {
+		return set, err
+	}
+	if err := db.DB.Where("query_set_id = ?", set.ID).
+		Order("query_type, position").Find(&set.Items).Error; err != nil {
+		return set, err
+	}
+	return set, nil
+}
+
+// GET /sites/:id/query-sets
+func ListQuerySets(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+
+		var sets []models.QuerySet
+		if err := db.DB.Where("site_id = ? AND user_id = ?", site.ID, user.ID).
+			Order("id").Find(&sets).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load query sets", nil)
+			return
+		}
+
+		ids := make([]int64, 0, len(sets))
+		for _, s := range sets {
+			ids = append(ids, s.ID)
+		}
+		var items []models.QuerySetItem
+		if len(ids) > 0 {
+			if err := db.DB.Where("query_set_id IN ?", ids).
+				Order("query_type, position").Find(&items).Error; err != nil {
+				response.Respond(c, http.StatusInternalServerError, "failed to load query sets", nil)
+				return
+			}
+		}
+		bySet := map[int64][]models.QuerySetItem{}
+		for _, it := range items {
+			bySet[it.QuerySetID] = append(bySet[it.QuerySetID], it)
+		}
+		for i := range sets {
+			sets[i].Items = bySet[sets[i].ID]
+			if sets[i].Items == nil {
+				sets[i].Items = []models.QuerySetItem{}
+			}
+		}
+
+		response.Respond(c, http.StatusOK, "Query sets loaded", sets)
+	}
+}
+
+// GET /sites/:id/query-sets/:qid
+func GetQuerySet(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+
+		set, err := loadQuerySet(db, user.ID, site.ID, c.Param("qid"))
+		if err != nil {
+			respondQuerySetError(c, err)
+			return
+		}
+		response.Respond(c, http.StatusOK, "Query set loaded", set)
+	}
+}
+
+// POST /sites/:id/query-sets  from the given queries, or from those of
+// brand_analysis_id
+func CreateQuerySet(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+
+		var body querySetRequest
+		if err := c.ShouldBindJSON(&body); err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		if len(body.Queries) == 0 && body.BrandAnalysisID != 0 {
+			queries, err := analysisQueries(db, user.ID, site.ID, body.BrandAnalysisID)
+			if err != nil {
+				response.Respond(c, http.StatusNotFound, "brand analysis not found", nil)
+				return
+			}
+			body.Queries = queries
+		}
+		items, ok := querySetItems(c, body.Queries)
+		if !ok {
+			return
+		}
+
+		set := models.QuerySet{
+			UserID:      user.ID,
+			SiteID:      site.ID,
+			Name:        strings.TrimSpace(body.Name),
+			Description: body.Description,
+		}
+		err := db.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&set).Error; err != nil {
+				return err
+			}
+			return replaceQuerySetItems(tx, set.ID, items)
+		})
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "query set save failed", nil)
+			return
+		}
+		set.Items = items
+		response.Respond(c, http.StatusCreated, "Query set created", set)
+	}
+}
+
+// PUT /sites/:id/query-sets/:qid  replaces the name, description and queries
+func UpdateQuerySet(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+		set, err := loadQuerySet(db, user.ID, site.ID, c.Param("qid"))
+		if err != nil {
+			respondQuerySetError(c, err)
+			return
+		}
+
+		var body querySetRequest
+		if err := c.ShouldBindJSON(&body); err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		items, ok := querySetItems(c, body.Queries)
+		if !ok {
+			return
+		}
+
+		set.Name = strings.TrimSpace(body.Name)
+		set.Description = body.Description
+		err = db.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&set).Updates(map[string]any{
+				"name":        set.Name,
+				"description": set.Description,
+			}).Error; err != nil {
+				return err
+			}
+			return replaceQuerySetItems(tx, set.ID, items)
+		})
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "query set save failed", nil)
+			return
+		}
+		set.Items = items
+		response.Respond(c, http.StatusOK, "Query set updated", set)
+	}
+}
+
+// DELETE /sites/:id/query-sets/:qid  analyses run from the set keep their
+// queries
+func DeleteQuerySet(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+		set, err := loadQuerySet(db, user.ID, site.ID, c.Param("qid"))
+		if err != nil {
+			respondQuerySetError(c, err)
+			return
+		}
+
+		if err := db.DB.Delete(&set).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "query set delete failed", nil)
+			return
+		}
+		response.Respond(c, http.StatusOK, "Query set deleted", nil)
+	}
+}
+
+// querySetItems validates queries into items, responding 400 on failure.
+// Blank and repeated queries of a type are dropped.
+func querySetItems(c *gin.Context, queries []querySetQuery) ([]models.QuerySetItem, bool) {
+	items := []models.QuerySetItem{}
+	seen := map[QueryType]map[string]bool{}
+	positions := map[QueryType]int{}
+	for _, q := range queries {
+		if q.Type != QueryTypeDirect && q.Type != QueryTypeIntermediate && q.Type != QueryTypeIndirect {
+			response.Respond(c, http.StatusBadRequest, "query type must be one of direct, intermediate, indirect", nil)
+			return nil, false
+		}
+		text := strings.TrimSpace(q.Query)
+		if text == "" {
+			continue
+		}
+		if seen[q.Type] == nil {
+			seen[q.Type] = map[string]bool{}
+		}
+		if seen[q.Type][strings.ToLower(text)] {
+			continue
+		}
+		seen[q.Type][strings.ToLower(text)] = true
+
+		items = append(items, models.QuerySetItem{QueryType: string(q.Type), Query: text, Position: positions[q.Type]})
+		positions[q.Type]++
+	}
+	if len(items) == 0 {
+		response.Respond(c, http.StatusBadRequest, "a query set needs at least one query", nil)
+		return nil, false
+	}
+	return items, true
+}
+
+func replaceQuerySetItems(tx *gorm.DB, setID int64, items []models.QuerySetItem) error {
+	if err := tx.Where("query_set_id = ?", setID).Delete(&models.QuerySetItem{}).Error; err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].ID = 0
+		items[i].QuerySetID = setID
+	}
+	return tx.Create(&items).Error
+}
+
+// analysisQueries are the queries a stored brand analysis researched.
+func analysisQueries(db *database.Service, userID, siteID, analysisID int64) ([]querySetQuery, error) {
+	var raw models.JSONB
+	if err := db.DB.Table("brand_analyses").Select("analysis").
+		Where("id = ? AND site_id = ? AND user_id = ?", analysisID, siteID, userID).
+		Row().Scan(&raw); err != nil {
+		return nil, err
+	}
+	var analysis FinalBrandAnalysis
+	if err := json.Unmarshal(raw, &analysis); err != nil {
+		return nil, err
+	}
+
+	var out []querySetQuery
+	for i, g := range []QueryGroup{analysis.Direct, analysis.Intermediate, analysis.Indirect} {
+		for _, q := range g.Queries {
+			out = append(out, querySetQuery{Type: queryTypes[i], Query: q.Query})
+		}
+	}
+	return out, nil
+}
+
+func respondQuerySetError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Respond(c, http.StatusNotFound, "query set not found", nil)
+		return
+	}
+	response.Respond(c, http.StatusInternalServerError, "failed to load query set", nil)
+}
+
+// querySetColumn is the value of brand_analyses.query_set_id; NULL for
+// generated queries.
+func querySetColumn(id int64) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
@@ -0,0 +1,42 @@
+package scanmanager
+
+import (
+	"encoding/json"
+	"founders-toolkit-api/internal/llm"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+)
+
+// fakeOpenAI serves the Responses API with the text reply returns for each
+// prompt and returns a client pointed at it.
+func fakeOpenAI(reply func(input string) string) (*openai.Client, func()) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error":{"message":"bad request"}}`, http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":     "resp_test",
+			"object": "response",
+			"status": "completed",
+			"output": []map[string]any{{
+				"type":    "message",
+				"id":      "msg_test",
+				"role":    "assistant",
+				"status":  "completed",
+				"content": []map[string]any{{"type": "output_text", "text": reply(req.Input), "annotations": []any{}}},
+			}},
+			"usage": map[string]any{"input_tokens": 10, "output_tokens": 5},
+		})
+	}))
+	client := llm.NewOpenAIClient(option.WithBaseURL(srv.URL+"/v1"), option.WithAPIKey("test"))
+	return &client, srv.Close
+}
@@ -0,0 +1,148 @@
+package scanmanager
+
+import (
+	"encoding/json"
+	"founders-toolkit-api/internal/audit"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/plans"
+	"founders-toolkit-api/internal/prompts"
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/internal/sites"
+	"founders-toolkit-api/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /sites/:id/workflow-runs  brand workflow and engine comparison runs of
+// a site, newest first
+func ListWorkflowRuns(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+
+		var runs []models.WorkflowRun
+		if err := db.DB.
+			Where("site_id = ? AND user_id = ?", site.ID, user.ID).
+			Order("created_at DESC").
+			Limit(100).
+			Find(&runs).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load workflow runs", nil)
+			return
+		}
+
+		response.Respond(c, http.StatusOK, "Workflow runs loaded", runs)
+	}
+}
+
+type resumeRequest struct {
+	Async       bool `json:"async"`
+	BypassCache bool `json:"bypass_cache"`
+}
+
+// POST /workflow-runs/:id/resume  re-runs a failed brand workflow with its
+// original config. Steps that completed before the failure are reused, so
+// only the failed step and those after it call OpenAI again.
+func ResumeWorkflowRun(db *database.Service, auditor *audit.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uRaw, _ := c.Get("user")
+		user, _ := uRaw.(models.User)
+		if user.ID == 0 {
+			response.Respond(c, http.StatusUnauthorized, "unauthorized", nil)
+			return
+		}
+
+		var req resumeRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+				return
+			}
+		}
+
+		var wr models.WorkflowRun
+		if err := db.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).
+			First(&wr).Error; err != nil {
+			response.Respond(c, http.StatusNotFound, "workflow run not found", nil)
+			return
+		}
+		if wr.Kind != JobKindBrandAnalysis {
+			response.Respond(c, http.StatusConflict, "only brand workflow runs can be resumed", wr)
+			return
+		}
+		if wr.Status != models.RunStatusFailed {
+			response.Respond(c, http.StatusConflict, "only failed runs can be resumed", wr)
+			return
+		}
+
+		var cfg BrandWorkflowConfig
+		if err := json.Unmarshal(wr.Config, &cfg); err != nil {
+			response.Respond(c, http.StatusInternalServerError, "invalid stored workflow config", nil)
+			return
+		}
+
+		// keep the prompts the run started with so stored steps stay consistent
+		var versions map[string]int
+		if len(wr.PromptVersions) > 0 {
+			if err := json.Unmarshal(wr.PromptVersions, &versions); err != nil {
+				response.Respond(c, http.StatusInternalServerError, "invalid stored prompt versions", nil)
+				return
+			}
+		}
+
+		var site models.Site
+		if err := db.DB.Where("id = ? AND user_id = ?", wr.SiteID, user.ID).
+			First(&site).Error; err != nil || site.ID == 0 {
+			response.Respond(c, http.StatusNotFound, "site not found", nil)
+			return
+		}
+
+		// the stored models were checked against the plan when the run started
+		if !plans.Enforce(c, db, user, plans.Request{
+			SiteID:         site.ID,
+			QueriesPerType: max(cfg.NumDirect, cfg.NumIntermediate, cfg.NumIndirect),
+			Samples:        cfg.Samples,
+		}) {
+			return
+		}
+
+		// Claim the run so two resume requests cannot execute it twice.
+		res := db.DB.Model(&models.WorkflowRun{}).
+			Where("id = ? AND status = ?", wr.ID, models.RunStatusFailed).
+			Updates(map[string]any{
+				"status":      models.RunStatusRunning,
+				"error":       "",
+				"error_class": "",
+				"attempts":    gorm.Expr("attempts + 1"),
+				"finished_at": nil,
+			})
+		if res.Error != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to resume workflow run", nil)
+			return
+		}
+		if res.RowsAffected == 0 {
+			response.Respond(c, http.StatusConflict, "workflow run is already being resumed", nil)
+			return
+		}
+
+		siteInput := siteInputFor(db, site)
+		siteInput.Market = cfg.Market // the market the run started with
+
+		run := brandRun{
+			db:        db,
+			auditor:   auditor,
+			runID:     wr.ID,
+			user:      user,
+			site:      site,
+			siteInput: siteInput,
+			cfg:       cfg,
+			prompts:   prompts.Pin(db, versions),
+
+			bypassCache: req.BypassCache,
+		}
+		run.launch(c, req.Async)
+	}
+}
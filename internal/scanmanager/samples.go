@@ -0,0 +1,231 @@
+package scanmanager
+
+import (
+	"encoding/json"
+	"founders-toolkit-api/internal/scoring"
+	"founders-toolkit-api/models"
+	"math"
+	"sort"
+)
+
+// maxSamples bounds the repetitions per query; each one is a web search.
+const maxSamples = 10
+
+// QuerySample is one repetition of the research of a query.
+type QuerySample struct {
+	Brands []BrandCitation `json:"brands"`
+}
+
+// BrandFrequency is how often a brand was named for a query across samples.
+type BrandFrequency struct {
+	Name        string  `json:"name"`
+	URL         string  `json:"url,omitempty"`
+	Appearances int     `json:"appearances"`
+	Frequency   float64 `json:"frequency"`    // appearances / samples
+	AvgPosition float64 `json:"avg_position"` // 1-based, over the samples it appeared in
+	IsTarget    bool    `json:"is_target,omitempty"`
+}
+
+// aggregateSamples merges the samples of a query. Brands are matched by
+// compacted name; the merged list is ordered by frequency, then by average
+// position, and carries the union of each brand's citations.
+func aggregateSamples(query string, samples []QuerySample, m targetMatcher) QueryBrandsResult {
+	type acc struct {
+		brand     BrandCitation
+		count     int
+		positions int
+	}
+	byKey := map[string]*acc{}
+	var order []string
+
+	for _, s := range samples {
+		seen := map[string]bool{}
+		for pos, b := range s.Brands {
+			key := compactName(b.Name)
+			if key == "" {
+				key = registrableDomain(b.URL)
+			}
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			a, ok := byKey[key]
+			if !ok {
+				a = &acc{brand: BrandCitation{Name: b.Name, URL: b.URL, Citations: []string{}}}
+				byKey[key] = a
+				order = append(order, key)
+			}
+			if a.brand.URL == "" {
+				a.brand.URL = b.URL
+			}
+			for _, c := range b.Citations {
+				a.brand.Citations = appendUnique(a.brand.Citations, c)
+			}
+			a.count++
+			a.positions += pos + 1
+		}
+	}
+
+	avg := func(a *acc) float64 { return float64(a.positions) / float64(a.count) }
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := byKey[order[i]], byKey[order[j]]
+		if a.count != b.count {
+			return a.count > b.count
+		}
+		return avg(a) < avg(b)
+	})
+
+	out := QueryBrandsResult{
+		Query:       query,
+		Brands:      make([]BrandCitation, 0, len(order)),
+		Samples:     samples,
+		Frequencies: make([]BrandFrequency, 0, len(order)),
+	}
+	for _, key := range order {
+		a := byKey[key]
+		isTarget, _ := m.match(a.brand)
+		out.Brands = append(out.Brands, a.brand)
+		out.Frequencies = append(out.Frequencies, BrandFrequency{
+			Name:        a.brand.Name,
+			URL:         a.brand.URL,
+			Appearances: a.count,
+			Frequency:   float64(a.count) / float64(len(samples)),
+			AvgPosition: avg(a),
+			IsTarget:    isTarget,
+		})
+	}
+	return out
+}
+
+// sampleCount is the number of samples every query of the analysis has, 0
+// when it was not sampled.
+func sampleCount(analysis FinalBrandAnalysis) int {
+	n := 0
+	for _, g := range []QueryGroup{analysis.Direct, analysis.Intermediate, analysis.Indirect} {
+		for _, q := range g.Queries {
+			if len(q.Samples) < 2 {
+				return 0
+			}
+			if n == 0 || len(q.Samples) < n {
+				n = len(q.Samples)
+			}
+		}
+	}
+	return n
+}
+
+// sampleAnalysis is the analysis as sample k alone saw it.
+func sampleAnalysis(analysis FinalBrandAnalysis, k int) FinalBrandAnalysis {
+	pick := func(g QueryGroup) QueryGroup {
+		out := QueryGroup{Queries: make([]QueryBrandsResult, 0, len(g.Queries))}
+		for _, q := range g.Queries {
+			out.Queries = append(out.Queries, QueryBrandsResult{Query: q.Query, Brands: q.Samples[k].Brands})
+		}
+		return out
+	}
+	return FinalBrandAnalysis{
+		Direct:       pick(analysis.Direct),
+		Intermediate: pick(analysis.Intermediate),
+		Indirect:     pick(analysis.Indirect),
+	}
+}
+
+// ScoreInterval is the mean of a score over the samples and its 95%
+// confidence interval.
+type ScoreInterval struct {
+	Mean   float64 `json:"mean"`
+	Low    float64 `json:"low"`
+	High   float64 `json:"high"`
+	StdDev float64 `json:"std_dev"`
+}
+
+// ScoreIntervals are the scores of a sampled analysis. Two runs whose
+// intervals do not overlap differ by more than sampling noise.
+type ScoreIntervals struct {
+	Samples      int           `json:"samples"`
+	Direct       ScoreInterval `json:"direct"`
+	Intermediate ScoreInterval `json:"intermediate"`
+	Indirect     ScoreInterval `json:"indirect"`
+	Visibility   ScoreInterval `json:"visibility"`
+	ShareOfVoice ScoreInterval `json:"share_of_voice"`
+}
+
+// tCritical95 holds two-sided 95% Student's t values by degrees of freedom
+// (index 0 is df=1), covering up to maxSamples samples.
+var tCritical95 = []float64{12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262}
+
+// interval summarizes xs, clamping the bounds to [lo, hi].
+func interval(xs []float64, lo, hi float64) ScoreInterval {
+	n := float64(len(xs))
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	iv := ScoreInterval{Mean: sum / n, Low: sum / n, High: sum / n}
+	if len(xs) < 2 {
+		return iv
+	}
+
+	var ss float64
+	for _, x := range xs {
+		ss += (x - iv.Mean) * (x - iv.Mean)
+	}
+	iv.StdDev = math.Sqrt(ss / (n - 1))
+
+	t := 1.96
+	if df := len(xs) - 1; df <= len(tCritical95) {
+		t = tCritical95[df-1]
+	}
+	half := t * iv.StdDev / math.Sqrt(n)
+	iv.Low = math.Max(lo, iv.Mean-half)
+	iv.High = math.Min(hi, iv.Mean+half)
+	return iv
+}
+
+// scoreSamples scores every sample on its own and returns the mean scores
+// with their intervals. The merged brand lists are annotated with the
+// target's presence for display.
+func scoreSamples(analysis *FinalBrandAnalysis, site SiteInput, profile scoring.Profile, n int) BrandScores {
+	var direct, intermediate, indirect, visibility, sov []float64
+	for k := range n {
+		sample := sampleAnalysis(*analysis, k)
+		s := scoreSingle(&sample, site, profile)
+		direct = append(direct, s.Direct)
+		intermediate = append(intermediate, s.Intermediate)
+		indirect = append(indirect, s.Indirect)
+		visibility = append(visibility, s.Visibility)
+		sov = append(sov, s.ShareOfVoice)
+	}
+	annotateTargetPresence(analysis, site, profile.MaxBrands)
+
+	iv := &ScoreIntervals{
+		Samples:      n,
+		Direct:       interval(direct, 0, 100),
+		Intermediate: interval(intermediate, 0, 100),
+		Indirect:     interval(indirect, 0, 100),
+		Visibility:   interval(visibility, 0, 100),
+		ShareOfVoice: interval(sov, 0, 1),
+	}
+	return BrandScores{
+		Direct:       iv.Direct.Mean,
+		Intermediate: iv.Intermediate.Mean,
+		Indirect:     iv.Indirect.Mean,
+		Visibility:   iv.Visibility.Mean,
+		ShareOfVoice: iv.ShareOfVoice.Mean,
+		Intervals:    iv,
+	}
+}
+
+// intervalsJSON is the value of brand_analyses.score_intervals; nil for
+// unsampled analyses.
+func intervalsJSON(iv *ScoreIntervals) any {
+	if iv == nil {
+		return nil
+	}
+	b, err := json.Marshal(iv)
+	if err != nil {
+		return nil
+	}
+	return models.JSONB(b)
+}
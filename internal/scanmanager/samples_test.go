@@ -0,0 +1,155 @@
+package scanmanager
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestInterval(t *testing.T) {
+	tests := []struct {
+		name            string
+		xs              []float64
+		lo, hi          float64
+		mean, low, high float64
+	}{
+		{
+			name: "one sample has no interval",
+			xs:   []float64{40}, lo: 0, hi: 100,
+			mean: 40, low: 40, high: 40,
+		},
+		{
+			name: "two samples use t for df=1",
+			xs:   []float64{50, 51}, lo: 0, hi: 100,
+			// sd = sqrt(0.5), half = 12.706 * sd / sqrt(2) = 6.353
+			mean: 50.5, low: 50.5 - 6.353, high: 50.5 + 6.353,
+		},
+		{
+			name: "ten samples use t for df=9",
+			xs:   []float64{40, 40, 40, 40, 40, 60, 60, 60, 60, 60}, lo: 0, hi: 100,
+			// sd / sqrt(n) = sqrt(1000/9/10), half = 2.262 * 10/3
+			mean: 50, low: 50 - 2.262*10/3, high: 50 + 2.262*10/3,
+		},
+		{
+			name: "clamped to [0, 100]",
+			xs:   []float64{0, 100}, lo: 0, hi: 100,
+			mean: 50, low: 0, high: 100,
+		},
+		{
+			name: "only the low bound clamped",
+			xs:   []float64{0, 2}, lo: 0, hi: 100,
+			// sd = sqrt(2), half = 12.706
+			mean: 1, low: 0, high: 1 + 12.706,
+		},
+		{
+			name: "share of voice clamped to [0, 1]",
+			xs:   []float64{0.2, 0.9}, lo: 0, hi: 1,
+			mean: 0.55, low: 0, high: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iv := interval(tt.xs, tt.lo, tt.hi)
+			got := []float64{iv.Mean, iv.Low, iv.High}
+			want := []float64{tt.mean, tt.low, tt.high}
+			for i := range got {
+				if math.Abs(got[i]-want[i]) > 1e-9 {
+					t.Errorf("interval(%v) (mean, low, high) = %v, want %v", tt.xs, got, want)
+					break
+				}
+			}
+			if len(tt.xs) < 2 && iv.StdDev != 0 {
+				t.Errorf("std dev of one sample = %v, want 0", iv.StdDev)
+			}
+		})
+	}
+}
+
+func TestTCriticalCoversMaxSamples(t *testing.T) {
+	if got, want := len(tCritical95), maxSamples-1; got != want {
+		t.Errorf("tCritical95 covers df up to %d, want %d", got, want)
+	}
+}
+
+func TestAggregateSamples(t *testing.T) {
+	acme := BrandCitation{Name: "Acme", URL: "https://acme.io"}
+	rival := func(cites ...string) BrandCitation {
+		return BrandCitation{Name: "Rival", URL: "https://rival.com", Citations: cites}
+	}
+	other := BrandCitation{Name: "Other"}
+
+	tests := []struct {
+		name      string
+		samples   []QuerySample
+		want      []BrandFrequency
+		citations map[string][]string
+	}{
+		{
+			name: "most frequent first, ties by average position",
+			samples: []QuerySample{
+				{Brands: []BrandCitation{rival("https://a.com"), acme, other}},
+				{Brands: []BrandCitation{acme, rival("https://b.com", "https://a.com")}},
+				// a brand repeated within one sample counts once
+				{Brands: []BrandCitation{other, acme, {Name: "ACME"}}},
+			},
+			want: []BrandFrequency{
+				{Name: "Acme", URL: "https://acme.io", Appearances: 3, Frequency: 1, AvgPosition: 5.0 / 3, IsTarget: true},
+				{Name: "Rival", URL: "https://rival.com", Appearances: 2, Frequency: 2.0 / 3, AvgPosition: 1.5},
+				{Name: "Other", Appearances: 2, Frequency: 2.0 / 3, AvgPosition: 2},
+			},
+			citations: map[string][]string{"Rival": {"https://a.com", "https://b.com"}},
+		},
+		{
+			name: "full ties keep first seen order",
+			samples: []QuerySample{
+				{Brands: []BrandCitation{other, rival()}},
+				{Brands: []BrandCitation{rival(), other}},
+			},
+			want: []BrandFrequency{
+				{Name: "Other", Appearances: 2, Frequency: 1, AvgPosition: 1.5},
+				{Name: "Rival", URL: "https://rival.com", Appearances: 2, Frequency: 1, AvgPosition: 1.5},
+			},
+		},
+		{
+			name: "a brand without a name is keyed by its domain",
+			samples: []QuerySample{
+				{Brands: []BrandCitation{{URL: "https://www.acme.io/pricing"}}},
+				{Brands: []BrandCitation{{URL: "https://acme.io"}, other}},
+			},
+			want: []BrandFrequency{
+				{URL: "https://www.acme.io/pricing", Appearances: 2, Frequency: 1, AvgPosition: 1, IsTarget: true},
+				{Name: "Other", Appearances: 1, Frequency: 0.5, AvgPosition: 2},
+			},
+		},
+	}
+
+	m := newTargetMatcher(SiteInput{Name: "Acme", URL: "https://acme.io"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := aggregateSamples("best crm", tt.samples, m)
+
+			if !reflect.DeepEqual(got.Frequencies, tt.want) {
+				t.Errorf("frequencies = %+v, want %+v", got.Frequencies, tt.want)
+			}
+			if len(got.Brands) != len(tt.want) {
+				t.Fatalf("brands = %d, want %d", len(got.Brands), len(tt.want))
+			}
+			for i, b := range got.Brands {
+				if b.Name != tt.want[i].Name {
+					t.Errorf("brand %d = %q, want %q", i, b.Name, tt.want[i].Name)
+				}
+				want := tt.citations[b.Name]
+				if want == nil {
+					want = []string{}
+				}
+				if !reflect.DeepEqual(b.Citations, want) {
+					t.Errorf("%s citations = %q, want %q", b.Name, b.Citations, want)
+				}
+			}
+			if len(got.Samples) != len(tt.samples) {
+				t.Errorf("samples = %d, want %d", len(got.Samples), len(tt.samples))
+			}
+		})
+	}
+}
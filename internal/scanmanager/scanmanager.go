@@ -1,8 +1,15 @@
 package scanmanager
 
 import (
+	"founders-toolkit-api/internal/audit"
 	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/llm"
+	"founders-toolkit-api/internal/plans"
+	"founders-toolkit-api/internal/prompts"
 	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/internal/scoring"
+	"founders-toolkit-api/internal/search"
+	"founders-toolkit-api/internal/webhooks"
 	"founders-toolkit-api/models"
 	"bytes"
 	"context"
@@ -13,6 +20,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -26,6 +34,16 @@ type SEOScanRequest struct {
 	URL         string `json:"url"         binding:"required"`
 	Description string `json:"description" binding:"required"`
 	Language    string `json:"language"    binding:"required"`
+
+	// BypassCache skips cached model responses for this scan.
+	BypassCache bool `json:"bypass_cache"`
+	// Models overrides the model of a step ("scan", or "queries" and
+	// "suggestions" when a search provider is configured), as far as the
+	// plan allows.
+	Models map[string]string `json:"models"`
+	// Market is the key of one of the site's markets ("DE" or
+	// "US/California"); the site's primary market when empty.
+	Market string `json:"market"`
 }
 
 /* ---------- Final structured result ---------- */
@@ -42,19 +60,7 @@ type SEOAnalysisResult struct {
 		Intermediate []string `json:"intermediate"`
 		Indirect     []string `json:"indirect"`
 	} `json:"queries"`
-	PerQueryResults []struct {
-		Type    string `json:"type"` // "direct" | "intermediate" | "indirect"
-		Query   string `json:"query"`
-		Results []struct {
-			Rank          int     `json:"rank"` // 1..5
-			Title         string  `json:"title"`
-			URL           string  `json:"url"`
-			Domain        string  `json:"domain"`
-			Snippet       string  `json:"snippet"`
-			IsMention     bool    `json:"is_mention"`
-			MentionReason *string `json:"mention_reason"` // "domain" | "brand_in_text" | null
-		} `json:"results"`
-	} `json:"per_query_results"`
+	PerQueryResults []SEOQueryResult `json:"per_query_results"`
 	Scores struct {
 		DirectQueryScore              float64 `json:"direct_query_score"`
 		IntermediateContextQueryScore float64 `json:"intermediate_context_query_score"`
@@ -67,83 +73,21 @@ type SEOAnalysisResult struct {
 	Suggestions            []string `json:"suggestions"`
 }
 
-/* ---------- System prompt (STRICT, 1 query/type, tiny output) ---------- */
-
-const systemPrompt = `
-You are an AI SEO Analysis Agent.
-
-INPUT (from user content):
-	- site.url
-- site.name
-- site.description
-- site.language
-
-DERIVE brand_tokens (lowercased):
-- full brand name (site.name)
-- brand split into tokens
-- registrable domain root from site.url (e.g., "github.com" → "github")
-- variants with/without hyphens/spaces
-Example: "Acme Tools" + "https://www.acme-tools.io" → {"acme","tools","acmetools","acme-tools","acme tools"}.
-
-YOUR TASK (STRICT):
-1) Generate EXACTLY 3 queries TOTAL:
-   A) DIRECT: MUST contain ≥1 brand_token.
-   B) INTERMEDIATE: relevant task/topic query, MUST NOT contain ANY brand_token.
-      Examples of modifiers: pricing, features, integration, tutorial, documentation, status, roadmap, "how to …", "best … for …".
-   C) INDIRECT: broad adjacent topic a prospect searches BEFORE knowing the brand, MUST NOT contain ANY brand_token; avoid brand-unique terms.
-   - All queries must be in the given language and ≤ 12 words.
-   - No duplicates.
-   - Self-validate: if INTERMEDIATE or INDIRECT accidentally contain any brand_token, regenerate them; if DIRECT lacks a brand_token, regenerate it.
-
-2) For EACH of the 3 queries you MUST call 'web_search' and keep ONLY the TOP 5 results.
-   For each result record: rank (1..5), url, domain, title, snippet (≤ 180 chars).
-
-3) MENTION LOGIC:
-   is_mention = true if:
-   - domain equals the target site's registrable domain, OR
-   - title or snippet includes any brand_token (case-insensitive).
-   mention_reason = "domain" | "brand_in_text" | null.
-
-4) SCORING:
-   weights: #1=1.0, #2=0.8, #3=0.6, #4=0.4, #5=0.2.
-   For each type: score = (sum of weights for results with is_mention=true / number_of_queries_in_type) * 100.
-   Overall visibility = 0.5*direct + 0.3*intermediate + 0.2*indirect.
-
-5) LIMITS (to keep JSON small and stable):
-   - keywords_from_the_queries: MAX 15 items, lowercase, deduped.
-   - suggestions: MAX 8 items, each 1 sentence.
-   - citations: MAX 10 unique items, prefer URLs; fallback to domains.
-
-6) OUTPUT: SINGLE JSON OBJECT ONLY (no prose, no markdown fences):
-{
-  "site": { "name": "...", "url": "...", "description": "...", "language": "..." },
-  "queries": {
-    "direct": [ "<1 item>" ],
-    "intermediate": [ "<1 item>" ],
-    "indirect": [ "<1 item>" ]
-  },
-  "per_query_results": [
-    {
-      "type": "direct" | "intermediate" | "indirect",
-      "query": "...",
-      "results": [
-        { "rank": 1..5, "title": "...", "url": "...", "domain": "...", "snippet": "...", "is_mention": true|false, "mention_reason": "domain"|"brand_in_text"|null }
-      ]
-    }
-  ],
-  "scores": {
-    "direct_query_score": number,
-    "intermediate_context_query_score": number,
-    "indirect_query_score": number,
-    "visibility_score": number
-  },
-  "citations": [strings],
-  "keywords_from_the_queries": [strings],
-  "all_of_the_queries_used": [strings], // exactly 3 in order: direct, intermediate, indirect
-  "suggestions": [strings]
+type SEOQueryResult struct {
+	Type    string            `json:"type"` // "direct" | "intermediate" | "indirect"
+	Query   string            `json:"query"`
+	Results []SEOSearchResult `json:"results"`
+}
+
+type SEOSearchResult struct {
+	Rank          int     `json:"rank"` // 1..5
+	Title         string  `json:"title"`
+	URL           string  `json:"url"`
+	Domain        string  `json:"domain"`
+	Snippet       string  `json:"snippet"`
+	IsMention     bool    `json:"is_mention"`
+	MentionReason *string `json:"mention_reason"` // "domain" | "brand_in_text" | null
 }
-If 'web_search' is unavailable, return the schema with empty arrays and zeros (still valid JSON).
-`
 
 /* ---------- Responses API envelope (minimal) ---------- */
 
@@ -174,16 +118,19 @@ type responsesMessageContent struct {
 
 /* ---------- Call Responses API with web_search tool ---------- */
 
-func callResponsesWebSearch(ctx context.Context, sysPrompt, userContent string) (*SEOAnalysisResult, string, error) {
+func callResponsesWebSearch(ctx context.Context, sysPrompt, userContent string, market *Market) (*SEOAnalysisResult, string, error) {
+	model := llm.ModelFor(ctx, llm.CallScan)
+	webSearch := map[string]any{"type": "web_search", "search_context_size": "low"}
+	if market != nil {
+		webSearch["user_location"] = webSearchLocation(market)
+	}
 	payload := map[string]any{
-		"model": "gpt-4o-mini",
+		"model": model,
 		"input": []map[string]string{
 			{"role": "system", "content": sysPrompt},
 			{"role": "user", "content": userContent},
 		},
-		"tools": []map[string]any{
-			{"type": "web_search", "search_context_size": "low"},
-		},
+		"tools":       []map[string]any{webSearch},
 		"tool_choice": "auto",
 		"temperature": 0.2,
 		// Ask for plain text so we can parse the JSON string ourselves.
@@ -193,29 +140,58 @@ func callResponsesWebSearch(ctx context.Context, sysPrompt, userContent string)
 	}
 
 	bodyBytes, _ := json.Marshal(payload)
-	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.openai.com/v1/responses", bytes.NewReader(bodyBytes))
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("OPENAI_API_KEY"))
-	req.Header.Set("Content-Type", "application/json")
-	if proj := os.Getenv("OPENAI_PROJECT_ID"); proj != "" {
-		req.Header.Set("OpenAI-Project", proj)
-	}
 
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, "", err
-	}
-	defer res.Body.Close()
+	// Only responses that parse are cached; result is nil on a cache hit.
+	var result *SEOAnalysisResult
+	raw, err := llm.Cached(ctx, llm.CallScan, model, payload, func(ctx context.Context) (string, error) {
+		var respBody []byte
+		err := llm.OpenAI.Do(ctx, "callResponsesWebSearch", func(ctx context.Context) error {
+			req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.openai.com/v1/responses", bytes.NewReader(bodyBytes))
+			req.Header.Set("Authorization", "Bearer "+os.Getenv("OPENAI_API_KEY"))
+			req.Header.Set("Content-Type", "application/json")
+			if proj := os.Getenv("OPENAI_PROJECT_ID"); proj != "" {
+				req.Header.Set("OpenAI-Project", proj)
+			}
 
-	respBody, _ := io.ReadAll(res.Body)
-	raw := string(respBody)
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return err
+			}
+			defer res.Body.Close()
 
-	if res.StatusCode >= 300 {
-		return nil, raw, fmt.Errorf("openai error: %s | body=%s", res.Status, raw)
+			respBody, err = io.ReadAll(res.Body)
+			if err != nil {
+				return err
+			}
+			if res.StatusCode >= 300 {
+				return &llm.StatusError{Status: res.StatusCode, Header: res.Header, Body: string(respBody)}
+			}
+			return nil
+		})
+		if err != nil {
+			return string(respBody), err
+		}
+		llm.Record(ctx, llm.CallScan, model, llm.UsageFromJSON(respBody))
+		result, err = parseWebSearchResponse(respBody)
+		return string(respBody), err
+	})
+	if err != nil {
+		return nil, raw, err
+	}
+	if result == nil {
+		if result, err = parseWebSearchResponse([]byte(raw)); err != nil {
+			return nil, raw, err
+		}
 	}
+	return result, raw, nil
+}
 
+// parseWebSearchResponse extracts the analysis JSON from a Responses API body.
+func parseWebSearchResponse(respBody []byte) (*SEOAnalysisResult, error) {
+	raw := string(respBody)
 	var env responsesEnvelope
 	if err := json.Unmarshal(respBody, &env); err != nil {
-		return nil, raw, fmt.Errorf("decode envelope error: %v | raw=%s", err, raw)
+		return nil, fmt.Errorf("decode envelope error: %v | raw=%s", err, raw)
 	}
 
 	// Concatenate all output_text segments in message outputs
@@ -232,7 +208,7 @@ func callResponsesWebSearch(ctx context.Context, sysPrompt, userContent string)
 	}
 	jsonText := strings.TrimSpace(textBuf.String())
 	if jsonText == "" {
-		return nil, raw, errors.New("model returned empty message text")
+		return nil, errors.New("model returned empty message text")
 	}
 
 	// Remove code fences if present
@@ -242,12 +218,12 @@ func callResponsesWebSearch(ctx context.Context, sysPrompt, userContent string)
 
 	var result SEOAnalysisResult
 	if err := json.Unmarshal([]byte(jsonText), &result); err != nil {
-		return nil, raw, fmt.Errorf("failed to parse model JSON: %v", err)
+		return nil, fmt.Errorf("failed to parse model JSON: %v", err)
 	}
 
 	normalizeResult(&result)
 	clampResult(&result) // enforce max lengths
-	return &result, raw, nil
+	return &result, nil
 }
 
 /* ---------- Helpers ---------- */
@@ -296,8 +272,6 @@ func trimToBalancedJSON(s string) string {
 	return s
 }
 
-var rankWeights = map[int]float64{1: 1.0, 2: 0.8, 3: 0.6, 4: 0.4, 5: 0.2}
-
 func normalizeResult(r *SEOAnalysisResult) {
 	// Non-nil slices
 	if r.Queries.Direct == nil {
@@ -342,61 +316,56 @@ func normalizeResult(r *SEOAnalysisResult) {
 		r.AllOfTheQueriesUsed = all
 	}
 
-	// Recompute scores if all zeros (1 query per type)
-	if len(r.PerQueryResults) > 0 &&
-		r.Scores.DirectQueryScore == 0 &&
-		r.Scores.IntermediateContextQueryScore == 0 &&
-		r.Scores.IndirectQueryScore == 0 {
-
-		var dSum, iSum, nSum float64
-		var dCnt, iCnt, nCnt int
+	// Clean citations whitespace
+	for i := range r.Citations {
+		r.Citations[i] = strings.TrimSpace(r.Citations[i])
+	}
+}
 
-		for _, pq := range r.PerQueryResults {
-			var weighted float64
-			for _, re := range pq.Results {
-				if re.IsMention {
-					if w, ok := rankWeights[re.Rank]; ok {
-						weighted += w
-					}
-				}
-			}
-			switch pq.Type {
-			case "direct":
-				dSum += weighted
-				dCnt++
-			case "intermediate":
-				iSum += weighted
-				iCnt++
-			case "indirect":
-				nSum += weighted
-				nCnt++
+// computeScores derives the per-type and visibility scores from the
+// rank-weighted mentions in PerQueryResults.
+func computeScores(r *SEOAnalysisResult, profile scoring.Profile) {
+	var dSum, iSum, nSum float64
+	var dCnt, iCnt, nCnt int
+
+	for _, pq := range r.PerQueryResults {
+		var weighted float64
+		for _, re := range pq.Results {
+			if re.IsMention {
+				weighted += profile.RankWeight(re.Rank)
 			}
 		}
-		if dCnt == 0 {
-			dCnt = 1
+		switch pq.Type {
+		case "direct":
+			dSum += weighted
+			dCnt++
+		case "intermediate":
+			iSum += weighted
+			iCnt++
+		case "indirect":
+			nSum += weighted
+			nCnt++
 		}
-		if iCnt == 0 {
-			iCnt = 1
-		}
-		if nCnt == 0 {
-			nCnt = 1
-		}
-
-		dScore := (dSum / float64(dCnt)) * 100.0
-		iScore := (iSum / float64(iCnt)) * 100.0
-		nScore := (nSum / float64(nCnt)) * 100.0
-		vis := 0.5*dScore + 0.3*iScore + 0.2*nScore
-
-		r.Scores.DirectQueryScore = dScore
-		r.Scores.IntermediateContextQueryScore = iScore
-		r.Scores.IndirectQueryScore = nScore
-		r.Scores.VisibilityScore = vis
 	}
-
-	// Clean citations whitespace
-	for i := range r.Citations {
-		r.Citations[i] = strings.TrimSpace(r.Citations[i])
+	if dCnt == 0 {
+		dCnt = 1
+	}
+	if iCnt == 0 {
+		iCnt = 1
 	}
+	if nCnt == 0 {
+		nCnt = 1
+	}
+
+	dScore := (dSum / float64(dCnt)) * 100.0
+	iScore := (iSum / float64(iCnt)) * 100.0
+	nScore := (nSum / float64(nCnt)) * 100.0
+	vis := profile.Visibility(dScore, iScore, nScore)
+
+	r.Scores.DirectQueryScore = dScore
+	r.Scores.IntermediateContextQueryScore = iScore
+	r.Scores.IndirectQueryScore = nScore
+	r.Scores.VisibilityScore = vis
 }
 
 func clampResult(r *SEOAnalysisResult) {
@@ -418,7 +387,7 @@ func clampResult(r *SEOAnalysisResult) {
 
 /* ---------- Handler ---------- */
 
-func AnalyzeAndCreateScan(db *database.Service) gin.HandlerFunc {
+func AnalyzeAndCreateScan(db *database.Service, auditor *audit.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		uRaw, ok := c.Get("user")
 		if !ok {
@@ -445,6 +414,19 @@ func AnalyzeAndCreateScan(db *database.Service) gin.HandlerFunc {
 			response.Respond(c, http.StatusNotFound, "site not found", nil)
 			return
 		}
+		provider := search.FromEnv()
+		steps := []llm.CallType{llm.CallScan}
+		if provider != nil {
+			steps = []llm.CallType{llm.CallQueries, llm.CallSuggestions}
+		}
+		stepModels, err := llm.ResolveModels(steps, req.Models)
+		if err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		if !plans.Enforce(c, db, user, plans.Request{SiteID: site.ID, Models: req.Models}) {
+			return
+		}
 
 		// Build user content (fed to model as "user" message)
 		userContent := "Site:\n" +
@@ -454,12 +436,73 @@ func AnalyzeAndCreateScan(db *database.Service) gin.HandlerFunc {
 			"- Language: " + req.Language + "\n\n" +
 			"Perform the SEO visibility analysis per the system instructions."
 
+		profile, err := scoring.Resolve(db, user.ID, site.ID)
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load scoring profile", nil)
+			return
+		}
+
+		siteInput := SiteInput{
+			Name:        req.Name,
+			URL:         req.URL,
+			Description: req.Description,
+			Language:    req.Language,
+		}
+		siteInput.Market, err = resolveMarket(db, siteInputFor(db, site), site.ID, req.Market)
+		if err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		if m := siteInput.Market; m != nil {
+			userContent += "\n\nTarget market: " + m.Key()
+			if m.Locale != "" {
+				userContent += " (locale " + m.Locale + ")"
+			}
+			userContent += ". Generate the queries and search as a user in that market would."
+		}
+
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 120*time.Second)
 		defer cancel()
-
-		result, raw, err := callResponsesWebSearch(ctx, systemPrompt, userContent)
+		if req.BypassCache {
+			ctx = llm.WithoutCache(ctx)
+		}
+		meter := llm.NewMeter()
+		ctx = llm.WithMeter(ctx, meter)
+		promptSet := prompts.Pick()
+		ctx = prompts.WithSet(ctx, promptSet)
+		ctx = llm.WithModels(ctx, stepModels)
+
+		var (
+			result *SEOAnalysisResult
+			raw    string
+		)
+		if provider != nil {
+			client := llm.NewOpenAIClient()
+			result, err = analyzeWithSearchProvider(ctx, &client, provider, siteInput, profile)
+		} else {
+			var systemPrompt string
+			if systemPrompt, err = prompts.Render(ctx, prompts.ScanSystem, nil); err == nil {
+				result, raw, err = callResponsesWebSearch(ctx, systemPrompt, userContent, siteInput.Market)
+			}
+			if err == nil {
+				scoreSEOResult(result, siteInput, profile)
+			}
+		}
 		if err != nil {
-			response.Respond(c, http.StatusBadGateway, "openai error: "+err.Error(), gin.H{"raw": raw})
+			auditor.Record(c, audit.Entry{
+				Action:     audit.ActionScanFailed,
+				TargetType: audit.TargetSite,
+				TargetID:   audit.ID(site.ID),
+				Metadata:   map[string]any{"error": err.Error()},
+			})
+			afterRunFailed(db, user.ID, site.ID, webhooks.EventScanFailed, err)
+			scanID := recordFailedScan(db, user.ID, site.ID, err)
+			saveRunInputs(db, "scans", scanID, promptSet, stepModels)
+			respondLLMError(c, "openai error", err, gin.H{
+				"raw":     raw,
+				"scan_id": scanID,
+				"usage":   saveScanUsage(db, meter, user.ID, site.ID, scanID),
+			})
 			return
 		}
 
@@ -479,16 +522,39 @@ func AnalyzeAndCreateScan(db *database.Service) gin.HandlerFunc {
 			Queries:         models.StringArray(result.AllOfTheQueriesUsed),
 		}
 		if err := db.DB.Create(&scan).Error; err != nil {
+			saveScanUsage(db, meter, user.ID, site.ID, 0)
 			response.Respond(c, http.StatusInternalServerError, "scan save failed: "+err.Error(), gin.H{
 				"result": result,
 				"raw":    raw,
 			})
 			return
 		}
+		usage := saveScanUsage(db, meter, user.ID, site.ID, scan.ID)
+		saveRunInputs(db, "scans", scan.ID, promptSet, stepModels)
+
+		if id := profile.StoredID(); id != nil {
+			db.DB.Model(&scan).Update("scoring_profile_id", *id)
+		}
+		if siteInput.Market != nil {
+			db.DB.Table("scans").Where("id = ?", scan.ID).Updates(marketColumns(siteInput.Market))
+		}
+		afterScanSaved(db, scan, siteInput)
+
+		auditor.Record(c, audit.Entry{
+			Action:     audit.ActionScanCreate,
+			TargetType: audit.TargetScan,
+			TargetID:   audit.ID(scan.ID),
+			Metadata: map[string]any{
+				"site_id":          site.ID,
+				"visibility_score": scan.VisibilityScore,
+			},
+		})
 
 		response.Respond(c, http.StatusOK, "ok", gin.H{
 			"scan_id": scan.ID,
+			"market":  siteInput.Market,
 			"result":  result,
+			"usage":   usage,
 		})
 	}
 }
@@ -502,3 +568,20 @@ func domainFromURL(u string) string {
 	host := parsed.Hostname()
 	return strings.TrimPrefix(strings.TrimPrefix(host, "www."), "m.")
 }
+
+// respondLLMError answers with the status matching the error class, adding
+// the class and whether retrying later may help.
+func respondLLMError(c *gin.Context, prefix string, err error, data gin.H) {
+	if data == nil {
+		data = gin.H{}
+	}
+	var e *llm.Error
+	if errors.As(err, &e) {
+		data["retryable"] = e.Retryable || e.Class == llm.ClassCircuitOpen
+		if e.RetryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(e.RetryAfter.Seconds()+0.999)))
+		}
+	}
+	data["error_class"] = llm.ClassOf(err)
+	response.Respond(c, llm.HTTPStatus(err), prefix+": "+err.Error(), data)
+}
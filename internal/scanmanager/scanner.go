@@ -1,7 +1,11 @@
 package scanmanager
 
 import (
+	"founders-toolkit-api/internal/audit"
 	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/llm"
+	"founders-toolkit-api/internal/plans"
+	"founders-toolkit-api/internal/prompts"
 	"founders-toolkit-api/internal/response"
 	"founders-toolkit-api/models"
 	"context"
@@ -17,7 +21,6 @@ import (
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/packages/param"
 	"github.com/openai/openai-go/v3/responses"
-	"github.com/openai/openai-go/v3/shared"
 )
 
 // ---- Core types ----
@@ -35,6 +38,11 @@ type SiteInput struct {
 	URL         string `json:"url"`
 	Description string `json:"description"`
 	Language    string `json:"language"`
+	// Aliases are additional registrable domains of the brand.
+	Aliases []string `json:"aliases,omitempty"`
+	// Market localizes queries and web searches; nil searches without a
+	// location.
+	Market *Market `json:"market,omitempty"`
 }
 
 type BrandCitation struct {
@@ -46,6 +54,11 @@ type BrandCitation struct {
 type QueryBrandsResult struct {
 	Query  string          `json:"query"`
 	Brands []BrandCitation `json:"brands"`
+	Target *TargetPresence `json:"target,omitempty"`
+	// Samples and Frequencies are set when the query was researched more
+	// than once; Brands then merges the brands of every sample.
+	Samples     []QuerySample    `json:"samples,omitempty"`
+	Frequencies []BrandFrequency `json:"frequencies,omitempty"`
 }
 
 type QueryGroup struct {
@@ -59,14 +72,18 @@ type FinalBrandAnalysis struct {
 }
 
 // Low-level helper: call Responses API and return concatenated text output.
+// The model is the one chosen for callType on ctx, see llm.ModelFor.
+// Responses are cached per call type, see llm.Cached.
 func callOpenAIText(
 	ctx context.Context,
 	client *openai.Client,
-	model shared.ChatModel,
+	callType llm.CallType,
 	input string,
 	tools []responses.ToolUnionParam,
 	toolChoice *responses.ResponseNewParamsToolChoiceUnion,
 ) (string, error) {
+	model := llm.ModelFor(ctx, callType)
+
 	// Short preview of the prompt for the logs
 	snippet := input
 	if len(snippet) > 120 {
@@ -89,20 +106,28 @@ func callOpenAIText(
 		params.ToolChoice = *toolChoice
 	}
 
-	resp, err := client.Responses.New(ctx, params)
-	if err != nil {
-		log.Printf("[OpenAI] ERROR: %v", err)
-		return "", err
-	}
+	return llm.Cached(ctx, callType, model, params, func(ctx context.Context) (string, error) {
+		var resp *responses.Response
+		err := llm.OpenAI.Do(ctx, "callOpenAIText", func(ctx context.Context) error {
+			var err error
+			resp, err = client.Responses.New(ctx, params)
+			return err
+		})
+		if err != nil {
+			log.Printf("[OpenAI] ERROR: %v", err)
+			return "", err
+		}
+		llm.Record(ctx, callType, model, llm.UsageFromJSON([]byte(resp.RawJSON())))
 
-	out := resp.OutputText()
-	out = strings.TrimSpace(out)
-	log.Printf("[OpenAI] callOpenAIText: got output len=%d", len(out))
+		out := resp.OutputText()
+		out = strings.TrimSpace(out)
+		log.Printf("[OpenAI] callOpenAIText: got output len=%d", len(out))
 
-	if out == "" {
-		return "", errors.New("empty output from OpenAI")
-	}
-	return out, nil
+		if out == "" {
+			return "", errors.New("empty output from OpenAI")
+		}
+		return out, nil
+	})
 }
 
 // Generate N queries of a specific type for a given site.
@@ -120,28 +145,15 @@ func GenerateQueriesForType(
 		return []string{}, nil
 	}
 
-	systemInstructions := fmt.Sprintf(`
-You are an SEO query generator.
-
-Given the following site, generate EXACTLY %d distinct %s queries
-in the site's language (%s).
-
-Rules:
-- Return ONLY a JSON array of strings, e.g. ["query 1", "query 2", ...].
-- No extra text, explanations, or comments.
-- Queries must be 3-12 words.
-- Do not include duplicate queries.
-- For type "direct": must contain the brand or domain or clear brand token.
-- For "intermediate": task/topic queries related to the product, NO brand tokens.
-- For "indirect": broader, upstream intent queries, NO brand tokens.
-`, n, qType, site.Language)
-
-	prompt := systemInstructions + "\n\n" + buildSiteContext(site)
+	prompt, err := prompts.Render(ctx, prompts.Queries, prompts.QueriesData{N: n, Type: string(qType), Site: promptSite(site)})
+	if err != nil {
+		return nil, err
+	}
 
 	text, err := callOpenAIText(
 		ctx,
 		client,
-		shared.ChatModelGPT4_1Mini,
+		llm.CallQueries,
 		prompt,
 		nil,
 		nil,
@@ -191,26 +203,16 @@ func RunWebSearchForQuery(
 ) (string, error) {
 	log.Printf("[RunWebSearchForQuery] START query=%q site=%s", query, site.URL)
 
-	instructions := fmt.Sprintf(`
-You are a research assistant.
-
-Use the web_search tool to research the query:
-
-"%s"
-
-Focus on brands and services that appear relevant to this query.
-Return a concise English summary (or in the site's language) that lists:
-- brand names
-- their URLs if possible
-- where you found them (domains / pages)
-
-You may structure your answer as bullet points, but do NOT output JSON in this step.
-`, query)
+	instructions, err := prompts.Render(ctx, prompts.Research, prompts.ResearchData{Query: query, Site: promptSite(site)})
+	if err != nil {
+		return "", err
+	}
 
 	tools := []responses.ToolUnionParam{
 		{
 			OfWebSearch: &responses.WebSearchToolParam{
-				Type: responses.WebSearchToolTypeWebSearch,
+				Type:         responses.WebSearchToolTypeWebSearch,
+				UserLocation: webSearchLocation(site.Market),
 			},
 		},
 	}
@@ -223,7 +225,7 @@ You may structure your answer as bullet points, but do NOT output JSON in this s
 	text, err := callOpenAIText(
 		ctx,
 		client,
-		shared.ChatModelGPT4_1Mini,
+		llm.CallResearch,
 		instructions,
 		tools,
 		&toolChoice,
@@ -248,45 +250,15 @@ func ExtractBrandsFromResearchText(
 ) ([]BrandCitation, error) {
 	log.Printf("[ExtractBrandsFromResearchText] START query=%q researchTextLen=%d", query, len(researchText))
 
-	prompt := fmt.Sprintf(`
-You will receive some research notes that summarize web search results for this query:
-
-"%s"
-
-The notes may include brand names, their URLs, and the websites where they were mentioned.
-
-Your job:
-- Identify brands that appear.
-- For each brand, output:
-  - name: the brand name (string)
-  - url: the brand's main URL if visible (string, can be empty if unknown)
-  - citations: list of domains or full URLs where the brand was mentioned (array of strings)
-
-OUTPUT FORMAT (STRICT):
-{
-  "brands": [
-    {
-      "name": "...",
-      "url": "...",
-      "citations": ["...", "..."]
-    }
-  ]
-}
-
-RULES:
-- Output ONLY valid JSON as above. No extra text, no markdown.
-- citations array must not be null; use [] if nothing is known.
-- If you find no brands, return {"brands": []}.
-
-Research notes:
-----------------
-%s
-`, query, researchText)
+	prompt, err := prompts.Render(ctx, prompts.Brands, prompts.BrandsData{Query: query, ResearchText: researchText})
+	if err != nil {
+		return nil, err
+	}
 
 	text, err := callOpenAIText(
 		ctx,
 		client,
-		shared.ChatModelGPT4_1Mini,
+		llm.CallBrands,
 		prompt,
 		nil,
 		nil,
@@ -327,24 +299,89 @@ func ProcessSingleQuery(
 	query string,
 	site SiteInput,
 ) (QueryBrandsResult, error) {
+	return processSingleQuery(ctx, client, "", 0, query, site, 1, nil, nil)
+}
+
+// processSingleQuery is ProcessSingleQuery for query i of a type, repeated
+// samples times. Stored research text and brands of a previous attempt are
+// reused when available. With several samples the result lists every brand
+// named across them, most frequent first.
+func processSingleQuery(
+	ctx context.Context,
+	client *openai.Client,
+	qType QueryType,
+	i int,
+	query string,
+	site SiteInput,
+	samples int,
+	progress *progressTracker,
+	stages StageStore,
+) (QueryBrandsResult, error) {
+	runs := make([]QuerySample, 0, max(samples, 1))
+	for sample := range max(samples, 1) {
+		brands, err := researchSample(ctx, client, qType, i, sample, query, site, progress, stages)
+		if err != nil {
+			return QueryBrandsResult{}, err
+		}
+		runs = append(runs, QuerySample{Brands: brands})
+	}
+
+	result := QueryBrandsResult{Query: query, Brands: runs[0].Brands}
+	if len(runs) > 1 {
+		result = aggregateSamples(query, runs, newTargetMatcher(site))
+	}
+	progress.extracted(ctx, qType, query, len(result.Brands))
+	return result, nil
+}
+
+// researchSample runs the web research and brand extraction of one sample.
+// Samples after the first get their own cache entries so they are
+// independent answers rather than copies of the first. The query counts as
+// searched once the research of its first sample is done.
+func researchSample(
+	ctx context.Context,
+	client *openai.Client,
+	qType QueryType,
+	i, sample int,
+	query string,
+	site SiteInput,
+	progress *progressTracker,
+	stages StageStore,
+) ([]BrandCitation, error) {
+	var brands []BrandCitation
+	if loadStep(stages, stepBrands(qType, i, sample), &brands) {
+		if sample == 0 {
+			progress.searched(ctx, qType, query)
+		}
+		return brands, nil
+	}
+
 	// You can set a per-query timeout if you want:
 	perQueryCtx, cancel := context.WithTimeout(ctx, 300*time.Second)
 	defer cancel()
+	if sample > 0 {
+		perQueryCtx = llm.WithCacheSalt(perQueryCtx, fmt.Sprintf("sample-%d", sample))
+	}
 
-	researchText, err := RunWebSearchForQuery(perQueryCtx, client, query, site)
-	if err != nil {
-		return QueryBrandsResult{}, err
+	var researchText string
+	if !loadStep(stages, stepResearch(qType, i, sample), &researchText) {
+		var err error
+		researchText, err = RunWebSearchForQuery(perQueryCtx, client, query, site)
+		if err != nil {
+			return nil, err
+		}
+		saveStep(stages, stepResearch(qType, i, sample), researchText)
+	}
+	if sample == 0 {
+		progress.searched(ctx, qType, query)
 	}
 
 	brands, err := ExtractBrandsFromResearchText(perQueryCtx, client, query, researchText)
 	if err != nil {
-		return QueryBrandsResult{}, err
+		return nil, err
 	}
-
-	return QueryBrandsResult{
-		Query:  query,
-		Brands: brands,
-	}, nil
+	saveStep(stages, stepBrands(qType, i, sample), brands)
+	return brands, nil
 }
 
 // Process all queries of one type (direct/intermediate/indirect)
@@ -354,15 +391,29 @@ func ProcessQueriesForType(
 	site SiteInput,
 	qType QueryType,
 	queries []string,
+) ([]QueryBrandsResult, error) {
+	return processQueriesForType(ctx, client, site, qType, queries, 1, nil, nil)
+}
+
+func processQueriesForType(
+	ctx context.Context,
+	client *openai.Client,
+	site SiteInput,
+	qType QueryType,
+	queries []string,
+	samples int,
+	progress *progressTracker,
+	stages StageStore,
 ) ([]QueryBrandsResult, error) {
 	results := make([]QueryBrandsResult, 0, len(queries))
-	for _, q := range queries {
+	for i, q := range queries {
 		if strings.TrimSpace(q) == "" {
 			continue
 		}
-		r, err := ProcessSingleQuery(ctx, client, q, site)
+		r, err := processSingleQuery(ctx, client, qType, i, q, site, samples, progress, stages)
 		if err != nil {
-			return nil, fmt.Errorf("processing %s query %q failed: %w", qType, q, err)
+			// the queries finished so far are still returned
+			return results, fmt.Errorf("processing %s query %q failed: %w", qType, q, err)
 		}
 		results = append(results, r)
 	}
@@ -371,9 +422,48 @@ func ProcessQueriesForType(
 
 // This is the "do everything" function you can call from a handler or a background job.
 type BrandWorkflowConfig struct {
-	NumDirect       int
-	NumIntermediate int
-	NumIndirect     int
+	NumDirect       int `json:"num_direct"`
+	NumIntermediate int `json:"num_intermediate"`
+	NumIndirect     int `json:"num_indirect"`
+	// Samples repeats the research of every query to average out the
+	// variance of model answers; 0 and 1 mean a single run.
+	Samples int `json:"samples,omitempty"`
+	// QuerySetID, when set, runs the saved queries in Queries instead of
+	// generating new ones; the Num* counts are then ignored.
+	QuerySetID int64                  `json:"query_set_id,omitempty"`
+	Queries    map[QueryType][]string `json:"queries,omitempty"`
+	// Market is the market the run targets, nil for none.
+	Market *Market `json:"market,omitempty"`
+
+	// Models is the model of each step; steps missing here use the
+	// configured model. Stored with the run so a resume uses the same ones.
+	Models llm.Models `json:"models,omitempty"`
+
+	// Progress, when set, receives a ProgressEvent after each step. The
+	// workflow blocks on sends, so the reader must keep draining it.
+	Progress chan<- ProgressEvent `json:"-"`
+	// Stages, when set, persists every step's output and is consulted
+	// before each step, which makes a failed run resumable.
+	Stages StageStore `json:"-"`
+}
+
+// generateQueriesStep is GenerateQueriesForType, reusing stored queries when
+// resuming. Runs of a query set use its queries as they are.
+func generateQueriesStep(ctx context.Context, client *openai.Client, site SiteInput, qType QueryType, n int, cfg BrandWorkflowConfig) ([]string, error) {
+	if cfg.QuerySetID != 0 {
+		return cfg.Queries[qType], nil
+	}
+	stages := cfg.Stages
+	var queries []string
+	if loadStep(stages, stepQueries(qType), &queries) {
+		return queries, nil
+	}
+	queries, err := GenerateQueriesForType(ctx, client, site, qType, n)
+	if err != nil {
+		return nil, err
+	}
+	saveStep(stages, stepQueries(qType), queries)
+	return queries, nil
 }
 
 func RunFullBrandWorkflow(
@@ -382,60 +472,56 @@ func RunFullBrandWorkflow(
 	site SiteInput,
 	cfg BrandWorkflowConfig,
 ) (FinalBrandAnalysis, error) {
+	progress := newProgressTracker(cfg.Progress)
+
 	// 1) Generate queries for each type
-	directQueries, err := GenerateDirectQueries(ctx, client, site, cfg.NumDirect)
+	directQueries, err := generateQueriesStep(ctx, client, site, QueryTypeDirect, cfg.NumDirect, cfg)
 	if err != nil {
 		return FinalBrandAnalysis{}, fmt.Errorf("generate direct queries: %w", err)
 	}
+	progress.emit(ctx, ProgressEvent{Stage: StageQueriesGenerated, QueryType: QueryTypeDirect, Queries: directQueries, Count: len(directQueries)})
 
-	fmt.Printf(">>> %+v", directQueries)
-	intermediateQueries, err := GenerateIntermediateQueries(ctx, client, site, cfg.NumIntermediate)
+	intermediateQueries, err := generateQueriesStep(ctx, client, site, QueryTypeIntermediate, cfg.NumIntermediate, cfg)
 	if err != nil {
 		return FinalBrandAnalysis{}, fmt.Errorf("generate intermediate queries: %w", err)
 	}
-	indirectQueries, err := GenerateIndirectQueries(ctx, client, site, cfg.NumIndirect)
+	progress.emit(ctx, ProgressEvent{Stage: StageQueriesGenerated, QueryType: QueryTypeIntermediate, Queries: intermediateQueries, Count: len(intermediateQueries)})
+	indirectQueries, err := generateQueriesStep(ctx, client, site, QueryTypeIndirect, cfg.NumIndirect, cfg)
 	if err != nil {
 		return FinalBrandAnalysis{}, fmt.Errorf("generate indirect queries: %w", err)
 	}
-	fmt.Printf(">>> %+v", indirectQueries)
+	progress.emit(ctx, ProgressEvent{Stage: StageQueriesGenerated, QueryType: QueryTypeIndirect, Queries: indirectQueries, Count: len(indirectQueries)})
 
-	// 2) For each type, run search + brand extraction per query
-	directResults, err := ProcessQueriesForType(ctx, client, site, QueryTypeDirect, directQueries)
-	if err != nil {
-		return FinalBrandAnalysis{}, err
+	for _, qs := range [][]string{directQueries, intermediateQueries, indirectQueries} {
+		for _, q := range qs {
+			if strings.TrimSpace(q) != "" {
+				progress.total++
+			}
+		}
 	}
-	intermediateResults, err := ProcessQueriesForType(ctx, client, site, QueryTypeIntermediate, intermediateQueries)
+
+	// 2) For each type, run search + brand extraction per query. On error the
+	// analysis assembled so far is returned alongside it.
+	var final FinalBrandAnalysis
+	final.Direct.Queries, err = processQueriesForType(ctx, client, site, QueryTypeDirect, directQueries, cfg.Samples, progress, cfg.Stages)
 	if err != nil {
-		return FinalBrandAnalysis{}, err
+		return final, err
 	}
-	indirectResults, err := ProcessQueriesForType(ctx, client, site, QueryTypeIndirect, indirectQueries)
+	final.Intermediate.Queries, err = processQueriesForType(ctx, client, site, QueryTypeIntermediate, intermediateQueries, cfg.Samples, progress, cfg.Stages)
 	if err != nil {
-		return FinalBrandAnalysis{}, err
+		return final, err
 	}
-
-	fmt.Println("RESULTS--------------------------")
-	fmt.Printf("%+v\n\n", directResults)
-	fmt.Printf("%+v\n\n", intermediateResults)
-	fmt.Printf("%+v\n\n", indirectResults)
-
-	// 3) Assemble final JSON
-	final := FinalBrandAnalysis{
-		Indirect: QueryGroup{
-			Queries: indirectResults,
-		},
-		Intermediate: QueryGroup{
-			Queries: intermediateResults,
-		},
-		Direct: QueryGroup{
-			Queries: directResults,
-		},
+	final.Indirect.Queries, err = processQueriesForType(ctx, client, site, QueryTypeIndirect, indirectQueries, cfg.Samples, progress, cfg.Stages)
+	if err != nil {
+		return final, err
 	}
 
-	fmt.Printf("final is\n %s", final)
-
 	return final, nil
 }
 
+// brandWorkflowSteps are the model calls of a brand workflow.
+var brandWorkflowSteps = []llm.CallType{llm.CallQueries, llm.CallResearch, llm.CallBrands, llm.CallSuggestions}
+
 // Example request DTO for this brand workflow endpoint.
 type BrandWorkflowRequest struct {
 	Name        string `json:"name"        binding:"required"`
@@ -446,9 +532,28 @@ type BrandWorkflowRequest struct {
 	NumDirect       int `json:"num_direct"       `
 	NumIntermediate int `json:"num_intermediate" `
 	NumIndirect     int `json:"num_indirect"     `
+	// Samples is how many times each query is researched (1-10); scores
+	// then come with confidence intervals.
+	Samples int `json:"samples"`
+	// QuerySetID runs a saved query set of the site instead of generating
+	// num_* new queries, so runs stay comparable over time.
+	QuerySetID int64 `json:"query_set_id"`
+	// Market is the key of one of the site's markets ("DE" or
+	// "US/California"); the site's primary market when empty. Run once per
+	// market to compare visibility across markets.
+	Market string `json:"market"`
+
+	// Async returns 202 with a job id right away; progress and the result
+	// are then available under /jobs/:id.
+	Async bool `json:"async"`
+	// BypassCache skips cached model responses for this run.
+	BypassCache bool `json:"bypass_cache"`
+	// Models overrides the model of a step (queries, research, brands,
+	// suggestions), as far as the plan allows.
+	Models map[string]string `json:"models"`
 }
 
-func BrandWorkflowHandler(db *database.Service) gin.HandlerFunc {
+func BrandWorkflowHandler(db *database.Service, auditor *audit.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// --- auth ---
 		uRaw, ok := c.Get("user")
@@ -478,6 +583,13 @@ func BrandWorkflowHandler(db *database.Service) gin.HandlerFunc {
 		if req.NumIndirect <= 0 {
 			req.NumIndirect = 1
 		}
+		if req.Samples <= 0 {
+			req.Samples = 1
+		}
+		if req.Samples > maxSamples {
+			response.Respond(c, http.StatusBadRequest, fmt.Sprintf("samples must be at most %d", maxSamples), nil)
+			return
+		}
 
 		// --- find site by (user_id, url) ---
 		var site models.Site
@@ -488,124 +600,69 @@ func BrandWorkflowHandler(db *database.Service) gin.HandlerFunc {
 			return
 		}
 
-		siteInput := SiteInput{
-			Name:        site.Name,
-			URL:         site.URL,
-			Description: site.Description,
-			Language:    site.Lang,
-		}
-		cfg := BrandWorkflowConfig{
-			NumDirect:       req.NumDirect,
-			NumIntermediate: req.NumIntermediate,
-			NumIndirect:     req.NumIndirect,
-		}
-
-		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Minute)
-		defer cancel()
-
-		client := openai.NewClient()
-		log.Printf("[BrandWorkflowHandler] user=%d site_id=%d url=%s cfg=%+v",
-			user.ID, site.ID, site.URL, cfg)
-
-		// --- run main workflow ---
-		analysis, err := RunFullBrandWorkflow(ctx, &client, siteInput, cfg)
+		stepModels, err := llm.ResolveModels(brandWorkflowSteps, req.Models)
 		if err != nil {
-			log.Printf("[BrandWorkflowHandler] RunFullBrandWorkflow error: %v", err)
-			response.Respond(c, http.StatusBadGateway, "openai error: "+err.Error(), nil)
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
 			return
 		}
 
-		// --- compute raw brand counts for each type ---
-		directBrands := countBrandsInGroup(analysis.Direct)
-		interBrands := countBrandsInGroup(analysis.Intermediate)
-		indirectBrands := countBrandsInGroup(analysis.Indirect)
-
-		// --- percentage-based scores (0–100) ---
-		const maxBrandsPerQuery = 10.0 // tweak as you like
-
-		maxDirect := maxBrandsPerQuery * float64(cfg.NumDirect)
-		if maxDirect == 0 {
-			maxDirect = 1
+		siteInput := siteInputFor(db, site)
+		siteInput.Market, err = resolveMarket(db, siteInput, site.ID, req.Market)
+		if err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
 		}
-		maxIntermediate := maxBrandsPerQuery * float64(cfg.NumIntermediate)
-		if maxIntermediate == 0 {
-			maxIntermediate = 1
+		cfg := BrandWorkflowConfig{
+			NumDirect:       req.NumDirect,
+			NumIntermediate: req.NumIntermediate,
+			NumIndirect:     req.NumIndirect,
+			Samples:         req.Samples,
+			Models:          stepModels,
+			Market:          siteInput.Market,
 		}
-		maxIndirect := maxBrandsPerQuery * float64(cfg.NumIndirect)
-		if maxIndirect == 0 {
-			maxIndirect = 1
+		if req.QuerySetID != 0 {
+			set, err := loadQuerySet(db, user.ID, site.ID, req.QuerySetID)
+			if err != nil {
+				respondQuerySetError(c, err)
+				return
+			}
+			cfg.QuerySetID = set.ID
+			cfg.Queries = querySetQueries(set)
+			cfg.NumDirect = len(cfg.Queries[QueryTypeDirect])
+			cfg.NumIntermediate = len(cfg.Queries[QueryTypeIntermediate])
+			cfg.NumIndirect = len(cfg.Queries[QueryTypeIndirect])
 		}
 
-		directScore := (float64(directBrands) / maxDirect) * 100.0
-		intermediateScore := (float64(interBrands) / maxIntermediate) * 100.0
-		indirectScore := (float64(indirectBrands) / maxIndirect) * 100.0
-
-		// weighted visibility (still 0–100)
-		visibilityScore := 0.5*directScore + 0.3*intermediateScore + 0.2*indirectScore
-
-		// --- collect all queries used ---
-		allQueries := collectAllQueries(analysis)
-
-		// --- generate suggestions (second OpenAI call) ---
-		suggestions, err := GenerateSuggestionsForSite(ctx, &client, siteInput, analysis)
-		if err != nil {
-			log.Printf("[BrandWorkflowHandler] GenerateSuggestionsForSite error: %v", err)
-			response.Respond(c, http.StatusBadGateway, "suggestions error: "+err.Error(), nil)
+		if !plans.Enforce(c, db, user, plans.Request{
+			SiteID:         site.ID,
+			QueriesPerType: max(cfg.NumDirect, cfg.NumIntermediate, cfg.NumIndirect),
+			Samples:        cfg.Samples,
+			Models:         req.Models,
+		}) {
 			return
 		}
 
-		// --- marshal full FinalBrandAnalysis for storage ---
-		analysisBytes, err := json.Marshal(analysis)
+		promptSet := prompts.Pick()
+		runID, err := startWorkflowRun(db, user.ID, site.ID, JobKindBrandAnalysis, cfg, promptSet)
 		if err != nil {
-			log.Printf("[BrandWorkflowHandler] json.Marshal analysis error: %v", err)
-			response.Respond(c, http.StatusInternalServerError, "marshal analysis failed: "+err.Error(), nil)
+			log.Printf("[BrandWorkflowHandler] create workflow run: %v", err)
+			response.Respond(c, http.StatusInternalServerError, "failed to start brand workflow", nil)
 			return
 		}
 
-		// --- build and save BrandAnalysis row ---
-		ba := models.BrandAnalysis{
-			SiteID:            site.ID,
-			UserID:            user.ID,
-			DirectScore:       directScore,
-			IntermediateScore: intermediateScore,
-			IndirectScore:     indirectScore,
-			VisibilityScore:   visibilityScore,
-			Suggestions:       models.StringArray(suggestions),
-			Queries:           models.StringArray(allQueries),
-			Analysis:          models.JSONB(analysisBytes),
-		}
-
-		if err := db.DB.Table("brand_analyses").Create(&ba).Error; err != nil {
-			log.Printf("[BrandWorkflowHandler] DB create error: %v", err)
-			response.Respond(c, http.StatusInternalServerError, "brand analysis save failed: "+err.Error(), gin.H{
-				"analysis":    analysis,
-				"suggestions": suggestions,
-				"queries":     allQueries,
-			})
-			return
+		run := brandRun{
+			db:        db,
+			auditor:   auditor,
+			runID:     runID,
+			user:      user,
+			site:      site,
+			siteInput: siteInput,
+			cfg:       cfg,
+			prompts:   promptSet,
+
+			bypassCache: req.BypassCache,
 		}
-
-		log.Printf(
-			"[BrandWorkflowHandler] saved brand_analyses id=%d site_id=%d user_id=%d scores={d=%.2f i=%.2f n=%.2f vis=%.2f} suggestions=%d queries=%d",
-			ba.ID, ba.SiteID, ba.UserID,
-			directScore, intermediateScore, indirectScore, visibilityScore,
-			len(suggestions), len(allQueries),
-		)
-
-		// --- final response ---
-		response.Respond(c, http.StatusOK, "ok", gin.H{
-			"brand_analysis_id": ba.ID,
-			"site_id":           site.ID,
-			"scores": gin.H{
-				"direct":       directScore,
-				"intermediate": intermediateScore,
-				"indirect":     indirectScore,
-				"visibility":   visibilityScore,
-			},
-			"queries":     allQueries,
-			"suggestions": suggestions,
-			"analysis":    analysis,
-		})
+		run.launch(c, req.Async)
 	}
 }
 
@@ -620,15 +677,6 @@ func BrandDebugPing() gin.HandlerFunc {
 	}
 }
 
-// count total brands across all queries in a group
-func countBrandsInGroup(g QueryGroup) int {
-	total := 0
-	for _, q := range g.Queries {
-		total += len(q.Brands)
-	}
-	return total
-}
-
 func collectAllQueries(analysis FinalBrandAnalysis) []string {
 	m := make(map[string]struct{})
 
@@ -665,53 +713,15 @@ func GenerateSuggestionsForSite(
 		return nil, fmt.Errorf("marshal analysis for suggestions: %w", err)
 	}
 
-	prompt := fmt.Sprintf(`
-You are an SEO strategist.
-
-You will receive:
-1) Target site:
-   - name: %s
-   - url: %s
-   - description: %s
-   - language/region: %s
-
-2) A JSON object called FinalBrandAnalysis with:
-   - direct queries (brand-aware)
-   - intermediate queries (product / task / use-case)
-   - indirect queries (broader upstream intent)
-Each query contains multiple competing brands, their URLs, and the pages/domains where they were cited.
-
-Your tasks:
-- Compare the target site against all the competitors that appear in the analysis.
-- Pay attention to:
-  - where competitors are cited (domains/pages),
-  - how often they appear across queries and query types,
-  - what they seem to be doing that the target is not (content, landing pages, tools, comparison pages, etc.).
-- Think in terms of realistic SEO / content / product suggestions that the target site could implement.
-
-OUTPUT FORMAT (STRICT):
-{
-  "suggestions": [
-    "One short, concrete suggestion...",
-    "Another short, concrete suggestion..."
-  ]
-}
-
-Rules:
-- Maximum 10 suggestions.
-- Each suggestion: 1–2 sentences, absolutely practical and specific to THIS target site.
-- Do NOT mention JSON structure or internal details.
-- Output ONLY valid JSON in the exact schema above. No markdown, no explanations.
-
-FinalBrandAnalysis JSON:
-------------------------
-%s
-`, site.Name, site.URL, site.Description, site.Language, string(analysisJSON))
+	prompt, err := prompts.Render(ctx, prompts.Suggestions, prompts.SuggestionsData{Site: promptSite(site), AnalysisJSON: string(analysisJSON)})
+	if err != nil {
+		return nil, err
+	}
 
 	text, err := callOpenAIText(
 		ctx,
 		client,
-		shared.ChatModelGPT4_1Mini,
+		llm.CallSuggestions,
 		prompt,
 		nil,
 		nil,
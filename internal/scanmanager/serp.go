@@ -0,0 +1,160 @@
+package scanmanager
+
+import (
+	"context"
+	"fmt"
+	"founders-toolkit-api/internal/scoring"
+	"founders-toolkit-api/internal/search"
+	"log"
+	"strings"
+
+	"github.com/openai/openai-go/v3"
+)
+
+const serpResultsPerQuery = 5
+
+// analyzeWithSearchProvider is the SEO scan path used when a real SERP
+// provider is configured. The model only writes the queries and the
+// suggestions; rankings come from the provider so rankWeights apply to
+// actual positions.
+func analyzeWithSearchProvider(
+	ctx context.Context,
+	client *openai.Client,
+	provider search.Provider,
+	site SiteInput,
+	profile scoring.Profile,
+) (*SEOAnalysisResult, error) {
+	var result SEOAnalysisResult
+	result.Site.Name = site.Name
+	result.Site.URL = site.URL
+	result.Site.Description = site.Description
+	result.Site.Language = site.Language
+
+	for _, qType := range []QueryType{QueryTypeDirect, QueryTypeIntermediate, QueryTypeIndirect} {
+		queries, err := GenerateQueriesForType(ctx, client, site, qType, 1)
+		if err != nil {
+			return nil, fmt.Errorf("generate %s query: %w", qType, err)
+		}
+
+		for _, q := range queries {
+			organic, err := provider.Search(ctx, q, search.Options{
+				Num:      serpResultsPerQuery,
+				Country:  searchCountry(site.Market),
+				Language: searchLanguage(promptSite(site).Language),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("search %s query %q: %w", qType, q, err)
+			}
+			log.Printf("[analyzeWithSearchProvider] type=%s query=%q results=%d", qType, q, len(organic))
+
+			pq := SEOQueryResult{Type: string(qType), Query: q, Results: []SEOSearchResult{}}
+			for _, o := range organic {
+				res := SEOSearchResult{
+					Rank:    o.Position,
+					Title:   o.Title,
+					URL:     o.Link,
+					Domain:  o.Domain,
+					Snippet: o.Snippet,
+				}
+				pq.Results = append(pq.Results, res)
+				result.Citations = appendUnique(result.Citations, o.Link)
+			}
+			result.PerQueryResults = append(result.PerQueryResults, pq)
+
+			switch qType {
+			case QueryTypeDirect:
+				result.Queries.Direct = append(result.Queries.Direct, q)
+			case QueryTypeIntermediate:
+				result.Queries.Intermediate = append(result.Queries.Intermediate, q)
+			case QueryTypeIndirect:
+				result.Queries.Indirect = append(result.Queries.Indirect, q)
+			}
+		}
+	}
+
+	result.KeywordsFromTheQueries = keywordsFromQueries(result.Queries.Direct, result.Queries.Intermediate, result.Queries.Indirect)
+
+	normalizeResult(&result)
+	scoreSEOResult(&result, site, profile)
+
+	suggestions, err := GenerateSuggestionsForSite(ctx, client, site, serpAnalysis(&result))
+	if err != nil {
+		return nil, fmt.Errorf("generate suggestions: %w", err)
+	}
+	result.Suggestions = suggestions
+
+	clampResult(&result)
+	return &result, nil
+}
+
+// serpAnalysis presents the organic results of a scan as a brand analysis so
+// the suggestions prompt can compare the site with the domains ranking
+// instead of it. Results that mention the site are left out.
+func serpAnalysis(result *SEOAnalysisResult) FinalBrandAnalysis {
+	var analysis FinalBrandAnalysis
+	for _, pq := range result.PerQueryResults {
+		qr := QueryBrandsResult{Query: pq.Query, Brands: []BrandCitation{}}
+		for _, r := range pq.Results {
+			if r.IsMention {
+				continue
+			}
+			qr.Brands = append(qr.Brands, BrandCitation{Name: r.Domain, URL: r.URL, Citations: []string{r.URL}})
+		}
+		switch QueryType(pq.Type) {
+		case QueryTypeDirect:
+			analysis.Direct.Queries = append(analysis.Direct.Queries, qr)
+		case QueryTypeIntermediate:
+			analysis.Intermediate.Queries = append(analysis.Intermediate.Queries, qr)
+		case QueryTypeIndirect:
+			analysis.Indirect.Queries = append(analysis.Indirect.Queries, qr)
+		}
+	}
+	return analysis
+}
+
+func keywordsFromQueries(groups ...[]string) []string {
+	var out []string
+	for _, qs := range groups {
+		for _, q := range qs {
+			for _, w := range strings.Fields(strings.ToLower(q)) {
+				w = strings.Trim(w, `.,;:!?"'()[]`)
+				if len([]rune(w)) < 3 {
+					continue
+				}
+				out = appendUnique(out, w)
+			}
+		}
+	}
+	return out
+}
+
+func appendUnique(list []string, v string) []string {
+	if v == "" {
+		return list
+	}
+	for _, existing := range list {
+		if existing == v {
+			return list
+		}
+	}
+	return append(list, v)
+}
+
+// searchLanguage passes two letter codes through and drops free-form
+// language names such as "English", which Serper would reject.
+func searchLanguage(lang string) string {
+	lang, _, _ = strings.Cut(strings.ToLower(strings.TrimSpace(lang)), "-")
+	if len(lang) == 2 {
+		return lang
+	}
+	return ""
+}
+
+func searchCountry(m *Market) string {
+	if m == nil {
+		return ""
+	}
+	return strings.ToLower(m.Country)
+}
+
+func strPtr(s string) *string { return &s }
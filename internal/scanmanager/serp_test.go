@@ -0,0 +1,90 @@
+package scanmanager
+
+import (
+	"context"
+	"founders-toolkit-api/internal/scoring"
+	"founders-toolkit-api/internal/search"
+	"founders-toolkit-api/internal/search/searchtest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAnalyzeWithSearchProvider(t *testing.T) {
+	serp := searchtest.NewServer(map[string][]search.OrganicResult{
+		"direct query": {
+			{Position: 1, Title: "Acme review", Link: "https://www.acme.io/", Snippet: "Acme CRM"},
+			{Position: 2, Title: "Rival", Link: "https://rival.com/", Snippet: "Rival CRM"},
+		},
+		"intermediate query": {
+			{Position: 1, Title: "Rival", Link: "https://rival.com/pricing", Snippet: "pricing"},
+			{Position: 2, Title: "Top CRMs", Link: "https://blog.example.com/top", Snippet: "includes Acme"},
+		},
+	})
+	defer serp.Close()
+
+	var mu sync.Mutex
+	var suggestionPrompts []string
+	client, closeFake := fakeOpenAI(func(input string) string {
+		if strings.Contains(input, "FinalBrandAnalysis JSON") {
+			mu.Lock()
+			suggestionPrompts = append(suggestionPrompts, input)
+			mu.Unlock()
+			return `{"suggestions": ["Publish a comparison page", "  ", "Get listed on review sites"]}`
+		}
+		for _, qType := range queryTypes {
+			if strings.Contains(input, "distinct "+string(qType)+" queries") {
+				return `["` + string(qType) + ` query"]`
+			}
+		}
+		return "[]"
+	})
+	defer closeFake()
+
+	site := SiteInput{Name: "Acme", URL: "https://acme.io", Description: "CRM for startups", Language: "en"}
+	result, err := analyzeWithSearchProvider(context.Background(), client, serp.Provider(), site, scoring.Default())
+	if err != nil {
+		t.Fatalf("analyzeWithSearchProvider: %v", err)
+	}
+
+	if got, want := serp.Queries(), []string{"direct query", "intermediate query", "indirect query"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("searched %q, want %q", got, want)
+	}
+
+	mentions := map[string]bool{}
+	for _, pq := range result.PerQueryResults {
+		for _, r := range pq.Results {
+			mentions[r.URL] = r.IsMention
+		}
+	}
+	wantMentions := map[string]bool{
+		"https://www.acme.io/":         true, // domain
+		"https://rival.com/":           false,
+		"https://rival.com/pricing":    false,
+		"https://blog.example.com/top": true, // brand in snippet
+	}
+	if !reflect.DeepEqual(mentions, wantMentions) {
+		t.Errorf("mentions = %v, want %v", mentions, wantMentions)
+	}
+	if result.Scores.DirectQueryScore <= 0 || result.Scores.IntermediateContextQueryScore <= 0 {
+		t.Errorf("scores = %+v, want direct and intermediate above 0", result.Scores)
+	}
+	if result.Scores.IndirectQueryScore != 0 {
+		t.Errorf("indirect score = %v, want 0 without results", result.Scores.IndirectQueryScore)
+	}
+
+	if want := []string{"Publish a comparison page", "Get listed on review sites"}; !reflect.DeepEqual(result.Suggestions, want) {
+		t.Errorf("suggestions = %q, want %q", result.Suggestions, want)
+	}
+	if len(suggestionPrompts) != 1 {
+		t.Fatalf("suggestions prompts = %d, want 1", len(suggestionPrompts))
+	}
+	_, analysisJSON, _ := strings.Cut(suggestionPrompts[0], "FinalBrandAnalysis JSON:")
+	if !strings.Contains(analysisJSON, `"name":"rival.com"`) {
+		t.Errorf("suggestions prompt should list the ranking competitors: %s", analysisJSON)
+	}
+	if strings.Contains(analysisJSON, "acme.io") {
+		t.Errorf("suggestions prompt should leave out the site's own results: %s", analysisJSON)
+	}
+}
@@ -0,0 +1,98 @@
+package scanmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/models"
+	"log"
+
+	"gorm.io/gorm/clause"
+)
+
+// StageStore keeps the output of completed workflow steps so a failed run
+// can be resumed from where it stopped instead of paying for every call
+// again.
+type StageStore interface {
+	// Load decodes a stored step into out and reports whether it existed.
+	Load(step string, out any) (bool, error)
+	Save(step string, output any) error
+}
+
+// Step keys. Query steps are keyed by type, position and sample so they stay
+// stable across resumes. The first sample has no suffix, so single-sample
+// runs keep short keys.
+func stepQueries(qType QueryType) string { return "queries/" + string(qType) }
+
+func stepResearch(qType QueryType, i, sample int) string {
+	if sample == 0 {
+		return fmt.Sprintf("research/%s/%d", qType, i)
+	}
+	return fmt.Sprintf("research/%s/%d/%d", qType, i, sample)
+}
+
+func stepBrands(qType QueryType, i, sample int) string {
+	if sample == 0 {
+		return fmt.Sprintf("brands/%s/%d", qType, i)
+	}
+	return fmt.Sprintf("brands/%s/%d/%d", qType, i, sample)
+}
+
+const stepSuggestions = "suggestions"
+
+// loadStep is Load on a possibly nil store. Read errors are logged and
+// treated as a miss so the step simply runs again.
+func loadStep(store StageStore, step string, out any) bool {
+	if store == nil {
+		return false
+	}
+	ok, err := store.Load(step, out)
+	if err != nil {
+		log.Printf("[StageStore] load %s: %v", step, err)
+		return false
+	}
+	return ok
+}
+
+// saveStep is Save on a possibly nil store. A failed save only costs a
+// repeated call on resume, so it is logged rather than failing the run.
+func saveStep(store StageStore, step string, output any) {
+	if store == nil {
+		return
+	}
+	if err := store.Save(step, output); err != nil {
+		log.Printf("[StageStore] save %s: %v", step, err)
+	}
+}
+
+// runStageStore stores steps in workflow_run_steps.
+type runStageStore struct {
+	db    *database.Service
+	runID int64
+}
+
+func newRunStageStore(db *database.Service, runID int64) runStageStore {
+	return runStageStore{db: db, runID: runID}
+}
+
+func (s runStageStore) Load(step string, out any) (bool, error) {
+	var rows []models.WorkflowRunStep
+	if err := s.db.DB.Where("run_id = ? AND step = ?", s.runID, step).Limit(1).Find(&rows).Error; err != nil {
+		return false, err
+	}
+	if len(rows) == 0 {
+		return false, nil
+	}
+	return true, json.Unmarshal(rows[0].Output, out)
+}
+
+func (s runStageStore) Save(step string, output any) error {
+	b, err := json.Marshal(output)
+	if err != nil {
+		return err
+	}
+	return s.db.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "run_id"}, {Name: "step"}},
+		DoUpdates: clause.AssignmentColumns([]string{"output"}),
+	}).Create(&models.WorkflowRunStep{RunID: s.runID, Step: step, Output: models.JSONB(b)}).Error
+}
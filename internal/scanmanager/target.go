@@ -0,0 +1,174 @@
+package scanmanager
+
+import (
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/internal/sites"
+	"founders-toolkit-api/models"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	TargetMatchName   = "name"
+	TargetMatchURL    = "url"
+	TargetMatchDomain = "domain_alias"
+)
+
+// TargetPresence describes where the target site shows up among the brands
+// found for one query.
+type TargetPresence struct {
+	Present      bool    `json:"present"`
+	Position     int     `json:"position,omitempty"` // 1-based among the query's brands
+	MatchedBy    string  `json:"matched_by,omitempty"`
+	ShareOfVoice float64 `json:"share_of_voice"`
+}
+
+// targetMatcher recognises the target site in extracted brand lists.
+type targetMatcher struct {
+	names   map[string]bool
+	domains map[string]bool
+}
+
+func newTargetMatcher(site SiteInput) targetMatcher {
+	m := targetMatcher{names: map[string]bool{}, domains: map[string]bool{}}
+
+	if n := compactName(site.Name); n != "" {
+		m.names[n] = true
+	}
+	if d := registrableDomain(site.URL); d != "" {
+		m.domains[d] = true
+		m.names[compactName(domainRoot(d))] = true
+	}
+	for _, alias := range site.Aliases {
+		if d := registrableDomain(alias); d != "" {
+			m.domains[d] = true
+		}
+	}
+	return m
+}
+
+// compactName lowercases and drops everything but letters and digits, so
+// "Acme-Tools", "acme tools" and "AcmeTools" compare equal.
+func compactName(s string) string {
+	return strings.Join(splitWords(strings.ToLower(s)), "")
+}
+
+func (m targetMatcher) match(b BrandCitation) (bool, string) {
+	if d := registrableDomain(b.URL); d != "" && m.domains[d] {
+		return true, TargetMatchURL
+	}
+	if m.names[compactName(b.Name)] {
+		return true, TargetMatchName
+	}
+	// brand names are sometimes just the domain, e.g. "acme.io"
+	if strings.Contains(b.Name, ".") && m.domains[registrableDomain(b.Name)] {
+		return true, TargetMatchDomain
+	}
+	return false, ""
+}
+
+// annotateTargetPresence records the target's presence on every query of the
+// analysis. Brands beyond maxBrands per query are not considered.
+func annotateTargetPresence(analysis *FinalBrandAnalysis, site SiteInput, maxBrands int) {
+	m := newTargetMatcher(site)
+
+	for _, g := range []*QueryGroup{&analysis.Direct, &analysis.Intermediate, &analysis.Indirect} {
+		for i := range g.Queries {
+			q := &g.Queries[i]
+			brands := q.Brands
+			if maxBrands > 0 && len(brands) > maxBrands {
+				brands = brands[:maxBrands]
+			}
+			q.Target = m.presence(brands)
+		}
+	}
+}
+
+// presence finds the target among brands, in the order they were named.
+func (m targetMatcher) presence(brands []BrandCitation) *TargetPresence {
+	presence := &TargetPresence{}
+	for pos, b := range brands {
+		if ok, by := m.match(b); ok {
+			presence.Present = true
+			presence.Position = pos + 1
+			presence.MatchedBy = by
+			presence.ShareOfVoice = 1.0 / float64(len(brands))
+			break
+		}
+	}
+	return presence
+}
+
+// cited reports whether any of urls is on one of the target's domains.
+func (m targetMatcher) cited(urls []string) bool {
+	for _, u := range urls {
+		if d := registrableDomain(u); d != "" && m.domains[d] {
+			return true
+		}
+	}
+	return false
+}
+
+func siteInputFor(db *database.Service, site models.Site) SiteInput {
+	in := SiteInput{
+		Name:        site.Name,
+		URL:         site.URL,
+		Description: site.Description,
+		Language:    site.Lang,
+	}
+
+	var (
+		aliases models.StringArray
+		market  Market
+	)
+	if err := db.DB.Table("sites").
+		Select("domain_aliases, COALESCE(country, ''), COALESCE(region, ''), COALESCE(locale, '')").
+		Where("id = ?", site.ID).Row().Scan(&aliases, &market.Country, &market.Region, &market.Locale); err != nil {
+		log.Printf("[siteInputFor] load domain aliases site_id=%d: %v", site.ID, err)
+	}
+	in.Aliases = aliases
+	if market.Country != "" {
+		in.Market = &market
+	}
+	return in
+}
+
+// PUT /sites/:id/domain-aliases  extra domains the brand is known under
+func UpdateDomainAliases(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+
+		var body struct {
+			Aliases []string `json:"aliases" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+
+		aliases := models.StringArray{}
+		for _, a := range body.Aliases {
+			d := registrableDomain(a)
+			if d == "" {
+				response.Respond(c, http.StatusBadRequest, "invalid domain: "+a, nil)
+				return
+			}
+			aliases = appendUnique(aliases, d)
+		}
+
+		if err := db.DB.Table("sites").Where("id = ?", site.ID).
+			Update("domain_aliases", aliases).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "domain aliases save failed", nil)
+			return
+		}
+
+		response.Respond(c, http.StatusOK, "Domain aliases saved", aliases)
+	}
+}
@@ -0,0 +1,139 @@
+package scoring
+
+import (
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/internal/sites"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /scoring-profile  the user's account-wide profile and its versions
+func GetUserProfile(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := sites.CurrentUser(c)
+		if !ok {
+			return
+		}
+
+		m, err := latest(db, user.ID, nil)
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load scoring profile", nil)
+			return
+		}
+		active := Default()
+		if m != nil {
+			active = FromModel(*m)
+		}
+
+		respondWithHistory(c, db, user.ID, nil, active)
+	}
+}
+
+// PUT /scoring-profile  store a new version of the account-wide profile
+func UpdateUserProfile(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := sites.CurrentUser(c)
+		if !ok {
+			return
+		}
+
+		m, err := latest(db, user.ID, nil)
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load scoring profile", nil)
+			return
+		}
+		base := Default()
+		if m != nil {
+			base = FromModel(*m)
+		}
+
+		saveFromBody(c, db, user.ID, nil, base)
+	}
+}
+
+// GET /sites/:id/scoring-profile  profile applied to the site's scans
+func GetSiteProfile(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+
+		active, err := Resolve(db, user.ID, site.ID)
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load scoring profile", nil)
+			return
+		}
+
+		respondWithHistory(c, db, user.ID, &site.ID, active)
+	}
+}
+
+// PUT /sites/:id/scoring-profile  store a new site specific version
+func UpdateSiteProfile(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, site, ok := sites.Owned(c, db)
+		if !ok {
+			return
+		}
+
+		base, err := Resolve(db, user.ID, site.ID)
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load scoring profile", nil)
+			return
+		}
+
+		saveFromBody(c, db, user.ID, &site.ID, base)
+	}
+}
+
+// saveFromBody overlays the request body on base, so omitted fields keep
+// their current values, and stores the result as the next version. Given
+// rank_weights replace the current ones, so ranks can be dropped.
+func saveFromBody(c *gin.Context, db *database.Service, userID int64, siteID *int64, base Profile) {
+	body := struct {
+		RankWeights      map[int]float64 `json:"rank_weights"`
+		TypeWeights      TypeWeights     `json:"type_weights"`
+		NormalizationCap float64         `json:"normalization_cap"`
+		MaxBrands        int             `json:"max_brands_per_query"`
+	}{
+		TypeWeights:      base.TypeWeights,
+		NormalizationCap: base.NormalizationCap,
+		MaxBrands:        base.MaxBrands,
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	p := base
+	p.TypeWeights = body.TypeWeights
+	p.NormalizationCap = body.NormalizationCap
+	p.MaxBrands = body.MaxBrands
+	if body.RankWeights != nil {
+		p.RankWeights = body.RankWeights
+	}
+
+	saved, err := Save(db, userID, siteID, p)
+	if err != nil {
+		response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	response.Respond(c, http.StatusOK, "Scoring profile saved", saved)
+}
+
+func respondWithHistory(c *gin.Context, db *database.Service, userID int64, siteID *int64, active Profile) {
+	versions, err := History(db, userID, siteID)
+	if err != nil {
+		response.Respond(c, http.StatusInternalServerError, "failed to load scoring profile", nil)
+		return
+	}
+
+	response.Respond(c, http.StatusOK, "Scoring profile loaded", gin.H{
+		"active":   active,
+		"versions": versions,
+	})
+}
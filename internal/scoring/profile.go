@@ -0,0 +1,232 @@
+package scoring
+
+import (
+	"errors"
+	"fmt"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/models"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TypeWeights struct {
+	Direct       float64 `json:"direct"`
+	Intermediate float64 `json:"intermediate"`
+	Indirect     float64 `json:"indirect"`
+}
+
+// Profile is the resolved set of weights a scan is scored with. ID is 0 for
+// the built-in default.
+type Profile struct {
+	ID               int64           `json:"id"`
+	Version          int             `json:"version"`
+	RankWeights      map[int]float64 `json:"rank_weights"`
+	TypeWeights      TypeWeights     `json:"type_weights"`
+	NormalizationCap float64         `json:"normalization_cap"`
+	// MaxBrands is how many brands of a brand workflow query are considered;
+	// the target ranked below them counts as absent.
+	MaxBrands int `json:"max_brands_per_query"`
+}
+
+func Default() Profile {
+	return Profile{
+		RankWeights:      map[int]float64{1: 1.0, 2: 0.8, 3: 0.6, 4: 0.4, 5: 0.2},
+		TypeWeights:      TypeWeights{Direct: 0.5, Intermediate: 0.3, Indirect: 0.2},
+		NormalizationCap: 10,
+		MaxBrands:        10,
+	}
+}
+
+// RankWeight is the weight of a result at rank (1-based); ranks without a
+// weight count as zero.
+func (p Profile) RankWeight(rank int) float64 {
+	return p.RankWeights[rank]
+}
+
+// Visibility combines the per-type scores with the profile's type weights.
+func (p Profile) Visibility(direct, intermediate, indirect float64) float64 {
+	w := p.TypeWeights
+	return w.Direct*direct + w.Intermediate*intermediate + w.Indirect*indirect
+}
+
+// StoredID is the value for scans.scoring_profile_id, nil for the default.
+func (p Profile) StoredID() *int64 {
+	if p.ID == 0 {
+		return nil
+	}
+	id := p.ID
+	return &id
+}
+
+func FromModel(m models.ScoringProfile) Profile {
+	p := Profile{
+		ID:               m.ID,
+		Version:          m.Version,
+		RankWeights:      map[int]float64{},
+		NormalizationCap: m.NormalizationCap,
+		MaxBrands:        m.MaxBrands,
+		TypeWeights: TypeWeights{
+			Direct:       m.TypeWeights["direct"],
+			Intermediate: m.TypeWeights["intermediate"],
+			Indirect:     m.TypeWeights["indirect"],
+		},
+	}
+	for k, v := range m.RankWeights {
+		if rank, err := strconv.Atoi(k); err == nil {
+			p.RankWeights[rank] = v
+		}
+	}
+	return p
+}
+
+func (p Profile) toModel() models.ScoringProfile {
+	rank := models.FloatMap{}
+	for k, v := range p.RankWeights {
+		rank[strconv.Itoa(k)] = v
+	}
+	return models.ScoringProfile{
+		RankWeights: rank,
+		TypeWeights: models.FloatMap{
+			"direct":       p.TypeWeights.Direct,
+			"intermediate": p.TypeWeights.Intermediate,
+			"indirect":     p.TypeWeights.Indirect,
+		},
+		NormalizationCap: p.NormalizationCap,
+		MaxBrands:        p.MaxBrands,
+	}
+}
+
+func (p Profile) validate() error {
+	if len(p.RankWeights) == 0 {
+		return errors.New("rank_weights must not be empty")
+	}
+	for rank, w := range p.RankWeights {
+		if rank < 1 || rank > 20 {
+			return fmt.Errorf("rank_weights: rank %d out of range 1..20", rank)
+		}
+		if w < 0 {
+			return fmt.Errorf("rank_weights: weight for rank %d must not be negative", rank)
+		}
+	}
+	w := p.TypeWeights
+	if w.Direct < 0 || w.Intermediate < 0 || w.Indirect < 0 {
+		return errors.New("type_weights must not be negative")
+	}
+	if w.Direct+w.Intermediate+w.Indirect == 0 {
+		return errors.New("type_weights must not all be zero")
+	}
+	if p.NormalizationCap <= 0 {
+		return errors.New("normalization_cap must be positive")
+	}
+	if p.MaxBrands < 1 || p.MaxBrands > 50 {
+		return errors.New("max_brands_per_query must be between 1 and 50")
+	}
+	return nil
+}
+
+// latest returns the newest version stored for the owner, siteID nil meaning
+// the user's account-wide profile.
+func latest(db *database.Service, userID int64, siteID *int64) (*models.ScoringProfile, error) {
+	q := db.DB.Where("user_id = ?", userID)
+	if siteID == nil {
+		q = q.Where("site_id IS NULL")
+	} else {
+		q = q.Where("site_id = ?", *siteID)
+	}
+
+	var m models.ScoringProfile
+	err := q.Order("version DESC").First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Resolve returns the profile that applies to new scans of the site: the
+// site's latest version, else the user's account-wide one, else Default.
+func Resolve(db *database.Service, userID, siteID int64) (Profile, error) {
+	m, err := latest(db, userID, &siteID)
+	if err != nil {
+		return Profile{}, err
+	}
+	if m == nil {
+		if m, err = latest(db, userID, nil); err != nil {
+			return Profile{}, err
+		}
+	}
+	if m == nil {
+		return Default(), nil
+	}
+	return FromModel(*m), nil
+}
+
+// Load returns a specific stored profile owned by the user.
+func Load(db *database.Service, userID, profileID int64) (Profile, error) {
+	var m models.ScoringProfile
+	if err := db.DB.Where("id = ? AND user_id = ?", profileID, userID).First(&m).Error; err != nil {
+		return Profile{}, err
+	}
+	return FromModel(m), nil
+}
+
+// Save stores p as the next version for the owner.
+func Save(db *database.Service, userID int64, siteID *int64, p Profile) (Profile, error) {
+	if err := p.validate(); err != nil {
+		return Profile{}, err
+	}
+
+	m := p.toModel()
+	m.UserID = userID
+	m.SiteID = siteID
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		// lock the owner so concurrent saves read MAX(version) one at a time
+		owner := tx.Table("users").Where("id = ?", userID)
+		if siteID != nil {
+			owner = tx.Table("sites").Where("id = ? AND user_id = ?", *siteID, userID)
+		}
+		var ownerID int64
+		if err := owner.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").Scan(&ownerID).Error; err != nil {
+			return err
+		}
+		if ownerID == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		q := tx.Model(&models.ScoringProfile{}).Where("user_id = ?", userID)
+		if siteID == nil {
+			q = q.Where("site_id IS NULL")
+		} else {
+			q = q.Where("site_id = ?", *siteID)
+		}
+		var current int
+		if err := q.Select("COALESCE(MAX(version), 0)").Scan(&current).Error; err != nil {
+			return err
+		}
+		m.Version = current + 1
+		return tx.Create(&m).Error
+	})
+	if err != nil {
+		return Profile{}, err
+	}
+	return FromModel(m), nil
+}
+
+// History lists every stored version for the owner, newest first.
+func History(db *database.Service, userID int64, siteID *int64) ([]models.ScoringProfile, error) {
+	q := db.DB.Where("user_id = ?", userID)
+	if siteID == nil {
+		q = q.Where("site_id IS NULL")
+	} else {
+		q = q.Where("site_id = ?", *siteID)
+	}
+	var rows []models.ScoringProfile
+	err := q.Order("version DESC").Find(&rows).Error
+	return rows, err
+}
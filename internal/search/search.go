@@ -0,0 +1,40 @@
+package search
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// OrganicResult is a single ranked web result. Position starts at 1.
+type OrganicResult struct {
+	Position int    `json:"position"`
+	Title    string `json:"title"`
+	Link     string `json:"link"`
+	Snippet  string `json:"snippet"`
+	Domain   string `json:"domain"`
+}
+
+type Options struct {
+	// Num is the number of organic results wanted, 10 when zero.
+	Num int
+	// Country is a two letter country code (Serper "gl").
+	Country string
+	// Language is a two letter language code (Serper "hl").
+	Language string
+}
+
+// Provider returns real SERP rankings for a query.
+type Provider interface {
+	Search(ctx context.Context, query string, opts Options) ([]OrganicResult, error)
+}
+
+// DomainOf returns the host of link without "www." / "m." prefixes.
+func DomainOf(link string) string {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(parsed.Hostname())
+	return strings.TrimPrefix(strings.TrimPrefix(host, "www."), "m.")
+}
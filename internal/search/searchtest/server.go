@@ -0,0 +1,102 @@
+// Package searchtest provides a local stand-in for the Serper API so search
+// backed code paths can be exercised without network access or an API key.
+package searchtest
+
+import (
+	"encoding/json"
+	"founders-toolkit-api/internal/search"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+const APIKey = "test-serper-key"
+
+// Server fakes POST /search in Serper's wire format. Queries without canned
+// results get an empty organic list.
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	results map[string][]search.OrganicResult
+	queries []string
+}
+
+func NewServer(results map[string][]search.OrganicResult) *Server {
+	s := &Server{results: results}
+	if s.results == nil {
+		s.results = map[string][]search.OrganicResult{}
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Provider returns a Serper client pointed at the fake.
+func (s *Server) Provider() *search.Serper {
+	p := search.NewSerper(APIKey)
+	p.BaseURL = s.URL
+	p.Client = s.Client()
+	return p
+}
+
+func (s *Server) SetResults(query string, results []search.OrganicResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[query] = results
+}
+
+// Queries returns every query received so far, in order.
+func (s *Server) Queries() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.queries...)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/search" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Header.Get("X-API-KEY") != APIKey {
+		http.Error(w, `{"message":"Unauthorized."}`, http.StatusForbidden)
+		return
+	}
+
+	var body struct {
+		Q   string `json:"q"`
+		Num int    `json:"num"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"message":"bad request"}`, http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.queries = append(s.queries, body.Q)
+	canned := s.results[body.Q]
+	s.mu.Unlock()
+
+	type organic struct {
+		Title    string `json:"title"`
+		Link     string `json:"link"`
+		Snippet  string `json:"snippet"`
+		Position int    `json:"position"`
+	}
+	out := struct {
+		Organic []organic `json:"organic"`
+	}{Organic: []organic{}}
+	for i, res := range canned {
+		if body.Num > 0 && i >= body.Num {
+			break
+		}
+		out.Organic = append(out.Organic, organic{
+			Title:    res.Title,
+			Link:     res.Link,
+			Snippet:  res.Snippet,
+			Position: res.Position,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
@@ -0,0 +1,117 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+const defaultSerperBaseURL = "https://google.serper.dev"
+
+// Serper talks to the serper.dev Google SERP API.
+type Serper struct {
+	APIKey  string
+	BaseURL string
+	Client  *http.Client
+}
+
+func NewSerper(apiKey string) *Serper {
+	return &Serper{
+		APIKey:  apiKey,
+		BaseURL: defaultSerperBaseURL,
+		Client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// FromEnv returns a Serper provider when SERPER_API_KEY is set, nil otherwise.
+// SERPER_BASE_URL overrides the endpoint, e.g. to point at a local fake.
+func FromEnv() Provider {
+	key := os.Getenv("SERPER_API_KEY")
+	if key == "" {
+		return nil
+	}
+	s := NewSerper(key)
+	if base := os.Getenv("SERPER_BASE_URL"); base != "" {
+		s.BaseURL = base
+	}
+	return s
+}
+
+type serperRequest struct {
+	Q   string `json:"q"`
+	Num int    `json:"num,omitempty"`
+	GL  string `json:"gl,omitempty"`
+	HL  string `json:"hl,omitempty"`
+}
+
+type serperResponse struct {
+	Organic []struct {
+		Title    string `json:"title"`
+		Link     string `json:"link"`
+		Snippet  string `json:"snippet"`
+		Position int    `json:"position"`
+	} `json:"organic"`
+}
+
+func (s *Serper) Search(ctx context.Context, query string, opts Options) ([]OrganicResult, error) {
+	num := opts.Num
+	if num <= 0 {
+		num = 10
+	}
+
+	body, err := json.Marshal(serperRequest{Q: query, Num: num, GL: opts.Country, HL: opts.Language})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.BaseURL+"/search", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-API-KEY", s.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	respBody, _ := io.ReadAll(res.Body)
+	if res.StatusCode >= 300 {
+		return nil, fmt.Errorf("serper error: %s | body=%s", res.Status, respBody)
+	}
+
+	var parsed serperResponse
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return nil, fmt.Errorf("decode serper response: %w", err)
+	}
+
+	results := make([]OrganicResult, 0, len(parsed.Organic))
+	for i, o := range parsed.Organic {
+		pos := o.Position
+		if pos <= 0 {
+			pos = i + 1
+		}
+		results = append(results, OrganicResult{
+			Position: pos,
+			Title:    o.Title,
+			Link:     o.Link,
+			Snippet:  o.Snippet,
+			Domain:   DomainOf(o.Link),
+		})
+		if len(results) == num {
+			break
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,79 @@
+package search_test
+
+import (
+	"context"
+	"founders-toolkit-api/internal/search"
+	"founders-toolkit-api/internal/search/searchtest"
+	"reflect"
+	"testing"
+)
+
+func TestSerperSearch(t *testing.T) {
+	srv := searchtest.NewServer(map[string][]search.OrganicResult{
+		"crm for startups": {
+			{Position: 1, Title: "Best CRMs", Link: "https://www.example.com/crm", Snippet: "a list"},
+			{Title: "Acme CRM", Link: "https://m.acme.io/", Snippet: "Acme"},
+			{Position: 3, Title: "Reddit", Link: "https://reddit.com/r/startups", Snippet: "thread"},
+		},
+	})
+	defer srv.Close()
+
+	tests := []struct {
+		name  string
+		query string
+		opts  search.Options
+		want  []search.OrganicResult
+	}{
+		{
+			name:  "all results, missing positions filled in",
+			query: "crm for startups",
+			opts:  search.Options{Country: "de", Language: "de"},
+			want: []search.OrganicResult{
+				{Position: 1, Title: "Best CRMs", Link: "https://www.example.com/crm", Snippet: "a list", Domain: "example.com"},
+				{Position: 2, Title: "Acme CRM", Link: "https://m.acme.io/", Snippet: "Acme", Domain: "acme.io"},
+				{Position: 3, Title: "Reddit", Link: "https://reddit.com/r/startups", Snippet: "thread", Domain: "reddit.com"},
+			},
+		},
+		{
+			name:  "limited to Num",
+			query: "crm for startups",
+			opts:  search.Options{Num: 1},
+			want: []search.OrganicResult{
+				{Position: 1, Title: "Best CRMs", Link: "https://www.example.com/crm", Snippet: "a list", Domain: "example.com"},
+			},
+		},
+		{
+			name:  "no results",
+			query: "unknown query",
+			want:  []search.OrganicResult{},
+		},
+	}
+
+	p := srv.Provider()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.Search(context.Background(), tt.query, tt.opts)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+
+	if got, want := srv.Queries(), []string{"crm for startups", "crm for startups", "unknown query"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("queries = %q, want %q", got, want)
+	}
+}
+
+func TestSerperSearchRejectedKey(t *testing.T) {
+	srv := searchtest.NewServer(nil)
+	defer srv.Close()
+
+	p := srv.Provider()
+	p.APIKey = "wrong"
+	if _, err := p.Search(context.Background(), "anything", search.Options{}); err == nil {
+		t.Fatal("Search with a rejected key should fail")
+	}
+}
@@ -1,7 +1,20 @@
 package server
 
 import (
+	"founders-toolkit-api/internal/account"
+	"founders-toolkit-api/internal/alerts"
+	"founders-toolkit-api/internal/analytics"
+	"founders-toolkit-api/internal/audit"
 	"founders-toolkit-api/internal/auth"
+	"founders-toolkit-api/internal/competitors"
+	"founders-toolkit-api/internal/jobs"
+	"founders-toolkit-api/internal/llm"
+	"founders-toolkit-api/internal/plans"
+	"founders-toolkit-api/internal/prompts"
+	"founders-toolkit-api/internal/scanmanager"
+	"founders-toolkit-api/internal/scoring"
+	"founders-toolkit-api/internal/webhooks"
+	"founders-toolkit-api/models"
 	"net/http"
 
 	"github.com/gin-contrib/cors"
@@ -9,6 +22,8 @@ import (
 )
 
 func (s *Server) setupMiddlewares() {
+	s.router.Use(auth.AuditImpersonation(s.audit))
+
 	s.router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH", "*"},
@@ -24,10 +39,102 @@ func (s *Server) registerRoutes() {
 
 	authGroup := s.router.Group("/auth")
 	{
-		authGroup.POST("/signup", auth.SignUp(s.db))
-		authGroup.POST("/login", auth.Login(s.db))
+		authGroup.POST("/signup", auth.SignUp(s.db, s.audit))
+		authGroup.POST("/login", auth.Login(s.db, s.audit))
 		authGroup.POST("/logout", auth.Logout)
 		authGroup.POST("/refresh", auth.RefreshAccessToken(s.db))
-		authGroup.POST("/change-password", auth.AuthenticateUser(s.db), auth.ChangePassword(s.db))
+		authGroup.POST("/change-password", auth.AuthenticateUser(s.db), auth.ForbidImpersonation(), auth.ChangePassword(s.db, s.audit))
+	}
+
+	scanGroup := s.router.Group("/scans", auth.AuthenticateUser(s.db))
+	{
+		scanGroup.POST("", scanmanager.AnalyzeAndCreateScan(s.db, s.audit))
+		scanGroup.POST("/brand", scanmanager.BrandWorkflowHandler(s.db, s.audit))
+		scanGroup.POST("/engines", scanmanager.EngineComparisonHandler(s.db, s.audit))
+		scanGroup.GET("/:id", scanmanager.GetScan(s.db))
+	}
+
+	jobGroup := s.router.Group("/jobs", auth.AuthenticateUser(s.db))
+	{
+		jobGroup.GET("/:id", jobs.GetJob)
+		jobGroup.DELETE("/:id", jobs.CancelJob)
+		jobGroup.GET("/:id/events", jobs.StreamEvents)
+	}
+
+	s.router.POST("/workflow-runs/:id/resume", auth.AuthenticateUser(s.db), scanmanager.ResumeWorkflowRun(s.db, s.audit))
+
+	siteGroup := s.router.Group("/sites", auth.AuthenticateUser(s.db))
+	{
+		siteGroup.GET("/:id/scans", scanmanager.ListScansForSite(s.db))
+		siteGroup.GET("/:id/brand-analyses", scanmanager.ListBrandAnalysesForSite(s.db))
+		siteGroup.POST("/:id/brand-analyses/rescore", scanmanager.RescoreBrandAnalyses(s.db))
+		siteGroup.PUT("/:id/domain-aliases", scanmanager.UpdateDomainAliases(s.db))
+		siteGroup.GET("/:id/workflow-runs", scanmanager.ListWorkflowRuns(s.db))
+		siteGroup.GET("/:id/query-sets", scanmanager.ListQuerySets(s.db))
+		siteGroup.POST("/:id/query-sets", scanmanager.CreateQuerySet(s.db))
+		siteGroup.GET("/:id/query-sets/:qid", scanmanager.GetQuerySet(s.db))
+		siteGroup.PUT("/:id/query-sets/:qid", scanmanager.UpdateQuerySet(s.db))
+		siteGroup.DELETE("/:id/query-sets/:qid", scanmanager.DeleteQuerySet(s.db))
+		siteGroup.GET("/:id/markets", scanmanager.ListSiteMarkets(s.db))
+		siteGroup.PUT("/:id/markets", scanmanager.UpdateSiteMarkets(s.db))
+		siteGroup.GET("/:id/markets/visibility", scanmanager.SiteMarketVisibility(s.db))
+		siteGroup.GET("/:id/competitors", competitors.ListCompetitors(s.db))
+		siteGroup.GET("/:id/competitors/:cid", competitors.GetCompetitor(s.db))
+		siteGroup.POST("/:id/competitors/:cid/confirm", competitors.SetStatus(s.db, models.CompetitorStatusConfirmed))
+		siteGroup.POST("/:id/competitors/:cid/ignore", competitors.SetStatus(s.db, models.CompetitorStatusIgnored))
+		siteGroup.GET("/:id/scoring-profile", scoring.GetSiteProfile(s.db))
+		siteGroup.PUT("/:id/scoring-profile", scoring.UpdateSiteProfile(s.db))
+		siteGroup.GET("/:id/analytics", analytics.SiteAnalytics(s.db))
+		siteGroup.GET("/:id/alerts", alerts.ListRules(s.db))
+		siteGroup.POST("/:id/alerts", alerts.CreateRule(s.db))
+		siteGroup.PUT("/:id/alerts/:rid", alerts.UpdateRule(s.db))
+		siteGroup.DELETE("/:id/alerts/:rid", alerts.DeleteRule(s.db))
+		siteGroup.POST("/:id/alerts/:rid/test", alerts.TestRule(s.db))
+		siteGroup.GET("/:id/alert-events", alerts.ListEvents(s.db))
+	}
+
+	s.router.GET("/analytics/portfolio", auth.AuthenticateUser(s.db), analytics.PortfolioAnalytics(s.db))
+
+	profileGroup := s.router.Group("/scoring-profile", auth.AuthenticateUser(s.db))
+	{
+		profileGroup.GET("", scoring.GetUserProfile(s.db))
+		profileGroup.PUT("", scoring.UpdateUserProfile(s.db))
+	}
+
+	s.router.GET("/me", auth.AuthenticateUser(s.db), plans.Me(s.db))
+	s.router.GET("/me/usage", auth.AuthenticateUser(s.db), llm.MonthlyUsage(s.db))
+	s.router.GET("/plans", plans.ListPlans(s.db))
+
+	meGroup := s.router.Group("/me", auth.AuthenticateUser(s.db), auth.ForbidImpersonation())
+	{
+		meGroup.POST("/export", account.RequestExport(s.db, s.bucket, s.audit))
+		meGroup.GET("/export/:id", account.GetExport(s.db, s.bucket))
+		meGroup.POST("/delete", account.RequestDeletion(s.db, s.audit))
+		meGroup.DELETE("/delete", account.CancelDeletion(s.db, s.audit))
+	}
+
+	webhookGroup := s.router.Group("/webhooks", auth.AuthenticateUser(s.db), auth.ForbidImpersonation())
+	{
+		webhookGroup.GET("", webhooks.ListEndpoints(s.db))
+		webhookGroup.POST("", webhooks.CreateEndpoint(s.db))
+		webhookGroup.PUT("/:id", webhooks.UpdateEndpoint(s.db))
+		webhookGroup.DELETE("/:id", webhooks.DeleteEndpoint(s.db))
+		webhookGroup.GET("/:id/deliveries", webhooks.ListDeliveries(s.db))
+		webhookGroup.POST("/:id/deliveries/:did/redeliver", webhooks.Redeliver(s.db))
+	}
+
+	s.router.GET("/audit", auth.AuthenticateUser(s.db), audit.ListMyEvents(s.db))
+
+	adminGroup := s.router.Group("/admin", auth.AuthenticateUser(s.db), auth.RequireAdmin())
+	{
+		adminGroup.GET("/audit", audit.ListAllEvents(s.db))
+		adminGroup.POST("/impersonate/:id", auth.ForbidImpersonation(), auth.Impersonate(s.db, s.audit))
+		adminGroup.PUT("/users/:id/plan", plans.SetUserPlan(s.db, s.audit))
+		adminGroup.GET("/llm-cache", llm.CacheStatus(s.db))
+		adminGroup.DELETE("/llm-cache", llm.PurgeCache(s.db))
+		adminGroup.GET("/prompts", prompts.ListPrompts(s.db))
+		adminGroup.POST("/prompts", prompts.CreatePrompt(s.db))
+		adminGroup.PUT("/prompts/:id", prompts.UpdatePrompt(s.db))
+		adminGroup.GET("/prompts/:name/results", prompts.PromptResults(s.db))
 	}
 }
@@ -1,16 +1,24 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"founders-toolkit-api/internal/account"
+	"founders-toolkit-api/internal/audit"
 	"founders-toolkit-api/internal/bucket"
 	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/llm"
+	"founders-toolkit-api/internal/prompts"
+	"founders-toolkit-api/internal/webhooks"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Server struct {
 	db     *database.Service
+	audit  *audit.Service
 	bucket *bucket.Service
 	router *gin.Engine
 	port   string
@@ -19,18 +27,26 @@ type Server struct {
 func NewServer() *Server {
 	db := database.New()
 	router := gin.Default()
-	// bucket := bucket.New()
 
 	s := &Server{
-		db: db,
-		// bucket: bucket,
+		db:     db,
+		audit:  audit.New(db),
 		router: router,
 		port:   os.Getenv("PORT"),
 	}
+	if os.Getenv("BUCKET_ENDPOINT") != "" {
+		s.bucket = bucket.New()
+	}
 
 	s.setupMiddlewares()
 	s.registerRoutes()
 
+	go account.StartPurger(context.Background(), s.db, s.bucket, s.audit, time.Hour)
+	go webhooks.StartWorker(context.Background(), s.db, 15*time.Second)
+	llm.EnableCache(s.db)
+	prompts.Enable(s.db)
+	go llm.StartCacheJanitor(context.Background(), s.db, time.Hour)
+
 	return s
 }
 
@@ -0,0 +1,39 @@
+// Package sites holds helpers shared by the handlers of site scoped routes.
+package sites
+
+import (
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CurrentUser is the signed-in user. It responds 401 when there is none;
+// ok is false then and the handler should return.
+func CurrentUser(c *gin.Context) (models.User, bool) {
+	uRaw, _ := c.Get("user")
+	user, _ := uRaw.(models.User)
+	if user.ID == 0 {
+		response.Respond(c, http.StatusUnauthorized, "unauthorized", nil)
+		return user, false
+	}
+	return user, true
+}
+
+// Owned loads the site of the :id path parameter for the signed-in user.
+// It responds 401 without a user and 404 when the site is missing or
+// belongs to someone else; ok is false then and the handler should return.
+func Owned(c *gin.Context, db *database.Service) (user models.User, site models.Site, ok bool) {
+	if user, ok = CurrentUser(c); !ok {
+		return user, site, false
+	}
+
+	if err := db.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).
+		First(&site).Error; err != nil || site.ID == 0 {
+		response.Respond(c, http.StatusNotFound, "site not found", nil)
+		return user, site, false
+	}
+	return user, site, true
+}
@@ -0,0 +1,227 @@
+package webhooks
+
+import (
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/egress"
+	"founders-toolkit-api/internal/response"
+	"founders-toolkit-api/internal/sites"
+	"founders-toolkit-api/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type endpointRequest struct {
+	URL     string   `json:"url" binding:"required"`
+	Events  []string `json:"events"`
+	Enabled *bool    `json:"enabled"`
+}
+
+// GET /webhooks
+func ListEndpoints(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := sites.CurrentUser(c)
+		if !ok {
+			return
+		}
+
+		var endpoints []models.WebhookEndpoint
+		if err := db.DB.Where("user_id = ?", user.ID).Order("id").Find(&endpoints).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load webhooks", nil)
+			return
+		}
+		response.Respond(c, http.StatusOK, "Webhooks loaded", endpoints)
+	}
+}
+
+// POST /webhooks  the signing secret is only returned here
+func CreateEndpoint(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := sites.CurrentUser(c)
+		if !ok {
+			return
+		}
+
+		var body endpointRequest
+		if err := c.ShouldBindJSON(&body); err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+
+		secret, err := newSecret()
+		if err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to generate secret", nil)
+			return
+		}
+		ep := models.WebhookEndpoint{UserID: user.ID, Secret: secret, Enabled: true}
+		if !applyEndpoint(c, &ep, body) {
+			return
+		}
+		if err := db.DB.Create(&ep).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "webhook save failed", nil)
+			return
+		}
+
+		response.Respond(c, http.StatusCreated, "Webhook created", gin.H{
+			"webhook": ep,
+			"secret":  secret,
+		})
+	}
+}
+
+// PUT /webhooks/:id
+func UpdateEndpoint(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ep, ok := ownedEndpoint(c, db)
+		if !ok {
+			return
+		}
+
+		var body endpointRequest
+		if err := c.ShouldBindJSON(&body); err != nil {
+			response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		if !applyEndpoint(c, &ep, body) {
+			return
+		}
+		if err := db.DB.Save(&ep).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "webhook save failed", nil)
+			return
+		}
+		response.Respond(c, http.StatusOK, "Webhook updated", ep)
+	}
+}
+
+// DELETE /webhooks/:id
+func DeleteEndpoint(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ep, ok := ownedEndpoint(c, db)
+		if !ok {
+			return
+		}
+		if err := db.DB.Delete(&ep).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "webhook delete failed", nil)
+			return
+		}
+		response.Respond(c, http.StatusOK, "Webhook deleted", nil)
+	}
+}
+
+// GET /webhooks/:id/deliveries?status=&limit=
+func ListDeliveries(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ep, ok := ownedEndpoint(c, db)
+		if !ok {
+			return
+		}
+
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if limit <= 0 || limit > 200 {
+			limit = 50
+		}
+
+		q := db.DB.Where("endpoint_id = ?", ep.ID)
+		if status := c.Query("status"); status != "" {
+			q = q.Where("status = ?", status)
+		}
+
+		var deliveries []models.WebhookDelivery
+		if err := q.Order("created_at DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "failed to load deliveries", nil)
+			return
+		}
+		response.Respond(c, http.StatusOK, "Deliveries loaded", deliveries)
+	}
+}
+
+// POST /webhooks/:id/deliveries/:did/redeliver  queues a fresh copy of a past
+// delivery; the original stays in the log
+func Redeliver(db *database.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ep, ok := ownedEndpoint(c, db)
+		if !ok {
+			return
+		}
+
+		var orig models.WebhookDelivery
+		if err := db.DB.Where("id = ? AND endpoint_id = ?", c.Param("did"), ep.ID).
+			First(&orig).Error; err != nil || orig.ID == 0 {
+			response.Respond(c, http.StatusNotFound, "delivery not found", nil)
+			return
+		}
+		if !ep.Enabled {
+			response.Respond(c, http.StatusConflict, "webhook is disabled", nil)
+			return
+		}
+
+		d := models.WebhookDelivery{
+			EndpointID:    ep.ID,
+			Event:         orig.Event,
+			Payload:       orig.Payload,
+			Status:        models.DeliveryStatusPending,
+			NextAttemptAt: time.Now(),
+		}
+		if err := db.DB.Create(&d).Error; err != nil {
+			response.Respond(c, http.StatusInternalServerError, "redelivery failed", nil)
+			return
+		}
+		wakeWorker()
+
+		response.Respond(c, http.StatusAccepted, "Redelivery queued", d)
+	}
+}
+
+// applyEndpoint validates body onto ep, responding 400 on failure. An empty
+// event list subscribes to every event.
+func applyEndpoint(c *gin.Context, ep *models.WebhookEndpoint, body endpointRequest) bool {
+	if err := egress.CheckURL(c.Request.Context(), body.URL); err != nil {
+		response.Respond(c, http.StatusBadRequest, err.Error(), nil)
+		return false
+	}
+
+	events := models.StringArray{}
+	for _, e := range body.Events {
+		if !knownEvent(e) {
+			response.Respond(c, http.StatusBadRequest, "unknown event: "+e, gin.H{"events": Events})
+			return false
+		}
+		events = append(events, e)
+	}
+	if len(events) == 0 {
+		events = append(events, Events...)
+	}
+
+	ep.URL = body.URL
+	ep.Events = events
+	if body.Enabled != nil {
+		ep.Enabled = *body.Enabled
+	}
+	return true
+}
+
+func knownEvent(e string) bool {
+	for _, known := range Events {
+		if e == known {
+			return true
+		}
+	}
+	return false
+}
+
+func ownedEndpoint(c *gin.Context, db *database.Service) (models.WebhookEndpoint, bool) {
+	user, ok := sites.CurrentUser(c)
+	if !ok {
+		return models.WebhookEndpoint{}, false
+	}
+
+	var ep models.WebhookEndpoint
+	if err := db.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).
+		First(&ep).Error; err != nil || ep.ID == 0 {
+		response.Respond(c, http.StatusNotFound, "webhook not found", nil)
+		return ep, false
+	}
+	return ep, true
+}
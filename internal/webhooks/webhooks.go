@@ -0,0 +1,111 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/models"
+	"log"
+	"strconv"
+	"time"
+)
+
+const (
+	EventScanCompleted          = "scan.completed"
+	EventScanFailed             = "scan.failed"
+	EventBrandAnalysisCompleted = "brand_analysis.completed"
+	EventBrandAnalysisFailed    = "brand_analysis.failed"
+)
+
+var Events = []string{
+	EventScanCompleted,
+	EventScanFailed,
+	EventBrandAnalysisCompleted,
+	EventBrandAnalysisFailed,
+}
+
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Envelope is the JSON body every endpoint receives.
+type Envelope struct {
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Dispatch queues event for every enabled endpoint of the user subscribed to
+// it. Delivery happens on the worker; errors are logged.
+func Dispatch(db *database.Service, userID int64, event string, data any) {
+	var endpoints []models.WebhookEndpoint
+	if err := db.DB.Where("user_id = ? AND enabled AND events @> ?::jsonb", userID,
+		`["`+event+`"]`).Find(&endpoints).Error; err != nil {
+		log.Printf("[webhooks.Dispatch] load endpoints user_id=%d: %v", userID, err)
+		return
+	}
+	if len(endpoints) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(Envelope{Event: event, CreatedAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		log.Printf("[webhooks.Dispatch] marshal %s: %v", event, err)
+		return
+	}
+
+	now := time.Now()
+	deliveries := make([]models.WebhookDelivery, 0, len(endpoints))
+	for _, ep := range endpoints {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			EndpointID:    ep.ID,
+			Event:         event,
+			Payload:       models.JSONB(payload),
+			Status:        models.DeliveryStatusPending,
+			NextAttemptAt: now,
+		})
+	}
+	if err := db.DB.Create(&deliveries).Error; err != nil {
+		log.Printf("[webhooks.Dispatch] queue %s user_id=%d: %v", event, userID, err)
+		return
+	}
+	wakeWorker()
+}
+
+// Sign returns the X-Webhook-Signature value for a body sent at timestamp:
+// "sha256=" + hex(HMAC-SHA256(secret, "<timestamp>.<body>")).
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignatureTolerance is how far a delivery's timestamp may be from the
+// receiver's clock before Verify rejects it as a replay.
+const SignatureTolerance = 5 * time.Minute
+
+// Verify checks a received signature and that it was made within
+// SignatureTolerance of now; receivers can use it as a reference.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	skew := time.Since(time.Unix(timestamp, 0))
+	if skew > SignatureTolerance || skew < -SignatureTolerance {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,79 @@
+package webhooks
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	const secret = "whsec_test"
+	body := []byte(`{"event":"scan.completed","data":{"id":1}}`)
+	now := time.Now().Unix()
+	sig := Sign(secret, now, body)
+
+	if want := "sha256="; len(sig) != len(want)+64 || sig[:len(want)] != want {
+		t.Fatalf("Sign = %q, want sha256= and 64 hex characters", sig)
+	}
+	if Sign(secret, now, body) != sig {
+		t.Fatal("Sign is not deterministic")
+	}
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      []byte
+		signature string
+		want      bool
+	}{
+		{name: "valid", secret: secret, timestamp: now, body: body, signature: sig, want: true},
+		{name: "tampered body", secret: secret, timestamp: now, body: []byte(`{"event":"scan.completed","data":{"id":2}}`), signature: sig},
+		{name: "tampered timestamp", secret: secret, timestamp: now + 1, body: body, signature: sig},
+		{name: "wrong secret", secret: "whsec_other", timestamp: now, body: body, signature: sig},
+		{name: "missing prefix", secret: secret, timestamp: now, body: body, signature: sig[len("sha256="):]},
+		{name: "empty signature", secret: secret, timestamp: now, body: body},
+		{name: "within skew", secret: secret, timestamp: now - 240, body: body, signature: Sign(secret, now-240, body), want: true},
+		{name: "too old", secret: secret, timestamp: now - 360, body: body, signature: Sign(secret, now-360, body)},
+		{name: "too far ahead", secret: secret, timestamp: now + 360, body: body, signature: Sign(secret, now+360, body)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.secret, tt.timestamp, tt.body, tt.signature); got != tt.want {
+				t.Errorf("Verify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{4, 4 * time.Minute},
+		{5, 8 * time.Minute},
+		{8, 64 * time.Minute},
+		{9, maxBackoff},  // 128m is capped
+		{40, maxBackoff}, // overflowing shifts are capped too
+		{70, maxBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+
+	// a delivery that keeps failing is retried over about a quarter hour
+	var total time.Duration
+	for a := 1; a < maxAttempts; a++ {
+		total += backoff(a)
+	}
+	if want := 30*time.Second + time.Minute + 2*time.Minute + 4*time.Minute + 8*time.Minute; total != want {
+		t.Errorf("retries span %v, want %v", total, want)
+	}
+}
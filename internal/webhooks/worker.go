@@ -0,0 +1,150 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"founders-toolkit-api/internal/database"
+	"founders-toolkit-api/internal/egress"
+	"founders-toolkit-api/models"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	maxAttempts  = 6
+	baseBackoff  = 30 * time.Second
+	maxBackoff   = 2 * time.Hour
+	batchSize    = 50
+	sendTimeout  = 10 * time.Second
+	maxErrorBody = 512
+)
+
+var (
+	wake   = make(chan struct{}, 1)
+	client = egress.NewClient(sendTimeout)
+)
+
+func wakeWorker() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// backoff is the wait after the given number of failed attempts:
+// 30s, 1m, 2m, 4m, ... capped at maxBackoff.
+func backoff(attempts int) time.Duration {
+	d := baseBackoff << (attempts - 1)
+	if d <= 0 || d > maxBackoff {
+		return maxBackoff
+	}
+	return d
+}
+
+// StartWorker delivers due webhooks every interval, or right away when new
+// ones are queued.
+func StartWorker(ctx context.Context, db *database.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+		deliverDue(ctx, db)
+	}
+}
+
+func deliverDue(ctx context.Context, db *database.Service) {
+	for {
+		var due []models.WebhookDelivery
+		if err := db.DB.Where("status = ? AND next_attempt_at <= ?", models.DeliveryStatusPending, time.Now()).
+			Order("next_attempt_at").Limit(batchSize).Find(&due).Error; err != nil {
+			log.Printf("[webhooks.deliverDue] load: %v", err)
+			return
+		}
+
+		for _, d := range due {
+			if ctx.Err() != nil {
+				return
+			}
+			attempt(ctx, db, d)
+		}
+		if len(due) < batchSize {
+			return
+		}
+	}
+}
+
+func attempt(ctx context.Context, db *database.Service, d models.WebhookDelivery) {
+	var ep models.WebhookEndpoint
+	if err := db.DB.First(&ep, d.EndpointID).Error; err != nil {
+		log.Printf("[webhooks.attempt] delivery_id=%d endpoint missing: %v", d.ID, err)
+		return
+	}
+
+	code, err := send(ctx, ep, d)
+	d.Attempts++
+	updates := map[string]any{"attempts": d.Attempts}
+	if code != 0 {
+		updates["last_status_code"] = code
+	}
+
+	switch {
+	case err == nil:
+		updates["status"] = models.DeliveryStatusSucceeded
+		updates["delivered_at"] = time.Now()
+		updates["last_error"] = ""
+	case d.Attempts >= maxAttempts || !ep.Enabled:
+		updates["status"] = models.DeliveryStatusFailed
+		updates["last_error"] = err.Error()
+	default:
+		updates["next_attempt_at"] = time.Now().Add(backoff(d.Attempts))
+		updates["last_error"] = err.Error()
+	}
+
+	if err := db.DB.Model(&models.WebhookDelivery{}).Where("id = ?", d.ID).Updates(updates).Error; err != nil {
+		log.Printf("[webhooks.attempt] save delivery_id=%d: %v", d.ID, err)
+	}
+}
+
+// send POSTs the delivery and returns the response status code.
+func send(ctx context.Context, ep models.WebhookEndpoint, d models.WebhookDelivery) (int, error) {
+	if !ep.Enabled {
+		return 0, fmt.Errorf("endpoint disabled")
+	}
+
+	ts := time.Now().Unix()
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "founders-toolkit-webhooks/1")
+	req.Header.Set(HeaderEvent, d.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(ep.Secret, ts, d.Payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, fmt.Errorf("endpoint responded %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return resp.StatusCode, nil
+}
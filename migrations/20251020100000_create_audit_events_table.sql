@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(32) NOT NULL DEFAULT 'user';
+
+CREATE TABLE IF NOT EXISTS audit_events (
+  id           BIGSERIAL PRIMARY KEY,
+  actor_id     BIGINT REFERENCES users(id) ON DELETE SET NULL,
+  action       VARCHAR(64) NOT NULL,
+  target_type  VARCHAR(64),
+  target_id    VARCHAR(64),
+  ip           VARCHAR(64),
+  user_agent   TEXT,
+  metadata     JSONB NOT NULL DEFAULT '{}'::jsonb,
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor_created ON audit_events (actor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events (action);
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_events;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
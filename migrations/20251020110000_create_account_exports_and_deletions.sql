@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS account_exports (
+  id            BIGSERIAL PRIMARY KEY,
+  user_id       BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  status        VARCHAR(16) NOT NULL DEFAULT 'pending',
+  object_key    TEXT,
+  error         TEXT,
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  completed_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_exports_user ON account_exports (user_id, created_at DESC);
+
+-- user_id intentionally has no foreign key: the row outlives the user as a record of the erasure.
+CREATE TABLE IF NOT EXISTS account_deletions (
+  id             BIGSERIAL PRIMARY KEY,
+  user_id        BIGINT NOT NULL,
+  status         VARCHAR(16) NOT NULL DEFAULT 'pending',
+  scheduled_for  TIMESTAMPTZ NOT NULL,
+  created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  completed_at   TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_deletions_pending_user
+  ON account_deletions (user_id) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE IF EXISTS account_deletions;
+DROP TABLE IF EXISTS account_exports;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS scoring_profiles (
+  id                 BIGSERIAL PRIMARY KEY,
+  user_id            BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  site_id            BIGINT REFERENCES sites(id) ON DELETE CASCADE,
+  version            INT NOT NULL,
+  rank_weights       JSONB NOT NULL,
+  type_weights       JSONB NOT NULL,
+  normalization_cap  DOUBLE PRECISION NOT NULL DEFAULT 10,
+  created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_scoring_profiles_owner_version
+  ON scoring_profiles (user_id, COALESCE(site_id, 0), version);
+
+ALTER TABLE scans ADD COLUMN IF NOT EXISTS scoring_profile_id BIGINT REFERENCES scoring_profiles(id) ON DELETE SET NULL;
+ALTER TABLE brand_analyses ADD COLUMN IF NOT EXISTS scoring_profile_id BIGINT REFERENCES scoring_profiles(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE brand_analyses DROP COLUMN IF EXISTS scoring_profile_id;
+ALTER TABLE scans DROP COLUMN IF EXISTS scoring_profile_id;
+DROP TABLE IF EXISTS scoring_profiles;
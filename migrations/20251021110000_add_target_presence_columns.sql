@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE sites ADD COLUMN IF NOT EXISTS domain_aliases JSONB NOT NULL DEFAULT '[]'::jsonb;
+ALTER TABLE brand_analyses ADD COLUMN IF NOT EXISTS share_of_voice DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE brand_analyses DROP COLUMN IF EXISTS share_of_voice;
+ALTER TABLE sites DROP COLUMN IF EXISTS domain_aliases;
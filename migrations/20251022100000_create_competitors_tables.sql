@@ -0,0 +1,36 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS competitors (
+  id               BIGSERIAL PRIMARY KEY,
+  site_id          BIGINT NOT NULL REFERENCES sites(id) ON DELETE CASCADE,
+  user_id          BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name             TEXT NOT NULL,
+  normalized_name  TEXT NOT NULL,
+  url              TEXT,
+  domain           TEXT,
+  status           VARCHAR(16) NOT NULL DEFAULT 'suggested',
+  first_seen_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  last_seen_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE (site_id, normalized_name)
+);
+
+CREATE TABLE IF NOT EXISTS competitor_appearances (
+  id                 BIGSERIAL PRIMARY KEY,
+  competitor_id      BIGINT NOT NULL REFERENCES competitors(id) ON DELETE CASCADE,
+  brand_analysis_id  BIGINT NOT NULL REFERENCES brand_analyses(id) ON DELETE CASCADE,
+  query_type         VARCHAR(16) NOT NULL,
+  query              TEXT NOT NULL,
+  position           INT NOT NULL,
+  target_position    INT NOT NULL DEFAULT 0,
+  won                BOOLEAN NOT NULL DEFAULT FALSE,
+  citations          JSONB NOT NULL DEFAULT '[]'::jsonb,
+  created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_competitor_appearances_competitor ON competitor_appearances (competitor_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_competitor_appearances_analysis ON competitor_appearances (brand_analysis_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS competitor_appearances;
+DROP TABLE IF EXISTS competitors;
@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS alert_rules (
+  id                 BIGSERIAL PRIMARY KEY,
+  user_id            BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  site_id            BIGINT NOT NULL REFERENCES sites(id) ON DELETE CASCADE,
+  kind               VARCHAR(32) NOT NULL,
+  source             VARCHAR(16) NOT NULL DEFAULT 'any',
+  threshold          DOUBLE PRECISION NOT NULL DEFAULT 0,
+  competitor_id      BIGINT REFERENCES competitors(id) ON DELETE CASCADE,
+  channel            VARCHAR(16) NOT NULL,
+  target             TEXT NOT NULL,
+  enabled            BOOLEAN NOT NULL DEFAULT TRUE,
+  last_triggered_at  TIMESTAMPTZ,
+  created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_rules_site ON alert_rules (site_id) WHERE enabled;
+
+CREATE TABLE IF NOT EXISTS alert_events (
+  id           BIGSERIAL PRIMARY KEY,
+  rule_id      BIGINT NOT NULL REFERENCES alert_rules(id) ON DELETE CASCADE,
+  site_id      BIGINT NOT NULL REFERENCES sites(id) ON DELETE CASCADE,
+  source       VARCHAR(16) NOT NULL,
+  source_id    BIGINT NOT NULL,
+  message      TEXT NOT NULL,
+  payload      JSONB,
+  delivered    BOOLEAN NOT NULL DEFAULT FALSE,
+  error        TEXT,
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_events_site ON alert_events (site_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS alert_events;
+DROP TABLE IF EXISTS alert_rules;
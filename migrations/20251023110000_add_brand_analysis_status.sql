@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE brand_analyses ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'completed';
+
+-- +goose Down
+ALTER TABLE brand_analyses DROP COLUMN IF EXISTS status;
@@ -0,0 +1,31 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS workflow_runs (
+  id                 BIGSERIAL PRIMARY KEY,
+  user_id            BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  site_id            BIGINT NOT NULL REFERENCES sites(id) ON DELETE CASCADE,
+  kind               VARCHAR(32) NOT NULL,
+  status             VARCHAR(16) NOT NULL DEFAULT 'running',
+  job_id             TEXT,
+  config             JSONB NOT NULL DEFAULT '{}'::jsonb,
+  error              TEXT,
+  attempts           INT NOT NULL DEFAULT 1,
+  brand_analysis_id  BIGINT REFERENCES brand_analyses(id) ON DELETE SET NULL,
+  created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  finished_at        TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_site ON workflow_runs (site_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS workflow_run_steps (
+  id          BIGSERIAL PRIMARY KEY,
+  run_id      BIGINT NOT NULL REFERENCES workflow_runs(id) ON DELETE CASCADE,
+  step        TEXT NOT NULL,
+  output      JSONB NOT NULL,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE (run_id, step)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS workflow_run_steps;
+DROP TABLE IF EXISTS workflow_runs;
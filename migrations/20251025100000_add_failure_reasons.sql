@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE scans ADD COLUMN IF NOT EXISTS failure_class VARCHAR(32);
+ALTER TABLE scans ADD COLUMN IF NOT EXISTS failure_reason TEXT;
+ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS error_class VARCHAR(32);
+
+-- +goose Down
+ALTER TABLE workflow_runs DROP COLUMN IF EXISTS error_class;
+ALTER TABLE scans DROP COLUMN IF EXISTS failure_reason;
+ALTER TABLE scans DROP COLUMN IF EXISTS failure_class;
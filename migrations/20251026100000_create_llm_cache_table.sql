@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS llm_cache (
+  key         CHAR(64) PRIMARY KEY,
+  call_type   VARCHAR(32) NOT NULL,
+  model       VARCHAR(64) NOT NULL,
+  response    TEXT NOT NULL,
+  hits        INT NOT NULL DEFAULT 0,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  expires_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_llm_cache_expires ON llm_cache (expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS llm_cache;
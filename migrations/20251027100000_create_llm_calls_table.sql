@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS llm_calls (
+  id                   BIGSERIAL PRIMARY KEY,
+  user_id              BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  site_id              BIGINT REFERENCES sites(id) ON DELETE CASCADE,
+  scan_id              BIGINT REFERENCES scans(id) ON DELETE SET NULL,
+  brand_analysis_id    BIGINT REFERENCES brand_analyses(id) ON DELETE SET NULL,
+  workflow_run_id      BIGINT REFERENCES workflow_runs(id) ON DELETE SET NULL,
+  call_type            VARCHAR(32) NOT NULL,
+  model                VARCHAR(64) NOT NULL,
+  input_tokens         BIGINT NOT NULL DEFAULT 0,
+  cached_input_tokens  BIGINT NOT NULL DEFAULT 0,
+  output_tokens        BIGINT NOT NULL DEFAULT 0,
+  web_search_calls     INT NOT NULL DEFAULT 0,
+  cost_usd             NUMERIC(12, 6) NOT NULL DEFAULT 0,
+  cache_hit            BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_llm_calls_user_created ON llm_calls (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_llm_calls_run ON llm_calls (workflow_run_id);
+
+ALTER TABLE scans ADD COLUMN IF NOT EXISTS usage JSONB;
+ALTER TABLE brand_analyses ADD COLUMN IF NOT EXISTS usage JSONB;
+
+-- +goose Down
+ALTER TABLE brand_analyses DROP COLUMN IF EXISTS usage;
+ALTER TABLE scans DROP COLUMN IF EXISTS usage;
+DROP TABLE IF EXISTS llm_calls;
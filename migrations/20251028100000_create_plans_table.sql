@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS plans (
+  key                    VARCHAR(32) PRIMARY KEY,
+  name                   TEXT NOT NULL,
+  -- NULL means unlimited
+  scans_per_month        INT,
+  max_queries_per_type   INT,
+  max_sites              INT,
+  max_concurrent_runs    INT,
+  created_at             TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at             TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO plans (key, name, scans_per_month, max_queries_per_type, max_sites, max_concurrent_runs) VALUES
+  ('free',     'Free',     10,   3,    1,    1),
+  ('pro',      'Pro',      200,  10,   10,   3),
+  ('business', 'Business', 2000, 25,   100,  10)
+ON CONFLICT (key) DO NOTHING;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS plan VARCHAR(32) NOT NULL DEFAULT 'free' REFERENCES plans(key);
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS plan;
+DROP TABLE IF EXISTS plans;
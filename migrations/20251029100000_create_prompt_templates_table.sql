@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS prompt_templates (
+  id               BIGSERIAL PRIMARY KEY,
+  name             VARCHAR(64) NOT NULL,
+  version          INT NOT NULL,
+  body             TEXT NOT NULL,
+  -- candidate: served to rollout_percent of runs; live: replaces the
+  -- built-in default; retired: kept for the record only
+  status           VARCHAR(16) NOT NULL DEFAULT 'candidate',
+  rollout_percent  INT NOT NULL DEFAULT 0 CHECK (rollout_percent BETWEEN 0 AND 100),
+  created_by       BIGINT REFERENCES users(id) ON DELETE SET NULL,
+  created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE (name, version)
+);
+
+ALTER TABLE scans ADD COLUMN IF NOT EXISTS prompt_versions JSONB;
+ALTER TABLE brand_analyses ADD COLUMN IF NOT EXISTS prompt_versions JSONB;
+ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS prompt_versions JSONB;
+
+-- +goose Down
+ALTER TABLE workflow_runs DROP COLUMN IF EXISTS prompt_versions;
+ALTER TABLE brand_analyses DROP COLUMN IF EXISTS prompt_versions;
+ALTER TABLE scans DROP COLUMN IF EXISTS prompt_versions;
+DROP TABLE IF EXISTS prompt_templates;
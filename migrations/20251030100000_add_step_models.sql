@@ -0,0 +1,13 @@
+-- +goose Up
+-- NULL allows overriding with any known model, [] allows no overrides
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS allowed_models JSONB;
+UPDATE plans SET allowed_models = '[]'::jsonb WHERE key = 'free';
+UPDATE plans SET allowed_models = '["gpt-4.1-mini", "gpt-4.1-nano", "gpt-4o-mini"]'::jsonb WHERE key = 'pro';
+
+ALTER TABLE scans ADD COLUMN IF NOT EXISTS step_models JSONB;
+ALTER TABLE brand_analyses ADD COLUMN IF NOT EXISTS step_models JSONB;
+
+-- +goose Down
+ALTER TABLE brand_analyses DROP COLUMN IF EXISTS step_models;
+ALTER TABLE scans DROP COLUMN IF EXISTS step_models;
+ALTER TABLE plans DROP COLUMN IF EXISTS allowed_models;
@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE brand_analyses ADD COLUMN IF NOT EXISTS samples INT NOT NULL DEFAULT 1;
+-- 95% intervals of the scores across samples, NULL when sampled once
+ALTER TABLE brand_analyses ADD COLUMN IF NOT EXISTS score_intervals JSONB;
+
+-- +goose Down
+ALTER TABLE brand_analyses DROP COLUMN IF EXISTS score_intervals;
+ALTER TABLE brand_analyses DROP COLUMN IF EXISTS samples;
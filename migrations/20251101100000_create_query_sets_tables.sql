@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS query_sets (
+  id           BIGSERIAL PRIMARY KEY,
+  user_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  site_id      BIGINT NOT NULL REFERENCES sites(id) ON DELETE CASCADE,
+  name         VARCHAR(255) NOT NULL,
+  description  TEXT,
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_query_sets_site ON query_sets (site_id);
+
+CREATE TABLE IF NOT EXISTS query_set_items (
+  id            BIGSERIAL PRIMARY KEY,
+  query_set_id  BIGINT NOT NULL REFERENCES query_sets(id) ON DELETE CASCADE,
+  query_type    VARCHAR(16) NOT NULL,
+  query         TEXT NOT NULL,
+  position      INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_query_set_items_set ON query_set_items (query_set_id, query_type, position);
+
+-- brand analyses run from a saved set
+ALTER TABLE brand_analyses ADD COLUMN IF NOT EXISTS query_set_id BIGINT REFERENCES query_sets(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE brand_analyses DROP COLUMN IF EXISTS query_set_id;
+DROP TABLE IF EXISTS query_set_items;
+DROP TABLE IF EXISTS query_sets;
@@ -0,0 +1,39 @@
+-- +goose Up
+-- primary market of the site; NULL country means no location context
+ALTER TABLE sites ADD COLUMN IF NOT EXISTS country VARCHAR(2);
+ALTER TABLE sites ADD COLUMN IF NOT EXISTS region VARCHAR(100);
+ALTER TABLE sites ADD COLUMN IF NOT EXISTS locale VARCHAR(35);
+
+CREATE TABLE IF NOT EXISTS site_markets (
+  id          BIGSERIAL PRIMARY KEY,
+  site_id     BIGINT NOT NULL REFERENCES sites(id) ON DELETE CASCADE,
+  country     VARCHAR(2) NOT NULL,
+  region      VARCHAR(100) NOT NULL DEFAULT '',
+  locale      VARCHAR(35) NOT NULL DEFAULT '',
+  position    INT NOT NULL DEFAULT 0,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE (site_id, country, region)
+);
+
+-- market a scan or brand analysis was run for
+ALTER TABLE scans ADD COLUMN IF NOT EXISTS country VARCHAR(2);
+ALTER TABLE scans ADD COLUMN IF NOT EXISTS region VARCHAR(100);
+ALTER TABLE scans ADD COLUMN IF NOT EXISTS locale VARCHAR(35);
+ALTER TABLE brand_analyses ADD COLUMN IF NOT EXISTS country VARCHAR(2);
+ALTER TABLE brand_analyses ADD COLUMN IF NOT EXISTS region VARCHAR(100);
+ALTER TABLE brand_analyses ADD COLUMN IF NOT EXISTS locale VARCHAR(35);
+
+CREATE INDEX IF NOT EXISTS idx_brand_analyses_site_market ON brand_analyses (site_id, country, region, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_brand_analyses_site_market;
+ALTER TABLE brand_analyses DROP COLUMN IF EXISTS locale;
+ALTER TABLE brand_analyses DROP COLUMN IF EXISTS region;
+ALTER TABLE brand_analyses DROP COLUMN IF EXISTS country;
+ALTER TABLE scans DROP COLUMN IF EXISTS locale;
+ALTER TABLE scans DROP COLUMN IF EXISTS region;
+ALTER TABLE scans DROP COLUMN IF EXISTS country;
+DROP TABLE IF EXISTS site_markets;
+ALTER TABLE sites DROP COLUMN IF EXISTS locale;
+ALTER TABLE sites DROP COLUMN IF EXISTS region;
+ALTER TABLE sites DROP COLUMN IF EXISTS country;
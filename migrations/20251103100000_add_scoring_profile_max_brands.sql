@@ -0,0 +1,6 @@
+-- +goose Up
+-- brands per query that count towards the brand workflow scores
+ALTER TABLE scoring_profiles ADD COLUMN IF NOT EXISTS max_brands_per_query INT NOT NULL DEFAULT 10;
+
+-- +goose Down
+ALTER TABLE scoring_profiles DROP COLUMN IF EXISTS max_brands_per_query;
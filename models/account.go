@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+const (
+	ExportStatusPending   = "pending"
+	ExportStatusRunning   = "running"
+	ExportStatusCompleted = "completed"
+	ExportStatusFailed    = "failed"
+)
+
+type AccountExport struct {
+	ID          int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UserID      int64      `json:"user_id" gorm:"column:user_id;not null"`
+	Status      string     `json:"status" gorm:"column:status;default:pending"`
+	ObjectKey   string     `json:"-" gorm:"column:object_key"`
+	Error       string     `json:"error,omitempty" gorm:"column:error"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" gorm:"column:completed_at"`
+}
+
+func (AccountExport) TableName() string { return "account_exports" }
+
+const (
+	DeletionStatusPending   = "pending"
+	DeletionStatusCancelled = "cancelled"
+	DeletionStatusCompleted = "completed"
+)
+
+type AccountDeletion struct {
+	ID           int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UserID       int64      `json:"user_id" gorm:"column:user_id;not null"`
+	Status       string     `json:"status" gorm:"column:status;default:pending"`
+	ScheduledFor time.Time  `json:"scheduled_for" gorm:"column:scheduled_for;not null"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty" gorm:"column:completed_at"`
+}
+
+func (AccountDeletion) TableName() string { return "account_deletions" }
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+const (
+	AlertKindThreshold          = "threshold"
+	AlertKindPercentDrop        = "pct_drop"
+	AlertKindCompetitorOvertake = "competitor_overtake"
+	AlertKindDirectDisappear    = "direct_disappear"
+)
+
+const (
+	AlertSourceAny   = "any"
+	AlertSourceScan  = "scan"
+	AlertSourceBrand = "brand"
+)
+
+const (
+	AlertChannelEmail   = "email"
+	AlertChannelWebhook = "webhook"
+	AlertChannelSlack   = "slack"
+)
+
+// AlertRule watches one site. Threshold is the visibility floor for
+// threshold rules and the percentage for pct_drop rules. Target is an email
+// address or a webhook URL depending on Channel.
+type AlertRule struct {
+	ID              int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UserID          int64      `json:"user_id" gorm:"column:user_id;not null"`
+	SiteID          int64      `json:"site_id" gorm:"column:site_id;not null"`
+	Kind            string     `json:"kind" gorm:"column:kind;not null"`
+	Source          string     `json:"source" gorm:"column:source;default:any"`
+	Threshold       float64    `json:"threshold" gorm:"column:threshold"`
+	CompetitorID    *int64     `json:"competitor_id,omitempty" gorm:"column:competitor_id"`
+	Channel         string     `json:"channel" gorm:"column:channel;not null"`
+	Target          string     `json:"target" gorm:"column:target;not null"`
+	Enabled         bool       `json:"enabled" gorm:"column:enabled"`
+	LastTriggeredAt *time.Time `json:"last_triggered_at,omitempty" gorm:"column:last_triggered_at"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt       time.Time  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (AlertRule) TableName() string { return "alert_rules" }
+
+type AlertEvent struct {
+	ID        int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	RuleID    int64     `json:"rule_id" gorm:"column:rule_id;not null"`
+	SiteID    int64     `json:"site_id" gorm:"column:site_id;not null"`
+	Source    string    `json:"source" gorm:"column:source;not null"`
+	SourceID  int64     `json:"source_id" gorm:"column:source_id;not null"`
+	Message   string    `json:"message" gorm:"column:message;not null"`
+	Payload   JSONB     `json:"payload" gorm:"column:payload;type:jsonb"`
+	Delivered bool      `json:"delivered" gorm:"column:delivered"`
+	Error     string    `json:"error,omitempty" gorm:"column:error"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (AlertEvent) TableName() string { return "alert_events" }
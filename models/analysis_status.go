@@ -0,0 +1,8 @@
+package models
+
+// Values of brand_analyses.status. Cancelled analyses hold the partial
+// results collected before the workflow was stopped.
+const (
+	AnalysisStatusCompleted = "completed"
+	AnalysisStatusCancelled = "cancelled"
+)
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type AuditEvent struct {
+	ID         int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ActorID    *int64    `json:"actor_id" gorm:"column:actor_id"`
+	Action     string    `json:"action" gorm:"column:action;not null"`
+	TargetType string    `json:"target_type,omitempty" gorm:"column:target_type"`
+	TargetID   string    `json:"target_id,omitempty" gorm:"column:target_id"`
+	IP         string    `json:"ip,omitempty" gorm:"column:ip"`
+	UserAgent  string    `json:"user_agent,omitempty" gorm:"column:user_agent"`
+	Metadata   JSONB     `json:"metadata" gorm:"column:metadata;type:jsonb"`
+	CreatedAt  time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (AuditEvent) TableName() string { return "audit_events" }
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+const (
+	CompetitorStatusSuggested = "suggested"
+	CompetitorStatusConfirmed = "confirmed"
+	CompetitorStatusIgnored   = "ignored"
+)
+
+type Competitor struct {
+	ID             int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	SiteID         int64     `json:"site_id" gorm:"column:site_id;not null"`
+	UserID         int64     `json:"user_id" gorm:"column:user_id;not null"`
+	Name           string    `json:"name" gorm:"column:name;not null"`
+	NormalizedName string    `json:"-" gorm:"column:normalized_name;not null"`
+	URL            string    `json:"url,omitempty" gorm:"column:url"`
+	Domain         string    `json:"domain,omitempty" gorm:"column:domain"`
+	Status         string    `json:"status" gorm:"column:status;default:suggested"`
+	FirstSeenAt    time.Time `json:"first_seen_at" gorm:"column:first_seen_at"`
+	LastSeenAt     time.Time `json:"last_seen_at" gorm:"column:last_seen_at"`
+	CreatedAt      time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt      time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (Competitor) TableName() string { return "competitors" }
+
+// CompetitorAppearance is one competitor showing up for one query of a
+// brand analysis. Won is set when it ranked ahead of the target site.
+type CompetitorAppearance struct {
+	ID              int64       `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	CompetitorID    int64       `json:"competitor_id" gorm:"column:competitor_id;not null"`
+	BrandAnalysisID int64       `json:"brand_analysis_id" gorm:"column:brand_analysis_id;not null"`
+	QueryType       string      `json:"query_type" gorm:"column:query_type"`
+	Query           string      `json:"query" gorm:"column:query"`
+	Position        int         `json:"position" gorm:"column:position"`
+	TargetPosition  int         `json:"target_position" gorm:"column:target_position"`
+	Won             bool        `json:"won" gorm:"column:won"`
+	Citations       StringArray `json:"citations" gorm:"column:citations;type:jsonb"`
+	CreatedAt       time.Time   `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (CompetitorAppearance) TableName() string { return "competitor_appearances" }
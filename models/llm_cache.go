@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// LLMCacheEntry is a stored model response, keyed by the SHA-256 of the
+// call type and the full request.
+type LLMCacheEntry struct {
+	Key       string    `json:"key" gorm:"column:key;primaryKey"`
+	CallType  string    `json:"call_type" gorm:"column:call_type;not null"`
+	Model     string    `json:"model" gorm:"column:model;not null"`
+	Response  string    `json:"-" gorm:"column:response;not null"`
+	Hits      int       `json:"hits" gorm:"column:hits"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"column:expires_at;not null"`
+}
+
+func (LLMCacheEntry) TableName() string { return "llm_cache" }
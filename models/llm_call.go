@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// LLMCall is one model request with its token usage and estimated cost.
+// Cache hits are recorded too, at zero cost.
+type LLMCall struct {
+	ID                int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UserID            int64     `json:"user_id" gorm:"column:user_id;not null"`
+	SiteID            *int64    `json:"site_id,omitempty" gorm:"column:site_id"`
+	ScanID            *int64    `json:"scan_id,omitempty" gorm:"column:scan_id"`
+	BrandAnalysisID   *int64    `json:"brand_analysis_id,omitempty" gorm:"column:brand_analysis_id"`
+	WorkflowRunID     *int64    `json:"workflow_run_id,omitempty" gorm:"column:workflow_run_id"`
+	CallType          string    `json:"call_type" gorm:"column:call_type;not null"`
+	Model             string    `json:"model" gorm:"column:model;not null"`
+	InputTokens       int64     `json:"input_tokens" gorm:"column:input_tokens"`
+	CachedInputTokens int64     `json:"cached_input_tokens" gorm:"column:cached_input_tokens"`
+	OutputTokens      int64     `json:"output_tokens" gorm:"column:output_tokens"`
+	WebSearchCalls    int       `json:"web_search_calls" gorm:"column:web_search_calls"`
+	CostUSD           float64   `json:"cost_usd" gorm:"column:cost_usd"`
+	CacheHit          bool      `json:"cache_hit" gorm:"column:cache_hit"`
+	CreatedAt         time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (LLMCall) TableName() string { return "llm_calls" }
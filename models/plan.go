@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const PlanFree = "free"
+
+// Plan holds the limits of a subscription tier. A nil limit is unlimited.
+type Plan struct {
+	Key               string `json:"key" gorm:"column:key;primaryKey"`
+	Name              string `json:"name" gorm:"column:name;not null"`
+	ScansPerMonth     *int   `json:"scans_per_month" gorm:"column:scans_per_month"`
+	MaxQueriesPerType *int   `json:"max_queries_per_type" gorm:"column:max_queries_per_type"`
+	MaxSites          *int   `json:"max_sites" gorm:"column:max_sites"`
+	MaxConcurrentRuns *int   `json:"max_concurrent_runs" gorm:"column:max_concurrent_runs"`
+	// AllowedModels may be chosen per request; nil allows any known model.
+	AllowedModels StringArray `json:"allowed_models" gorm:"column:allowed_models;type:jsonb"`
+	CreatedAt     time.Time   `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt     time.Time   `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (Plan) TableName() string { return "plans" }
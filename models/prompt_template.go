@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+const (
+	PromptStatusCandidate = "candidate"
+	PromptStatusLive      = "live"
+	PromptStatusRetired   = "retired"
+)
+
+// PromptTemplate is an admin-supplied version of a named prompt. Version 1
+// of every prompt is the built-in default.
+type PromptTemplate struct {
+	ID             int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Name           string    `json:"name" gorm:"column:name;not null"`
+	Version        int       `json:"version" gorm:"column:version;not null"`
+	Body           string    `json:"body" gorm:"column:body;not null"`
+	Status         string    `json:"status" gorm:"column:status;default:candidate"`
+	RolloutPercent int       `json:"rollout_percent" gorm:"column:rollout_percent"`
+	CreatedBy      *int64    `json:"created_by,omitempty" gorm:"column:created_by"`
+	CreatedAt      time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt      time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (PromptTemplate) TableName() string { return "prompt_templates" }
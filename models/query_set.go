@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// QuerySet is a saved list of queries for a site, reused across brand
+// analyses so their scores stay comparable.
+type QuerySet struct {
+	ID          int64          `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UserID      int64          `json:"user_id" gorm:"column:user_id;not null"`
+	SiteID      int64          `json:"site_id" gorm:"column:site_id;not null"`
+	Name        string         `json:"name" gorm:"column:name;not null"`
+	Description string         `json:"description,omitempty" gorm:"column:description"`
+	Items       []QuerySetItem `json:"items" gorm:"-"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (QuerySet) TableName() string { return "query_sets" }
+
+// QuerySetItem is one query of a set. QueryType is direct, intermediate or
+// indirect; Position keeps the order within a type.
+type QuerySetItem struct {
+	ID         int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	QuerySetID int64  `json:"query_set_id" gorm:"column:query_set_id;not null"`
+	QueryType  string `json:"query_type" gorm:"column:query_type;not null"`
+	Query      string `json:"query" gorm:"column:query;not null"`
+	Position   int    `json:"position" gorm:"column:position;not null"`
+}
+
+func (QuerySetItem) TableName() string { return "query_set_items" }
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type FloatMap map[string]float64
+
+func (m *FloatMap) Scan(value interface{}) error {
+	if value == nil {
+		*m = nil
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to scan FloatMap: %v", value)
+	}
+	return json.Unmarshal(b, m)
+}
+
+func (m FloatMap) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// ScoringProfile rows are immutable; editing a profile inserts the next
+// version so scans keep pointing at the weights that produced them.
+type ScoringProfile struct {
+	ID               int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UserID           int64     `json:"user_id" gorm:"column:user_id;not null"`
+	SiteID           *int64    `json:"site_id" gorm:"column:site_id"`
+	Version          int       `json:"version" gorm:"column:version;not null"`
+	RankWeights      FloatMap  `json:"rank_weights" gorm:"column:rank_weights;type:jsonb"`
+	TypeWeights      FloatMap  `json:"type_weights" gorm:"column:type_weights;type:jsonb"`
+	NormalizationCap float64   `json:"normalization_cap" gorm:"column:normalization_cap"`
+	MaxBrands        int       `json:"max_brands_per_query" gorm:"column:max_brands_per_query"`
+	CreatedAt        time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (ScoringProfile) TableName() string { return "scoring_profiles" }
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// SiteMarket is a country (optionally a region of it) a site sells in.
+// Position 0 is the primary market, mirrored on the sites row.
+type SiteMarket struct {
+	ID        int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	SiteID    int64     `json:"site_id" gorm:"column:site_id;not null"`
+	Country   string    `json:"country" gorm:"column:country;not null"`
+	Region    string    `json:"region,omitempty" gorm:"column:region"`
+	Locale    string    `json:"locale,omitempty" gorm:"column:locale"`
+	Position  int       `json:"position" gorm:"column:position;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (SiteMarket) TableName() string { return "site_markets" }
@@ -30,12 +30,21 @@ type User struct {
 	Email     string    `json:"email,omitempty" gorm:"column:email;uniqueIndex;not null"`
 	Fullname  string    `json:"full_name,omitempty" gorm:"column:full_name;"`
 	Password  string    `json:"password,omitempty" gorm:"column:password"`
+	Role      string    `json:"role,omitempty" gorm:"column:role;default:user"`
+	Plan      string    `json:"plan,omitempty" gorm:"column:plan;default:free"`
 	CreatedAt time.Time `json:"created_at,omitempty" gorm:"column:created_at;autoCreateTime"`
 	UpdatedAt time.Time `json:"updated_at,omitempty" gorm:"column:updated_at;autoUpdateTime"`
 }
 
 func (User) TableName() string { return "users" }
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+func (u User) IsAdmin() bool { return u.Role == RoleAdmin }
+
 type JSONB json.RawMessage
 
 func (j *JSONB) Scan(value interface{}) error {
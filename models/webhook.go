@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+type WebhookEndpoint struct {
+	ID        int64       `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UserID    int64       `json:"user_id" gorm:"column:user_id;not null"`
+	URL       string      `json:"url" gorm:"column:url;not null"`
+	Secret    string      `json:"-" gorm:"column:secret;not null"`
+	Events    StringArray `json:"events" gorm:"column:events;type:jsonb"`
+	Enabled   bool        `json:"enabled" gorm:"column:enabled"`
+	CreatedAt time.Time   `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time   `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (WebhookEndpoint) TableName() string { return "webhook_endpoints" }
+
+// WebhookDelivery is one event queued for one endpoint, retried until it
+// succeeds or runs out of attempts.
+type WebhookDelivery struct {
+	ID             int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	EndpointID     int64      `json:"endpoint_id" gorm:"column:endpoint_id;not null"`
+	Event          string     `json:"event" gorm:"column:event;not null"`
+	Payload        JSONB      `json:"payload" gorm:"column:payload;type:jsonb"`
+	Status         string     `json:"status" gorm:"column:status;default:pending"`
+	Attempts       int        `json:"attempts" gorm:"column:attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"column:next_attempt_at"`
+	LastStatusCode *int       `json:"last_status_code,omitempty" gorm:"column:last_status_code"`
+	LastError      string     `json:"last_error,omitempty" gorm:"column:last_error"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" gorm:"column:delivered_at"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (WebhookDelivery) TableName() string { return "webhook_deliveries" }
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+const (
+	RunStatusRunning   = "running"
+	RunStatusCompleted = "completed"
+	RunStatusFailed    = "failed"
+	RunStatusCancelled = "cancelled"
+)
+
+// WorkflowRun is one brand workflow execution. Failed runs keep their
+// completed steps and can be resumed.
+type WorkflowRun struct {
+	ID              int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UserID          int64      `json:"user_id" gorm:"column:user_id;not null"`
+	SiteID          int64      `json:"site_id" gorm:"column:site_id;not null"`
+	Kind            string     `json:"kind" gorm:"column:kind;not null"`
+	Status          string     `json:"status" gorm:"column:status;default:running"`
+	JobID           string     `json:"job_id,omitempty" gorm:"column:job_id"`
+	Config          JSONB      `json:"config" gorm:"column:config;type:jsonb"`
+	Error           string     `json:"error,omitempty" gorm:"column:error"`
+	ErrorClass      string     `json:"error_class,omitempty" gorm:"column:error_class"`
+	Attempts        int        `json:"attempts" gorm:"column:attempts;default:1"`
+	BrandAnalysisID *int64     `json:"brand_analysis_id,omitempty" gorm:"column:brand_analysis_id"`
+	PromptVersions  JSONB      `json:"prompt_versions,omitempty" gorm:"column:prompt_versions;type:jsonb"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt       time.Time  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty" gorm:"column:finished_at"`
+}
+
+func (WorkflowRun) TableName() string { return "workflow_runs" }
+
+// WorkflowRunStep is the stored output of one completed step, e.g. the
+// generated queries of a type or the research text of one query.
+type WorkflowRunStep struct {
+	ID        int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	RunID     int64     `json:"run_id" gorm:"column:run_id;not null"`
+	Step      string    `json:"step" gorm:"column:step;not null"`
+	Output    JSONB     `json:"output" gorm:"column:output;type:jsonb"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (WorkflowRunStep) TableName() string { return "workflow_run_steps" }